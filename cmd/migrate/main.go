@@ -0,0 +1,53 @@
+// Command migrate applies or reverts versioned database migrations, for deployments that
+// run the server with RUN_MIGRATIONS=false.
+//
+// Usage:
+//
+//	go run ./cmd/migrate              # apply all pending migrations
+//	go run ./cmd/migrate -to ID       # apply migrations up to ID
+//	go run ./cmd/migrate -down        # revert the last migration
+//	go run ./cmd/migrate -down -to ID # revert migrations applied after ID
+package main
+
+import (
+	"flag"
+	"log"
+
+	"event-ticketing-system/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	down := flag.Bool("down", false, "revert migrations instead of applying them")
+	to := flag.String("to", "", "migration ID to migrate or roll back to")
+	flag.Parse()
+
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: No .env file found or error loading it:", err)
+	}
+
+	db := database.InitDB()
+	if db == nil {
+		log.Fatal("Database connection is not available, cannot run migrations")
+	}
+	defer db.Close()
+
+	var err error
+	switch {
+	case *down && *to != "":
+		err = database.RollbackTo(db, *to)
+	case *down:
+		err = database.RollbackLast(db)
+	case *to != "":
+		err = database.MigrateTo(db, *to)
+	default:
+		err = database.Migrate(db)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	log.Println("Migrations completed successfully")
+}
@@ -0,0 +1,104 @@
+// Package apierror writes API errors in one envelope, {"error": {"code": ..., "message": ...}}.
+// Codes are stable so clients can branch on them; messages are for people and may change.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes shared by handlers and middleware
+const (
+	CodeInvalidRequestBody   = "INVALID_REQUEST_BODY"
+	CodeValidationFailed     = "VALIDATION_FAILED"
+	CodeInvalidID            = "INVALID_ID"
+	CodeInvalidParameter     = "INVALID_PARAMETER"
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInternal             = "INTERNAL_ERROR"
+	CodeDatabaseUnavailable  = "DATABASE_UNAVAILABLE"
+	CodeRateLimited          = "RATE_LIMITED"
+	CodeConflict             = "CONFLICT"
+	CodeAlreadyExists        = "ALREADY_EXISTS"
+
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	CodeTokenExpired        = "token_expired" // lowercase as clients read it before the envelope
+	CodeTokenInvalid        = "token_invalid"
+	CodeTokenRevoked        = "TOKEN_REVOKED"
+	CodeRefreshTokenInvalid = "REFRESH_TOKEN_INVALID"
+	CodeInvalidToken        = "INVALID_OR_EXPIRED_TOKEN"
+	CodeForbidden           = "FORBIDDEN"
+	CodeStepUpRequired      = "STEP_UP_REQUIRED"
+	CodeActiveEventLimit    = "ACTIVE_EVENT_LIMIT_REACHED"
+	CodeEmailAlreadyExists  = "EMAIL_ALREADY_EXISTS"
+
+	CodeEventNotFound       = "EVENT_NOT_FOUND"
+	CodeTicketNotFound      = "TICKET_NOT_FOUND"
+	CodeTicketTypeNotFound  = "TICKET_TYPE_NOT_FOUND"
+	CodeOrderNotFound       = "ORDER_NOT_FOUND"
+	CodeUserNotFound        = "USER_NOT_FOUND"
+	CodeCustomFieldNotFound = "CUSTOM_FIELD_NOT_FOUND"
+	CodeWebhookNotFound     = "WEBHOOK_NOT_FOUND"
+	CodeJobNotFound         = "JOB_NOT_FOUND"
+	CodeNotFound            = "NOT_FOUND"
+
+	CodeEventCancelled       = "EVENT_CANCELLED"
+	CodeEventPast            = "EVENT_PAST"
+	CodeCapacityExceeded     = "CAPACITY_EXCEEDED"
+	CodePurchaseLimit        = "PURCHASE_LIMIT_EXCEEDED"
+	CodeSeatRequired         = "SEAT_REQUIRED"
+	CodeSeatUnavailable      = "SEAT_UNAVAILABLE"
+	CodeTicketsAvailable     = "TICKETS_AVAILABLE"
+	CodeAlreadyWaitlisted    = "ALREADY_WAITLISTED"
+	CodeTicketTypeRequired   = "TICKET_TYPE_REQUIRED"
+	CodeInvalidPromoCode     = "INVALID_PROMO_CODE"
+	CodePaymentTokenRequired = "PAYMENT_TOKEN_REQUIRED"
+	CodePaymentDeclined      = "PAYMENT_DECLINED"
+	CodePaymentFailed        = "PAYMENT_FAILED"
+	CodeOrderNotPending      = "ORDER_NOT_PENDING"
+	CodeTicketNotValid       = "TICKET_NOT_VALID"
+	CodeQRCodeInvalid        = "QR_CODE_INVALID"
+	CodeTicketNoLongerValid  = "TICKET_NO_LONGER_VALID"
+	CodeTicketNotCheckedIn   = "TICKET_NOT_CHECKED_IN"
+	CodeReentryNotAllowed    = "REENTRY_NOT_ALLOWED"
+	CodeTransferCooldown     = "TRANSFER_COOLDOWN"
+	CodeTransferLimitReached = "TRANSFER_LIMIT_REACHED"
+)
+
+// Error is the body of the error envelope
+type Error struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"` // set on validation errors
+}
+
+// FieldError names a request field that failed validation and why
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Envelope is the JSON document every error response carries
+type Envelope struct {
+	Error Error `json:"error"`
+}
+
+// New builds an error for responses that carry fields next to the envelope
+func New(code, message string) Error {
+	return Error{Code: code, Message: message}
+}
+
+// Respond writes status and the error envelope as JSON
+func Respond(w http.ResponseWriter, status int, code, message string) {
+	RespondFields(w, status, code, message, nil)
+}
+
+// RespondFields writes status and the error envelope as JSON, listing the fields that failed
+func RespondFields(w http.ResponseWriter, status int, code, message string, fields []FieldError) {
+	body := New(code, message)
+	body.Fields = fields
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Envelope{Error: body})
+}
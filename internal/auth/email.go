@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"strings"
+
+	"event-ticketing-system/internal/config"
+)
+
+// Email canonicalization modes, from least to most aggressive
+const (
+	CanonicalizeOff    = "off"    // lowercase only
+	CanonicalizePlus   = "plus"   // also strip +tag from the local part
+	CanonicalizeGmail  = "gmail"  // also strip dots for gmail.com and googlemail.com
+	CanonicalizeStrict = "strict" // strip +tag and dots for every domain
+)
+
+// gmailDomains lists the domains known to ignore dots in the local part
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// EmailCanonicalizationMode returns the configured canonicalization mode
+func EmailCanonicalizationMode() string {
+	switch mode := config.GetEnv("EMAIL_CANONICALIZATION", CanonicalizeOff); mode {
+	case CanonicalizePlus, CanonicalizeGmail, CanonicalizeStrict:
+		return mode
+	default:
+		return CanonicalizeOff
+	}
+}
+
+// CanonicalEmail returns the canonical form of an email address used for duplicate checks.
+// The original address is still the one stored and used for delivery.
+func CanonicalEmail(email, mode string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || mode == CanonicalizeOff {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+
+	if mode == CanonicalizeStrict || (mode == CanonicalizeGmail && gmailDomains[domain]) {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+
+	if mode == CanonicalizeGmail && domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
@@ -0,0 +1,47 @@
+package auth
+
+import "testing"
+
+func TestCanonicalEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		mode  string
+		want  string
+	}{
+		{name: "off only lowercases", email: " User.Name+1@Gmail.com ", mode: CanonicalizeOff, want: "user.name+1@gmail.com"},
+		{name: "plus strips the tag", email: "user+1@example.com", mode: CanonicalizePlus, want: "user@example.com"},
+		{name: "plus keeps dots", email: "user.name+2@gmail.com", mode: CanonicalizePlus, want: "user.name@gmail.com"},
+		{name: "gmail strips tag and dots", email: "u.s.e.r+promo@gmail.com", mode: CanonicalizeGmail, want: "user@gmail.com"},
+		{name: "gmail folds googlemail", email: "user.name@googlemail.com", mode: CanonicalizeGmail, want: "username@gmail.com"},
+		{name: "gmail keeps dots elsewhere", email: "user.name+1@example.com", mode: CanonicalizeGmail, want: "user.name@example.com"},
+		{name: "strict strips dots everywhere", email: "user.name+1@example.com", mode: CanonicalizeStrict, want: "username@example.com"},
+		{name: "no local part is left alone", email: "@example.com", mode: CanonicalizeStrict, want: "@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalEmail(tt.email, tt.mode); got != tt.want {
+				t.Errorf("CanonicalEmail(%q, %q) = %q, want %q", tt.email, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalEmailVariantsMatch(t *testing.T) {
+	variants := []string{"user@gmail.com", "user+1@gmail.com", "user+2@gmail.com", "u.ser@gmail.com", "U.S.E.R+tag@GoogleMail.com"}
+	for _, variant := range variants {
+		if got := CanonicalEmail(variant, CanonicalizeGmail); got != "user@gmail.com" {
+			t.Errorf("CanonicalEmail(%q) = %q, want user@gmail.com", variant, got)
+		}
+	}
+}
+
+func TestEmailCanonicalizationMode(t *testing.T) {
+	for value, want := range map[string]string{"": CanonicalizeOff, "plus": CanonicalizePlus, "gmail": CanonicalizeGmail, "strict": CanonicalizeStrict, "aggressive": CanonicalizeOff} {
+		t.Setenv("EMAIL_CANONICALIZATION", value)
+		if got := EmailCanonicalizationMode(); got != want {
+			t.Errorf("EMAIL_CANONICALIZATION=%q gives mode %q, want %q", value, got, want)
+		}
+	}
+}
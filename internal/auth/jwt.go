@@ -2,30 +2,81 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"time"
 
+	"event-ticketing-system/internal/config"
 	"event-ticketing-system/internal/models"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/jinzhu/gorm"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte("your-secret-key-change-this-in-production")
+// minSecretLength is the shortest JWT_SECRET accepted for signing tokens
+const minSecretLength = 32
+
+// jwtKey is the token signing key, set once at startup by LoadSecret
+var jwtKey []byte
+
+// LoadSecret reads the token signing key from JWT_SECRET. It must be called before any token
+// is generated or validated, and fails when the secret is missing or too short.
+func LoadSecret() error {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return errors.New("JWT_SECRET environment variable is required but not set")
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("JWT_SECRET must be at least %d bytes long", minSecretLength)
+	}
+
+	jwtKey = []byte(secret)
+	return nil
+}
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID   uint   `json:"user_id"`
+	Role     string `json:"role"`
+	Elevated bool   `json:"elevated,omitempty"` // set on step-up tokens issued after re-entering the password
 	jwt.StandardClaims
 }
 
 // GenerateToken generates a JWT token for a user
 func GenerateToken(user models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+	return generateToken(user, 24*time.Hour, false) // Token valid for 24 hours
+}
+
+// StepUpTokenTTL returns how long elevated tokens stay valid
+func StepUpTokenTTL() time.Duration {
+	return time.Duration(config.GetInt("STEP_UP_TOKEN_TTL_MINUTES", 5)) * time.Minute
+}
+
+// StepUpRequired reports whether destructive admin actions need an elevated token
+func StepUpRequired() bool {
+	return config.GetEnv("REQUIRE_STEP_UP", "false") == "true"
+}
+
+// GenerateElevatedToken generates a short-lived token carrying the elevated claim
+func GenerateElevatedToken(user models.User) (string, error) {
+	return generateToken(user, StepUpTokenTTL(), true)
+}
+
+func generateToken(user models.User, ttl time.Duration, elevated bool) (string, error) {
+	expirationTime := time.Now().Add(ttl)
+
+	// A unique jti lets the token be revoked on logout
+	tokenID, err := GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
 
 	claims := &Claims{
-		UserID: user.ID,
-		Role:   user.Role,
+		UserID:   user.ID,
+		Role:     user.Role,
+		Elevated: elevated,
 		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID,
 			ExpiresAt: expirationTime.Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
@@ -40,25 +91,160 @@ func GenerateToken(user models.User) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token string
+// ErrTokenExpired and ErrTokenInvalid distinguish a token that only needs refreshing from
+// one that can never be accepted
+var (
+	ErrTokenExpired = errors.New("token has expired")
+	ErrTokenInvalid = errors.New("invalid token")
+)
+
+// Valid checks the standard claims, allowing JWT_EXPIRY_LEEWAY_SECONDS of clock skew on expiry
+func (c *Claims) Valid() error {
+	leeway := int64(config.GetInt("JWT_EXPIRY_LEEWAY_SECONDS", 0))
+	if c.ExpiresAt != 0 && time.Now().Unix() > c.ExpiresAt+leeway {
+		return &jwt.ValidationError{Inner: ErrTokenExpired, Errors: jwt.ValidationErrorExpired}
+	}
+
+	standard := c.StandardClaims
+	standard.ExpiresAt = 0
+	return standard.Valid()
+}
+
+// ValidateToken validates a JWT token string, returning ErrTokenExpired for expired tokens
+// and ErrTokenInvalid for malformed, tampered or otherwise unacceptable ones
 func ValidateToken(tokenString string) (*jwt.Token, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrTokenInvalid
+		}
 		return jwtKey, nil
 	})
 
 	if err != nil {
-		return nil, err
+		// Expiry is checked before the signature, so only a token failing on expiry alone is
+		// genuine and worth refreshing
+		if validationErr, ok := err.(*jwt.ValidationError); ok && validationErr.Errors == jwt.ValidationErrorExpired {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrTokenInvalid
 	}
 
 	if !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, ErrTokenInvalid
+	}
+
+	return token, nil
+}
+
+// blacklistKey identifies an access token in the blacklist by its jti, falling back to the
+// hash of the token for tokens issued before the claim existed
+func blacklistKey(claims *Claims, tokenString string) string {
+	if claims.Id != "" {
+		return claims.Id
+	}
+	return HashToken(tokenString)
+}
+
+// BlacklistToken revokes an access token until it expires. Revoking a token twice is not an
+// error.
+func BlacklistToken(db *gorm.DB, claims *Claims, tokenString string) error {
+	key := blacklistKey(claims, tokenString)
+
+	var existing models.BlacklistedToken
+	err := db.Where("token_id = ?", key).First(&existing).Error
+	if err == nil {
+		return nil
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+
+	record := models.BlacklistedToken{
+		TokenID:   key,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}
+	return db.Create(&record).Error
+}
+
+// IsTokenBlacklisted reports whether an access token was revoked on logout
+func IsTokenBlacklisted(db *gorm.DB, claims *Claims, tokenString string) (bool, error) {
+	var count int
+	if err := db.Model(&models.BlacklistedToken{}).Where("token_id = ?", blacklistKey(claims, tokenString)).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ErrRefreshTokenInvalid is returned for refresh tokens that are unknown, revoked or expired
+var ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+
+// RefreshTokenTTL returns how long refresh tokens stay valid
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(config.GetInt("REFRESH_TOKEN_TTL_HOURS", 720)) * time.Hour
+}
+
+// GenerateRefreshToken creates a refresh token for a user, storing only its hash
+func GenerateRefreshToken(db *gorm.DB, user models.User) (string, error) {
+	token, err := GenerateSecureToken()
+	if err != nil {
+		return "", err
 	}
 
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: HashToken(token),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return "", err
+	}
 	return token, nil
 }
 
+// ValidateRefreshToken returns the stored record of a refresh token that is neither revoked
+// nor expired, or ErrRefreshTokenInvalid
+func ValidateRefreshToken(db *gorm.DB, token string) (models.RefreshToken, error) {
+	var record models.RefreshToken
+	if err := db.Where("token_hash = ? AND revoked_at IS NULL", HashToken(token)).First(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return record, ErrRefreshTokenInvalid
+		}
+		return record, err
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		return record, ErrRefreshTokenInvalid
+	}
+	return record, nil
+}
+
+// RevokeRefreshToken revokes a refresh token so it can no longer be exchanged. It returns
+// ErrRefreshTokenInvalid when the token was not outstanding, so of two requests revoking the
+// same token only one succeeds.
+func RevokeRefreshToken(db *gorm.DB, token string) error {
+	now := time.Now()
+	result := db.Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", HashToken(token), now).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return ErrRefreshTokenInvalid
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every outstanding refresh token of a user, ending all of
+// their sessions once the current access tokens expire
+func RevokeUserRefreshTokens(db *gorm.DB, userID uint) error {
+	return db.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
 // HashPassword hashes a password using bcrypt
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -69,4 +255,4 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
-}
\ No newline at end of file
+}
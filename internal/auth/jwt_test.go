@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"testing"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestLoadSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "missing", secret: "", wantErr: true},
+		{name: "too short", secret: "short-secret", wantErr: true},
+		{name: "long enough", secret: "a-signing-secret-of-at-least-32-bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", tt.secret)
+			if err := LoadSecret(); (err != nil) != tt.wantErr {
+				t.Errorf("LoadSecret() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenSignedWithEnvironmentSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "first-signing-secret-of-32-bytes!")
+	if err := LoadSecret(); err != nil {
+		t.Fatalf("LoadSecret: %v", err)
+	}
+
+	token, err := GenerateToken(models.User{ID: 7, Role: "organizer"})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	parsed, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims := parsed.Claims.(*Claims); claims.UserID != 7 || claims.Role != "organizer" || claims.Id == "" {
+		t.Fatalf("claims = %+v, want user 7 as an organizer with a token ID", claims)
+	}
+
+	// A server started with another secret does not accept the token
+	t.Setenv("JWT_SECRET", "second-signing-secret-of-32-bytes")
+	if err := LoadSecret(); err != nil {
+		t.Fatalf("LoadSecret: %v", err)
+	}
+	if _, err := ValidateToken(token); err != ErrTokenInvalid {
+		t.Fatalf("ValidateToken with another secret returned %v, want ErrTokenInvalid", err)
+	}
+}
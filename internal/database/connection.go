@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
+
+	"event-ticketing-system/internal/config"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
@@ -32,8 +35,9 @@ func InitDB() *gorm.DB {
 		log.Println("Using individual environment variables for database connection")
 	}
 
-	// Connect to database
-	db, err := gorm.Open("postgres", dsn)
+	// Connect to database, retrying while it is still starting up
+	db, err := connectWithRetry(dsn, config.GetInt("DB_CONNECT_MAX_ATTEMPTS", 5),
+		time.Duration(config.GetInt("DB_CONNECT_RETRY_BASE_MS", 1000))*time.Millisecond)
 	if err != nil {
 		log.Printf("Failed to connect to database: %v", err)
 		log.Println("Warning: Database connection failed. Some features may not work properly.")
@@ -41,18 +45,48 @@ func InitDB() *gorm.DB {
 		return nil
 	}
 
-	// Test the connection
-	if err := db.DB().Ping(); err != nil {
-		log.Printf("Failed to ping database: %v", err)
-		log.Println("Warning: Database ping failed. Some features may not work properly.")
-		log.Println("Continuing without database connection for testing purposes...")
-		return nil
-	}
-
 	log.Println("Database connected successfully")
 	return db
 }
 
+// maxConnectRetryDelay caps the wait between connection attempts
+const maxConnectRetryDelay = 30 * time.Second
+
+// connectWithRetry opens and pings the database, retrying up to maxAttempts times with a delay
+// doubling from baseDelay. It returns the last error once the attempts are used up.
+func connectWithRetry(dsn string, maxAttempts int, baseDelay time.Duration) (*gorm.DB, error) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	delay := baseDelay
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var db *gorm.DB
+		db, err = gorm.Open("postgres", dsn)
+		if err == nil {
+			// Test the connection
+			if err = db.DB().Ping(); err == nil {
+				return db, nil
+			}
+			db.Close()
+		}
+
+		log.Printf("Database connection attempt %d/%d failed: %v", attempt, maxAttempts, err)
+		if attempt == maxAttempts {
+			break
+		}
+
+		log.Printf("Retrying database connection in %s", delay)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > maxConnectRetryDelay {
+			delay = maxConnectRetryDelay
+		}
+	}
+	return nil, err
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+// IsUniqueViolation reports whether err was caused by a unique constraint violation
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return strings.Contains(err.Error(), "duplicate key value violates unique constraint")
+}
+
+// CheckQRCodeIntegrity logs any QR payload shared by more than one ticket, which would make
+// validation ambiguous. Duplicates can only appear if the unique index is missing or was
+// bypassed, e.g. by an import.
+func CheckQRCodeIntegrity(db *gorm.DB) {
+	type duplicate struct {
+		Count     int
+		TicketIDs string
+	}
+
+	var duplicates []duplicate
+	if err := db.Table("tickets").
+		Select("COUNT(*) AS count, string_agg(id::text, ',') AS ticket_ids").
+		Group("qr_code").
+		Having("COUNT(*) > 1").
+		Scan(&duplicates).Error; err != nil {
+		log.Printf("Failed to check QR code integrity: %v", err)
+		return
+	}
+
+	for _, d := range duplicates {
+		log.Printf("Warning: %d tickets share the same QR payload (ticket IDs: %s)", d.Count, d.TicketIDs)
+	}
+	if len(duplicates) == 0 {
+		log.Println("QR code integrity check passed")
+	}
+}
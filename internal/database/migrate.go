@@ -0,0 +1,64 @@
+package database
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+	"gopkg.in/gormigrate.v1"
+)
+
+// ShouldRunMigrations reports whether the server should migrate the schema on startup.
+// It defaults to true for development; production deployments should set RUN_MIGRATIONS=false
+// and run cmd/migrate explicitly instead.
+func ShouldRunMigrations() bool {
+	value := os.Getenv("RUN_MIGRATIONS")
+	if value == "" {
+		return true
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return true
+	}
+	return enabled
+}
+
+// MigrateOnStartup applies pending migrations unless ShouldRunMigrations turns them off,
+// reporting whether they ran
+func MigrateOnStartup(db *gorm.DB) (bool, error) {
+	if !ShouldRunMigrations() {
+		return false, nil
+	}
+	return true, Migrate(db)
+}
+
+// newMigrator creates the versioned migrator over the registered migrations
+func newMigrator(db *gorm.DB) *gormigrate.Gormigrate {
+	return gormigrate.New(db, &gormigrate.Options{
+		TableName:      "schema_migrations",
+		IDColumnName:   "id",
+		IDColumnSize:   255,
+		UseTransaction: true,
+	}, migrations)
+}
+
+// Migrate applies all pending migrations
+func Migrate(db *gorm.DB) error {
+	return newMigrator(db).Migrate()
+}
+
+// MigrateTo applies pending migrations up to and including the given migration ID
+func MigrateTo(db *gorm.DB, migrationID string) error {
+	return newMigrator(db).MigrateTo(migrationID)
+}
+
+// RollbackLast reverts the most recently applied migration
+func RollbackLast(db *gorm.DB) error {
+	return newMigrator(db).RollbackLast()
+}
+
+// RollbackTo reverts migrations applied after the given migration ID
+func RollbackTo(db *gorm.DB, migrationID string) error {
+	return newMigrator(db).RollbackTo(migrationID)
+}
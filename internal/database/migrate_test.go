@@ -0,0 +1,86 @@
+package database
+
+import "testing"
+
+func TestShouldRunMigrations(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "1": true, "false": false, "0": false, "not-a-bool": true} {
+		t.Setenv("RUN_MIGRATIONS", value)
+		if got := ShouldRunMigrations(); got != want {
+			t.Errorf("RUN_MIGRATIONS=%q gives %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestMigrateOnStartupSkippedWhenDisabled(t *testing.T) {
+	db := openEmptyTestDB(t)
+	t.Setenv("RUN_MIGRATIONS", "false")
+
+	ran, err := MigrateOnStartup(db)
+	if err != nil || ran {
+		t.Fatalf("MigrateOnStartup ran %v with error %v, want it skipped", ran, err)
+	}
+	if db.HasTable("schema_migrations") || db.HasTable("users") {
+		t.Fatal("migrations ran with RUN_MIGRATIONS=false")
+	}
+
+	t.Setenv("RUN_MIGRATIONS", "true")
+	if ran, err := MigrateOnStartup(db); err != nil || !ran {
+		t.Fatalf("MigrateOnStartup ran %v with error %v, want it to migrate", ran, err)
+	}
+	if !db.HasTable("users") {
+		t.Fatal("migrations did not create the users table")
+	}
+}
+
+func TestMigrationsApplyToEmptyDatabase(t *testing.T) {
+	db := openEmptyTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	var applied int
+	if err := db.Table("schema_migrations").Count(&applied).Error; err != nil {
+		t.Fatalf("count applied migrations: %v", err)
+	}
+	if applied != len(migrations) {
+		t.Fatalf("%d migrations recorded, want %d", applied, len(migrations))
+	}
+	for _, table := range []string{"users", "events", "tickets", "orders", "attendance_logs"} {
+		if !db.HasTable(table) {
+			t.Errorf("table %s was not created", table)
+		}
+	}
+
+	// Migrating again is a no-op
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+}
+
+func TestMigrationsRollBackAndReapply(t *testing.T) {
+	db := openEmptyTestDB(t)
+	first := migrations[0].ID
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := RollbackTo(db, first); err != nil {
+		t.Fatalf("RollbackTo(%s): %v", first, err)
+	}
+	var applied int
+	db.Table("schema_migrations").Count(&applied)
+	if applied != 1 {
+		t.Fatalf("%d migrations recorded after rolling back to the initial schema, want 1", applied)
+	}
+
+	if err := RollbackLast(db); err != nil {
+		t.Fatalf("RollbackLast: %v", err)
+	}
+	if db.HasTable("users") || db.HasTable("events") {
+		t.Fatal("rolling back the initial schema left its tables behind")
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate after a full rollback: %v", err)
+	}
+}
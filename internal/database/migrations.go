@@ -0,0 +1,842 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"event-ticketing-system/pkg/utils"
+
+	"github.com/jinzhu/gorm"
+	"gopkg.in/gormigrate.v1"
+)
+
+// migrations lists every schema change in the order it must be applied. Each migration
+// declares the table shapes it needs as local snapshot structs so later model changes
+// never alter what an old migration does. Append new migrations at the end; never edit
+// one that has already been released.
+var migrations = []*gormigrate.Migration{
+	{
+		ID: "202610140001_initial_schema",
+		Migrate: func(tx *gorm.DB) error {
+			type user struct {
+				ID            uint   `gorm:"primary_key"`
+				Name          string `gorm:"not null"`
+				Email         string `gorm:"unique;not null"`
+				Password      string `gorm:"not null"`
+				Role          string `gorm:"default:'user'"`
+				EmailVerified bool   `gorm:"default:false"`
+				CreatedAt     time.Time
+				UpdatedAt     time.Time
+			}
+			type event struct {
+				ID           uint      `gorm:"primary_key"`
+				Title        string    `gorm:"not null"`
+				Description  string    `gorm:"not null"`
+				Date         time.Time `gorm:"not null"`
+				Location     string    `gorm:"not null"`
+				Capacity     int       `gorm:"not null"`
+				Price        float64   `gorm:"not null"`
+				OrganizerID  uint      `gorm:"index"`
+				MaxTransfers int       `gorm:"default:0"`
+				Status       string    `gorm:"default:'active'"`
+				CreatedAt    time.Time
+				UpdatedAt    time.Time
+			}
+			type ticket struct {
+				ID        uint   `gorm:"primary_key"`
+				EventID   uint   `gorm:"not null"`
+				UserID    uint   `gorm:"not null"`
+				QRCode    string `gorm:"unique;not null"`
+				Status    string `gorm:"default:'valid'"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			type attendanceLog struct {
+				ID          uint      `gorm:"primary_key"`
+				TicketID    uint      `gorm:"not null"`
+				CheckedInAt time.Time `gorm:"not null"`
+				CreatedAt   time.Time
+				UpdatedAt   time.Time
+			}
+			type ticketTransfer struct {
+				ID         uint `gorm:"primary_key"`
+				TicketID   uint `gorm:"not null;index"`
+				FromUserID uint `gorm:"not null"`
+				ToUserID   uint `gorm:"not null"`
+				CreatedAt  time.Time
+			}
+			type emailVerificationToken struct {
+				ID        uint      `gorm:"primary_key"`
+				UserID    uint      `gorm:"not null;index"`
+				TokenHash string    `gorm:"unique;not null"`
+				ExpiresAt time.Time `gorm:"not null"`
+				UsedAt    *time.Time
+				CreatedAt time.Time
+			}
+			type auditLog struct {
+				ID         uint `gorm:"primary_key"`
+				ActorID    uint
+				Action     string `gorm:"not null"`
+				EntityType string `gorm:"not null;index:idx_audit_entity"`
+				EntityID   uint   `gorm:"not null;index:idx_audit_entity"`
+				Details    string
+				CreatedAt  time.Time
+			}
+
+			tables := []struct {
+				name  string
+				model interface{}
+			}{
+				{"users", &user{}},
+				{"events", &event{}},
+				{"tickets", &ticket{}},
+				{"attendance_logs", &attendanceLog{}},
+				{"ticket_transfers", &ticketTransfer{}},
+				{"email_verification_tokens", &emailVerificationToken{}},
+				{"audit_logs", &auditLog{}},
+			}
+			for _, table := range tables {
+				if err := tx.Table(table.name).AutoMigrate(table.model).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(
+				"audit_logs",
+				"email_verification_tokens",
+				"ticket_transfers",
+				"attendance_logs",
+				"tickets",
+				"events",
+				"users",
+			).Error
+		},
+	},
+	{
+		ID: "202610140002_user_canonical_email",
+		Migrate: func(tx *gorm.DB) error {
+			type user struct {
+				CanonicalEmail string `gorm:"index"`
+			}
+			if err := tx.Table("users").AutoMigrate(&user{}).Error; err != nil {
+				return err
+			}
+			// Existing accounts start with the lowercased address as their canonical form
+			return tx.Exec("UPDATE users SET canonical_email = LOWER(email) WHERE canonical_email IS NULL OR canonical_email = ''").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("users").DropColumn("canonical_email").Error
+		},
+	},
+	{
+		ID: "202610140003_purchase_failures",
+		Migrate: func(tx *gorm.DB) error {
+			type purchaseFailure struct {
+				ID        uint   `gorm:"primary_key"`
+				EventID   uint   `gorm:"not null;index"`
+				UserID    uint   `gorm:"not null"`
+				Quantity  int    `gorm:"not null"`
+				Reason    string `gorm:"not null"`
+				CreatedAt time.Time
+			}
+			return tx.Table("purchase_failures").AutoMigrate(&purchaseFailure{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("purchase_failures").Error
+		},
+	},
+	{
+		ID: "202610140004_ticket_door_payment",
+		Migrate: func(tx *gorm.DB) error {
+			type ticket struct {
+				DoorPaymentAmount *float64
+				DoorPaymentMethod string
+				DoorPaidAt        *time.Time
+			}
+			return tx.Table("tickets").AutoMigrate(&ticket{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			for _, column := range []string{"door_payment_amount", "door_payment_method", "door_paid_at"} {
+				if err := tx.Table("tickets").DropColumn(column).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID: "202610140005_reserved_tickets",
+		Migrate: func(tx *gorm.DB) error {
+			// Reserved tickets are created before they have a holder
+			if err := tx.Exec("ALTER TABLE tickets ALTER COLUMN user_id DROP NOT NULL").Error; err != nil {
+				return err
+			}
+
+			type ticket struct {
+				SerialNumber *int
+			}
+			if err := tx.Table("tickets").AutoMigrate(&ticket{}).Error; err != nil {
+				return err
+			}
+			return tx.Table("tickets").AddUniqueIndex("idx_ticket_serial", "event_id", "serial_number").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Exec("DELETE FROM tickets WHERE user_id IS NULL").Error; err != nil {
+				return err
+			}
+			if err := tx.Table("tickets").RemoveIndex("idx_ticket_serial").Error; err != nil {
+				return err
+			}
+			if err := tx.Table("tickets").DropColumn("serial_number").Error; err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE tickets ALTER COLUMN user_id SET NOT NULL").Error
+		},
+	},
+	{
+		ID: "202610140006_event_sold_count",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				SoldCount int `gorm:"not null;default:0"`
+			}
+			if err := tx.Table("events").AutoMigrate(&event{}).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE events SET sold_count = (SELECT COUNT(*) FROM tickets WHERE tickets.event_id = events.id)").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("sold_count").Error
+		},
+	},
+	{
+		ID: "202610140007_orders",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				ID        uint `gorm:"primary_key"`
+				UserID    uint `gorm:"not null;index"`
+				EventID   uint `gorm:"not null;index"`
+				Quantity  int  `gorm:"not null"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			if err := tx.Table("orders").AutoMigrate(&order{}).Error; err != nil {
+				return err
+			}
+
+			type ticket struct {
+				OrderID *uint `gorm:"index"`
+			}
+			return tx.Table("tickets").AutoMigrate(&ticket{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("tickets").DropColumn("order_id").Error; err != nil {
+				return err
+			}
+			return tx.DropTableIfExists("orders").Error
+		},
+	},
+	{
+		ID: "202610140008_ticket_types",
+		Migrate: func(tx *gorm.DB) error {
+			type ticketType struct {
+				ID        uint    `gorm:"primary_key"`
+				EventID   uint    `gorm:"not null;index"`
+				Name      string  `gorm:"not null"`
+				Price     float64 `gorm:"not null"`
+				Capacity  int     `gorm:"not null"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			if err := tx.Table("ticket_types").AutoMigrate(&ticketType{}).Error; err != nil {
+				return err
+			}
+
+			type ticket struct {
+				TicketTypeID *uint `gorm:"index"`
+			}
+			return tx.Table("tickets").AutoMigrate(&ticket{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("tickets").DropColumn("ticket_type_id").Error; err != nil {
+				return err
+			}
+			return tx.DropTableIfExists("ticket_types").Error
+		},
+	},
+	{
+		ID: "202610140009_user_invites_opt_out",
+		Migrate: func(tx *gorm.DB) error {
+			type user struct {
+				InvitesOptOut bool `gorm:"not null;default:false"`
+			}
+			return tx.Table("users").AutoMigrate(&user{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("users").DropColumn("invites_opt_out").Error
+		},
+	},
+	{
+		ID: "202610140010_receipt_amounts",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				Discount float64 `gorm:"not null;default:0"`
+				TaxRate  float64 `gorm:"not null;default:0"`
+			}
+			if err := tx.Table("orders").AutoMigrate(&order{}).Error; err != nil {
+				return err
+			}
+
+			type ticket struct {
+				PricePaid float64 `gorm:"not null;default:0"`
+			}
+			if err := tx.Table("tickets").AutoMigrate(&ticket{}).Error; err != nil {
+				return err
+			}
+
+			// Existing purchased tickets were sold at the event price
+			return tx.Exec("UPDATE tickets SET price_paid = events.price FROM events WHERE events.id = tickets.event_id AND tickets.order_id IS NOT NULL").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("tickets").DropColumn("price_paid").Error; err != nil {
+				return err
+			}
+			if err := tx.Table("orders").DropColumn("tax_rate").Error; err != nil {
+				return err
+			}
+			return tx.Table("orders").DropColumn("discount").Error
+		},
+	},
+	{
+		ID: "202610140011_event_unlimited",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				Unlimited bool `gorm:"not null;default:false"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("unlimited").Error
+		},
+	},
+	{
+		ID: "202610140012_refunds",
+		Migrate: func(tx *gorm.DB) error {
+			type refund struct {
+				ID        uint    `gorm:"primary_key"`
+				TicketID  uint    `gorm:"not null;index"`
+				OrderID   *uint   `gorm:"index"`
+				UserID    uint    `gorm:"not null;index"`
+				Amount    float64 `gorm:"not null"`
+				Status    string  `gorm:"not null;default:'pending'"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			return tx.Table("refunds").AutoMigrate(&refund{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("refunds").Error
+		},
+	},
+	{
+		ID: "202610140013_custom_fields",
+		Migrate: func(tx *gorm.DB) error {
+			type customField struct {
+				ID        uint   `gorm:"primary_key"`
+				EventID   uint   `gorm:"not null;unique_index:idx_custom_field_name"`
+				Name      string `gorm:"not null;unique_index:idx_custom_field_name"`
+				Type      string `gorm:"not null;default:'text'"`
+				Required  bool   `gorm:"not null;default:false"`
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			if err := tx.Table("custom_fields").AutoMigrate(&customField{}).Error; err != nil {
+				return err
+			}
+
+			type ticketField struct {
+				ID            uint `gorm:"primary_key"`
+				TicketID      uint `gorm:"not null;index"`
+				CustomFieldID uint `gorm:"not null"`
+				Value         string
+				CreatedAt     time.Time
+			}
+			return tx.Table("ticket_fields").AutoMigrate(&ticketField{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.DropTableIfExists("ticket_fields").Error; err != nil {
+				return err
+			}
+			return tx.DropTableIfExists("custom_fields").Error
+		},
+	},
+	{
+		ID: "202610140014_refresh_tokens",
+		Migrate: func(tx *gorm.DB) error {
+			type refreshToken struct {
+				ID        uint      `gorm:"primary_key"`
+				UserID    uint      `gorm:"not null;index"`
+				TokenHash string    `gorm:"unique;not null"`
+				ExpiresAt time.Time `gorm:"not null"`
+				RevokedAt *time.Time
+				CreatedAt time.Time
+			}
+			return tx.Table("refresh_tokens").AutoMigrate(&refreshToken{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("refresh_tokens").Error
+		},
+	},
+	{
+		ID: "202610140015_event_snapshots",
+		Migrate: func(tx *gorm.DB) error {
+			type eventSnapshot struct {
+				ID            uint `gorm:"primary_key"`
+				EventID       uint `gorm:"unique;not null"`
+				Sold          int
+				CheckedIn     int
+				Revenue       float64
+				TicketsPurged bool `gorm:"not null;default:false"`
+				CreatedAt     time.Time
+			}
+			return tx.Table("event_snapshots").AutoMigrate(&eventSnapshot{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("event_snapshots").Error
+		},
+	},
+	{
+		ID: "202610140016_attendance_checked_in_by",
+		Migrate: func(tx *gorm.DB) error {
+			type attendanceLog struct {
+				CheckedInBy *uint `gorm:"index"`
+			}
+			return tx.Table("attendance_logs").AutoMigrate(&attendanceLog{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("attendance_logs").DropColumn("checked_in_by").Error
+		},
+	},
+	{
+		ID: "202610140017_ticket_barcodes",
+		Migrate: func(tx *gorm.DB) error {
+			type ticket struct {
+				Barcode *string `gorm:"unique"`
+			}
+			return tx.Table("tickets").AutoMigrate(&ticket{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("tickets").DropColumn("barcode").Error
+		},
+	},
+	{
+		ID: "202610140018_password_reset_tokens",
+		Migrate: func(tx *gorm.DB) error {
+			type passwordResetToken struct {
+				ID        uint      `gorm:"primary_key"`
+				UserID    uint      `gorm:"not null;index"`
+				TokenHash string    `gorm:"unique;not null"`
+				ExpiresAt time.Time `gorm:"not null"`
+				UsedAt    *time.Time
+				CreatedAt time.Time
+			}
+			return tx.Table("password_reset_tokens").AutoMigrate(&passwordResetToken{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("password_reset_tokens").Error
+		},
+	},
+	{
+		ID: "202610140019_blacklisted_tokens",
+		Migrate: func(tx *gorm.DB) error {
+			type blacklistedToken struct {
+				ID        uint      `gorm:"primary_key"`
+				TokenID   string    `gorm:"unique;not null"`
+				ExpiresAt time.Time `gorm:"not null;index"`
+				CreatedAt time.Time
+			}
+			return tx.Table("blacklisted_tokens").AutoMigrate(&blacklistedToken{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("blacklisted_tokens").Error
+		},
+	},
+	{
+		ID: "202610140020_event_purchase_rate_limit",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				PurchaseRateLimit int `gorm:"not null;default:0"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("purchase_rate_limit").Error
+		},
+	},
+	{
+		ID: "202610140021_event_soft_delete",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				DeletedAt *time.Time `sql:"index"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("deleted_at").Error
+		},
+	},
+	{
+		ID: "202610140022_waitlists",
+		Migrate: func(tx *gorm.DB) error {
+			type waitlist struct {
+				ID               uint `gorm:"primary_key"`
+				EventID          uint `gorm:"not null;unique_index:idx_waitlist_user"`
+				UserID           uint `gorm:"not null;unique_index:idx_waitlist_user"`
+				NotifiedAt       *time.Time
+				PromotedTicketID *uint
+				CreatedAt        time.Time
+			}
+			return tx.Table("waitlists").AutoMigrate(&waitlist{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists("waitlists").Error
+		},
+	},
+	{
+		ID: "202610140023_promo_codes",
+		Migrate: func(tx *gorm.DB) error {
+			type promoCode struct {
+				ID         uint    `gorm:"primary_key"`
+				Code       string  `gorm:"unique;not null"`
+				EventID    *uint   `gorm:"index"`
+				PercentOff float64 `gorm:"not null;default:0"`
+				AmountOff  float64 `gorm:"not null;default:0"`
+				MaxUses    int     `gorm:"not null;default:0"`
+				UsedCount  int     `gorm:"not null;default:0"`
+				Active     bool    `gorm:"not null;default:true"`
+				ExpiresAt  *time.Time
+				CreatedBy  uint
+				CreatedAt  time.Time
+				UpdatedAt  time.Time
+			}
+			type order struct {
+				PromoCodeID *uint `gorm:"index"`
+			}
+			if err := tx.Table("promo_codes").AutoMigrate(&promoCode{}).Error; err != nil {
+				return err
+			}
+			return tx.Table("orders").AutoMigrate(&order{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("orders").DropColumn("promo_code_id").Error; err != nil {
+				return err
+			}
+			return tx.DropTableIfExists("promo_codes").Error
+		},
+	},
+	{
+		ID: "202610140024_order_totals",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				Total  float64 `gorm:"not null;default:0"`
+				Status string  `gorm:"not null;default:'pending'"`
+			}
+			if err := tx.Table("orders").AutoMigrate(&order{}).Error; err != nil {
+				return err
+			}
+			// Existing orders owe what their tickets were sold for after discount and tax
+			return tx.Exec("UPDATE orders SET total = ROUND(CAST(GREATEST(COALESCE((SELECT SUM(price_paid) FROM tickets WHERE tickets.order_id = orders.id), 0) - discount, 0) * (1 + tax_rate / 100) AS numeric), 2)").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("orders").DropColumn("status").Error; err != nil {
+				return err
+			}
+			return tx.Table("orders").DropColumn("total").Error
+		},
+	},
+	{
+		ID: "202610140025_order_charge_id",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				ChargeID string `gorm:"index"`
+			}
+			return tx.Table("orders").AutoMigrate(&order{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("orders").DropColumn("charge_id").Error
+		},
+	},
+	{
+		ID: "202610140026_webhooks",
+		Migrate: func(tx *gorm.DB) error {
+			type webhook struct {
+				ID        uint   `gorm:"primary_key"`
+				URL       string `gorm:"not null"`
+				EventType string `gorm:"not null;index"`
+				Secret    string `gorm:"not null"`
+				Active    bool   `gorm:"not null;default:true"`
+				CreatedBy uint
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			type webhookDelivery struct {
+				ID            uint      `gorm:"primary_key"`
+				WebhookID     uint      `gorm:"not null;index"`
+				EventType     string    `gorm:"not null"`
+				Payload       string    `gorm:"type:text;not null"`
+				Status        string    `gorm:"not null;default:'pending';index"`
+				Attempts      int       `gorm:"not null;default:0"`
+				NextAttemptAt time.Time `gorm:"not null;index"`
+				LastError     string
+				DeliveredAt   *time.Time
+				CreatedAt     time.Time
+				UpdatedAt     time.Time
+			}
+			if err := tx.Table("webhooks").AutoMigrate(&webhook{}).Error; err != nil {
+				return err
+			}
+			return tx.Table("webhook_deliveries").AutoMigrate(&webhookDelivery{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.DropTableIfExists("webhook_deliveries").Error; err != nil {
+				return err
+			}
+			return tx.DropTableIfExists("webhooks").Error
+		},
+	},
+	{
+		ID: "202610140027_attendance_checkout",
+		Migrate: func(tx *gorm.DB) error {
+			type attendanceLog struct {
+				CheckedOutAt *time.Time
+			}
+			return tx.Table("attendance_logs").AutoMigrate(&attendanceLog{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("attendance_logs").DropColumn("checked_out_at").Error
+		},
+	},
+	{
+		ID: "202610140028_event_allow_reentry",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				AllowReentry bool `gorm:"not null;default:false"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("allow_reentry").Error
+		},
+	},
+	{
+		ID: "202610140029_event_category",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				Category string `gorm:"not null;default:'';index"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("category").Error
+		},
+	},
+	{
+		ID: "202610140030_event_max_per_user",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				MaxPerUser int `gorm:"not null;default:0"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("max_per_user").Error
+		},
+	},
+	{
+		ID: "202610140031_seats",
+		Migrate: func(tx *gorm.DB) error {
+			type seat struct {
+				ID        uint   `gorm:"primary_key"`
+				EventID   uint   `gorm:"not null;unique_index:idx_seat_position"`
+				Section   string `gorm:"not null;unique_index:idx_seat_position"`
+				Row       string `gorm:"not null;unique_index:idx_seat_position"`
+				Number    string `gorm:"not null;unique_index:idx_seat_position"`
+				Status    string `gorm:"not null;default:'available'"`
+				TicketID  *uint
+				CreatedAt time.Time
+				UpdatedAt time.Time
+			}
+			type ticket struct {
+				SeatID *uint `gorm:"index"`
+			}
+			if err := tx.Table("seats").AutoMigrate(&seat{}).Error; err != nil {
+				return err
+			}
+			return tx.Table("tickets").AutoMigrate(&ticket{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("tickets").DropColumn("seat_id").Error; err != nil {
+				return err
+			}
+			return tx.DropTableIfExists("seats").Error
+		},
+	},
+	{
+		ID: "202610140032_ticket_qr_payload",
+		Migrate: func(tx *gorm.DB) error {
+			// Tickets issued before payloads were stored hold PNG bytes, which no scan can match.
+			// They get a fresh payload in the TICKET-<event>-<holder>-<id>-<random>-<nanos> form.
+			return tx.Exec(`UPDATE tickets SET qr_code = 'TICKET-' || event_id || '-' || COALESCE(user_id, 0) || '-' || id || '-' ||
+				md5(random()::text || id::text) || '-' || CAST(EXTRACT(EPOCH FROM clock_timestamp()) * 1000000000 AS bigint)
+				WHERE qr_code NOT LIKE 'TICKET-%'`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			// The image bytes are not kept, so the rewritten payloads stay
+			return nil
+		},
+	},
+	{
+		ID: "202610140033_order_idempotency_key",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				IdempotencyKey *string
+			}
+			if err := tx.Table("orders").AutoMigrate(&order{}).Error; err != nil {
+				return err
+			}
+			// Keys are unique per buyer; orders placed without a key leave it null
+			return tx.Table("orders").AddUniqueIndex("idx_order_idempotency_key", "user_id", "idempotency_key").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Table("orders").RemoveIndex("idx_order_idempotency_key").Error; err != nil {
+				return err
+			}
+			return tx.Table("orders").DropColumn("idempotency_key").Error
+		},
+	},
+	{
+		ID: "202610140034_event_version",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				Version int `gorm:"not null;default:1"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("version").Error
+		},
+	},
+	{
+		ID: "202610140035_ticket_amount_charged",
+		Migrate: func(tx *gorm.DB) error {
+			type ticket struct {
+				AmountCharged float64 `gorm:"not null;default:0"`
+			}
+			if err := tx.Table("tickets").AutoMigrate(&ticket{}).Error; err != nil {
+				return err
+			}
+
+			// Split the total of each paid order over its tickets by price, the last ticket taking
+			// the rounding remainder, as purchases do
+			return tx.Exec(`UPDATE tickets SET amount_charged = shares.amount FROM (
+				SELECT id, CASE WHEN position = ticket_count THEN ROUND(CAST(total - (SUM(share) OVER (PARTITION BY order_id) - share) AS numeric), 2) ELSE share END AS amount
+				FROM (
+					SELECT tickets.id, tickets.order_id, orders.total,
+						ROUND(CAST(CASE WHEN SUM(tickets.price_paid) OVER (PARTITION BY tickets.order_id) > 0
+							THEN orders.total * tickets.price_paid / SUM(tickets.price_paid) OVER (PARTITION BY tickets.order_id)
+							ELSE orders.total / COUNT(*) OVER (PARTITION BY tickets.order_id) END AS numeric), 2) AS share,
+						ROW_NUMBER() OVER (PARTITION BY tickets.order_id ORDER BY tickets.id) AS position,
+						COUNT(*) OVER (PARTITION BY tickets.order_id) AS ticket_count
+					FROM tickets JOIN orders ON orders.id = tickets.order_id
+					WHERE orders.status = 'paid'
+				) split
+			) shares WHERE shares.id = tickets.id`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("tickets").DropColumn("amount_charged").Error
+		},
+	},
+	{
+		ID: "202610140036_sign_ticket_qr_payloads",
+		Migrate: func(tx *gorm.DB) error {
+			// Payloads issued while QR_SIGNING_SECRET was optional are unsigned, and older ones carry
+			// a purchase sequence where the ticket ID now goes. Scans require both, so every
+			// payload that does not verify is reissued naming its ticket and signed.
+			secret := []byte(os.Getenv("QR_SIGNING_SECRET"))
+			if len(secret) == 0 {
+				return errors.New("QR_SIGNING_SECRET must be set to sign ticket QR payloads")
+			}
+
+			type ticket struct {
+				ID      uint
+				EventID uint
+				UserID  *uint
+				QRCode  string
+			}
+			var tickets []ticket
+			if err := tx.Table("tickets").Select("id, event_id, user_id, qr_code").Order("id").Scan(&tickets).Error; err != nil {
+				return err
+			}
+
+			for _, t := range tickets {
+				if claims, err := utils.ValidateQRCode(t.QRCode, secret); err == nil && claims.TicketID == t.ID {
+					continue
+				}
+				var holderID uint
+				if t.UserID != nil {
+					holderID = *t.UserID
+				}
+				payload := utils.GenerateQRCode(t.EventID, holderID, t.ID, secret)
+				if err := tx.Table("tickets").Where("id = ?", t.ID).UpdateColumn("qr_code", payload).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			// The unsigned payloads are not kept, so the signed ones stay
+			return nil
+		},
+	},
+	{
+		ID: "202610140037_event_reservation_ttl",
+		Migrate: func(tx *gorm.DB) error {
+			type event struct {
+				ReservationTTLMinutes int `gorm:"not null;default:0"`
+			}
+			return tx.Table("events").AutoMigrate(&event{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("events").DropColumn("reservation_ttl_minutes").Error
+		},
+	},
+	{
+		// Pending orders placed before this migration have no expiry, so the sweeper leaves them
+		ID: "202610140038_order_expires_at",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				ExpiresAt *time.Time `gorm:"index"`
+			}
+			return tx.Table("orders").AutoMigrate(&order{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("orders").DropColumn("expires_at").Error
+		},
+	},
+	{
+		// Orders placed before this migration have no fingerprint and are matched by event and
+		// quantity only
+		ID: "202610140039_order_idempotency_fingerprint",
+		Migrate: func(tx *gorm.DB) error {
+			type order struct {
+				IdempotencyFingerprint string `gorm:"not null;default:''"`
+			}
+			return tx.Table("orders").AutoMigrate(&order{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Table("orders").DropColumn("idempotency_fingerprint").Error
+		},
+	},
+}
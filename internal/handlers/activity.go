@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// cancelledAuditDetails matches the audit details of a ticket status change to cancelled
+const cancelledAuditDetails = "% -> cancelled"
+
+// pageActivity sorts activity entries newest first and returns the entries of one page
+func pageActivity(entries []TimelineEntry, page pagination) []TimelineEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.After(entries[j].At)
+	})
+
+	if page.Offset() >= len(entries) {
+		return []TimelineEntry{}
+	}
+	end := page.Offset() + page.PerPage
+	if end > len(entries) {
+		end = len(entries)
+	}
+	return entries[page.Offset():end]
+}
+
+// GetActivity retrieves the current user's purchases, transfers, check-ins and cancellations
+// as one reverse chronological, paginated feed
+func (h *UserHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	// Each source contributes at most the entries up to the end of the requested page, which
+	// is enough to assemble that page of the merged feed
+	limit := page.Offset() + page.PerPage
+
+	orders := h.db.Model(&models.Order{}).Where("user_id = ?", userID)
+	transfers := h.db.Model(&models.TicketTransfer{}).Where("from_user_id = ? OR to_user_id = ?", userID, userID)
+	checkins := h.db.Model(&models.AttendanceLog{}).
+		Joins("JOIN tickets ON tickets.id = attendance_logs.ticket_id").
+		Where("tickets.user_id = ?", userID)
+	cancellations := h.db.Model(&models.AuditLog{}).
+		Joins("JOIN tickets ON tickets.id = audit_logs.entity_id").
+		Where("audit_logs.entity_type = ? AND audit_logs.action = ? AND audit_logs.details LIKE ? AND tickets.user_id = ?",
+			"ticket", "ticket.status_changed", cancelledAuditDetails, userID)
+
+	var total int64
+	for _, query := range []*gorm.DB{orders, transfers, checkins, cancellations} {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve activity")
+			return
+		}
+		total += count
+	}
+
+	entries := []TimelineEntry{}
+
+	var orderRows []models.Order
+	if err := orders.Order(stableOrder("created_at desc", "id")).Limit(limit).Find(&orderRows).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve activity")
+		return
+	}
+	for _, order := range orderRows {
+		entries = append(entries, TimelineEntry{Type: "purchased", At: order.CreatedAt, Details: map[string]interface{}{
+			"order_id": order.ID,
+			"event_id": order.EventID,
+			"quantity": order.Quantity,
+		}})
+	}
+
+	var transferRows []models.TicketTransfer
+	if err := transfers.Order(stableOrder("created_at desc", "id")).Limit(limit).Find(&transferRows).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve activity")
+		return
+	}
+	for _, transfer := range transferRows {
+		entryType := "transferred_in"
+		if id, ok := userID.(uint); ok && transfer.FromUserID == id {
+			entryType = "transferred_out"
+		}
+		entries = append(entries, TimelineEntry{Type: entryType, At: transfer.CreatedAt, Details: map[string]interface{}{
+			"ticket_id": transfer.TicketID,
+		}})
+	}
+
+	var checkinRows []models.AttendanceLog
+	if err := checkins.Select("attendance_logs.*").Order(stableOrder("attendance_logs.checked_in_at desc", "attendance_logs.id")).
+		Limit(limit).Find(&checkinRows).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve activity")
+		return
+	}
+	for _, checkin := range checkinRows {
+		entries = append(entries, TimelineEntry{Type: "checked_in", At: checkin.CheckedInAt, Details: map[string]interface{}{
+			"ticket_id": checkin.TicketID,
+		}})
+	}
+
+	var cancellationRows []models.AuditLog
+	if err := cancellations.Select("audit_logs.*").Order(stableOrder("audit_logs.created_at desc", "audit_logs.id")).
+		Limit(limit).Find(&cancellationRows).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve activity")
+		return
+	}
+	for _, cancellation := range cancellationRows {
+		entries = append(entries, TimelineEntry{Type: "cancelled", At: cancellation.CreatedAt, Details: map[string]interface{}{
+			"ticket_id": cancellation.EntityID,
+		}})
+	}
+
+	response := PaginatedResponse{
+		Data:    pageActivity(entries, page),
+		Page:    page.Page,
+		PerPage: page.PerPage,
+		Total:   total,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
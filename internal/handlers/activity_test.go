@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/mailer"
+)
+
+func TestGetActivityIsReverseChronological(t *testing.T) {
+	db := openTestDB(t)
+	h := NewUserHandler(db, mailer.LogSender{})
+	user := createTestUser(t, db, "user")
+	other := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	now := time.Now()
+
+	kept := createTestTicket(t, db, event, user)
+	given := createTestTicket(t, db, event, other)
+	cancelled := createTestTicket(t, db, event, user)
+	for _, row := range []interface{}{
+		&models.Order{UserID: user.ID, EventID: event.ID, Quantity: 2, Status: "paid", CreatedAt: now.Add(-5 * time.Hour)},
+		&models.TicketTransfer{TicketID: kept.ID, FromUserID: other.ID, ToUserID: user.ID, CreatedAt: now.Add(-4 * time.Hour)},
+		&models.AttendanceLog{TicketID: kept.ID, CheckedInAt: now.Add(-3 * time.Hour)},
+		&models.TicketTransfer{TicketID: given.ID, FromUserID: user.ID, ToUserID: other.ID, CreatedAt: now.Add(-2 * time.Hour)},
+		&models.AuditLog{Action: "ticket.status_changed", EntityType: "ticket", EntityID: cancelled.ID, Details: "valid -> cancelled", CreatedAt: now.Add(-time.Hour)},
+		// Neither another user's order nor a status change other than a cancellation is listed
+		&models.Order{UserID: other.ID, EventID: event.ID, Quantity: 1, Status: "paid", CreatedAt: now.Add(-30 * time.Minute)},
+		&models.AuditLog{Action: "ticket.status_changed", EntityType: "ticket", EntityID: kept.ID, Details: "valid -> used", CreatedAt: now.Add(-30 * time.Minute)},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+
+	// activity returns the types of one page of the feed and the total number of entries
+	activity := func(query string) ([]string, int64) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.GetActivity(w, authedRequest("GET", "/api/me/activity"+query, "", user, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("activity%s returned %d: %s", query, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data  []TimelineEntry `json:"data"`
+			Total int64           `json:"total"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("decode activity: %v", err)
+		}
+		types := []string{}
+		for i, entry := range response.Data {
+			if i > 0 && entry.At.After(response.Data[i-1].At) {
+				t.Errorf("activity%s entry %d is newer than the one before it", query, i)
+			}
+			types = append(types, entry.Type)
+		}
+		return types, response.Total
+	}
+
+	want := []string{"cancelled", "transferred_out", "checked_in", "transferred_in", "purchased"}
+	got, total := activity("")
+	if total != int64(len(want)) || len(got) != len(want) {
+		t.Fatalf("activity lists %v of %d entries, want %v", got, total, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("activity is %v, want %v", got, want)
+		}
+	}
+
+	got, total = activity("?page=2&per_page=2")
+	if total != 5 || len(got) != 2 || got[0] != "checked_in" || got[1] != "transferred_in" {
+		t.Fatalf("second page is %v of %d entries, want [checked_in transferred_in] of 5", got, total)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+// AdminHandler handles platform administration requests
+type AdminHandler struct {
+	db *gorm.DB
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(db *gorm.DB) *AdminHandler {
+	return &AdminHandler{db: db}
+}
+
+// MetricsBucket holds the platform activity for one time bucket
+type MetricsBucket struct {
+	Start         time.Time `json:"start"`
+	NewUsers      int64     `json:"new_users"`
+	EventsCreated int64     `json:"events_created"`
+	TicketsSold   int64     `json:"tickets_sold"`
+	Revenue       float64   `json:"revenue"`
+}
+
+// bucketRow is a single row of a grouped metrics query
+type bucketRow struct {
+	Bucket time.Time
+	Count  int64
+	Total  float64
+}
+
+// parseDateParam parses a date query parameter given as YYYY-MM-DD or RFC3339
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// truncateToBucket returns the start of the day or ISO week (Monday) containing t, in UTC
+func truncateToBucket(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if granularity == "week" {
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// buildMetricsBuckets creates zero-filled buckets covering [from, to)
+func buildMetricsBuckets(from, to time.Time, granularity string) []MetricsBucket {
+	buckets := []MetricsBucket{}
+	for start := truncateToBucket(from, granularity); start.Before(to); {
+		buckets = append(buckets, MetricsBucket{Start: start})
+		if granularity == "week" {
+			start = start.AddDate(0, 0, 7)
+		} else {
+			start = start.AddDate(0, 0, 1)
+		}
+	}
+	return buckets
+}
+
+// GetMetrics retrieves time-bucketed platform metrics (admin only)
+func (h *AdminHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = "day"
+	}
+	if granularity != "day" && granularity != "week" {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Granularity must be day or week")
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid to date")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if value := query.Get("from"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid from date")
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "From date must be before to date")
+		return
+	}
+
+	// Align the range to bucket boundaries so every bucket is complete
+	from = truncateToBucket(from, granularity)
+
+	var users, events, tickets []bucketRow
+	if err := h.db.Table("users").
+		Select("date_trunc(?, created_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS count", granularity).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("bucket").Scan(&users).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute user metrics")
+		return
+	}
+
+	if err := h.db.Table("events").
+		Select("date_trunc(?, created_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS count", granularity).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("bucket").Scan(&events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute event metrics")
+		return
+	}
+
+	if err := h.db.Table("tickets").
+		Select("date_trunc(?, tickets.created_at AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS count, COALESCE(SUM(events.price), 0) AS total", granularity).
+		Joins("JOIN events ON events.id = tickets.event_id").
+		Where("tickets.created_at >= ? AND tickets.created_at < ? AND tickets.user_id IS NOT NULL", from, to).
+		Group("bucket").Scan(&tickets).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute ticket metrics")
+		return
+	}
+
+	buckets := buildMetricsBuckets(from, to, granularity)
+	index := map[time.Time]int{}
+	for i, bucket := range buckets {
+		index[bucket.Start] = i
+	}
+
+	for _, row := range users {
+		if i, ok := index[truncateToBucket(row.Bucket, granularity)]; ok {
+			buckets[i].NewUsers = row.Count
+		}
+	}
+	for _, row := range events {
+		if i, ok := index[truncateToBucket(row.Bucket, granularity)]; ok {
+			buckets[i].EventsCreated = row.Count
+		}
+	}
+	for _, row := range tickets {
+		if i, ok := index[truncateToBucket(row.Bucket, granularity)]; ok {
+			buckets[i].TicketsSold = row.Count
+			buckets[i].Revenue = row.Total
+		}
+	}
+
+	response := map[string]interface{}{
+		"from":        from,
+		"to":          to,
+		"granularity": granularity,
+		"buckets":     buckets,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuildMetricsBucketsZeroFills(t *testing.T) {
+	from := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC) // a Wednesday
+	to := time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC)
+
+	days := buildMetricsBuckets(from, to, "day")
+	if len(days) != 13 || !days[0].Start.Equal(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)) || !days[12].Start.Equal(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("daily buckets %v, want March 4 to 16", days)
+	}
+
+	weeks := buildMetricsBuckets(from, to, "week")
+	want := []time.Time{time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)}
+	if len(weeks) != len(want) {
+		t.Fatalf("weekly buckets %v, want the weeks starting %v", weeks, want)
+	}
+	for i, bucket := range weeks {
+		if !bucket.Start.Equal(want[i]) || bucket.NewUsers != 0 || bucket.TicketsSold != 0 || bucket.Revenue != 0 {
+			t.Errorf("week %d is %+v, want an empty bucket starting %v", i, bucket, want[i])
+		}
+	}
+}
+
+func TestGetMetricsGranularity(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAdminHandler(db)
+	admin := createTestUser(t, db, "admin")
+
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+	backdate := func(table string, id uint, createdAt time.Time) {
+		t.Helper()
+		if err := db.Table(table).Where("id = ?", id).UpdateColumn("created_at", createdAt).Error; err != nil {
+			t.Fatalf("backdate %s %d: %v", table, id, err)
+		}
+	}
+	backdate("users", createTestUser(t, db, "user").ID, at(2, 10))
+	backdate("users", createTestUser(t, db, "user").ID, at(4, 9))
+	backdate("users", createTestUser(t, db, "user").ID, at(4, 23))
+	event := createTestEvent(t, db, 10, 20)
+	backdate("events", event.ID, at(2, 12))
+	holder := createTestUser(t, db, "user")
+	backdate("tickets", createTestTicket(t, db, event, holder).ID, at(4, 8))
+	backdate("tickets", createTestTicket(t, db, event, holder).ID, at(4, 20))
+	backdate("tickets", createTestTicket(t, db, event, holder).ID, at(17, 11))
+
+	metrics := func(query string) []MetricsBucket {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.GetMetrics(w, authedRequest("GET", "/api/admin/metrics?"+query, "", admin, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("metrics with %s returned %d: %s", query, w.Code, w.Body)
+		}
+		var response struct {
+			Buckets []MetricsBucket `json:"buckets"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("decode metrics: %v", err)
+		}
+		return response.Buckets
+	}
+
+	daily := metrics("from=2026-03-02&to=2026-03-05&granularity=day")
+	wantDaily := []MetricsBucket{
+		{Start: at(2, 0), NewUsers: 1, EventsCreated: 1},
+		{Start: at(3, 0)},
+		{Start: at(4, 0), NewUsers: 2, TicketsSold: 2, Revenue: 40},
+	}
+	checkMetricsBuckets(t, "daily", daily, wantDaily)
+
+	weekly := metrics("from=2026-03-04&to=2026-03-20&granularity=week")
+	wantWeekly := []MetricsBucket{
+		{Start: at(2, 0), NewUsers: 3, EventsCreated: 1, TicketsSold: 2, Revenue: 40},
+		{Start: at(9, 0)},
+		{Start: at(16, 0), TicketsSold: 1, Revenue: 20},
+	}
+	checkMetricsBuckets(t, "weekly", weekly, wantWeekly)
+
+	w := httptest.NewRecorder()
+	h.GetMetrics(w, authedRequest("GET", "/api/admin/metrics?granularity=month", "", admin, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("monthly granularity returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// checkMetricsBuckets compares metrics buckets with the expected ones
+func checkMetricsBuckets(t *testing.T, name string, got, want []MetricsBucket) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s buckets %+v, want %+v", name, got, want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || got[i].NewUsers != want[i].NewUsers || got[i].EventsCreated != want[i].EventsCreated ||
+			got[i].TicketsSold != want[i].TicketsSold || got[i].Revenue != want[i].Revenue {
+			t.Errorf("%s bucket %d is %+v, want %+v", name, i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// maxBulkAssignTickets caps the number of tickets that can be assigned in one request
+const maxBulkAssignTickets = 500
+
+// Per-row outcomes of a bulk assignment
+const (
+	assignResultAssigned      = "assigned"
+	assignResultNotFound      = "not_found"
+	assignResultNotAssignable = "not_assignable"
+	assignResultUserNotFound  = "user_not_found"
+	assignResultDuplicate     = "duplicate"
+	assignResultConflict      = "conflict"
+)
+
+// TicketAssignment is one row of a bulk assignment: the ticket and the email of its new holder
+type TicketAssignment struct {
+	TicketID uint   `json:"ticket_id" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+}
+
+// AssignResult is the outcome of assigning one ticket of a batch
+type AssignResult struct {
+	TicketID uint   `json:"ticket_id"`
+	Email    string `json:"email"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+	UserID   *uint  `json:"user_id,omitempty"`
+}
+
+// createPlaceholderUsers reports whether assigning a ticket to an unknown email creates an
+// account for it; the account has a random password and must be recovered to sign in
+func createPlaceholderUsers() bool {
+	return config.GetEnv("ASSIGN_CREATE_PLACEHOLDER_USERS", "false") == "true"
+}
+
+// placeholderUser builds the account created for an unknown email on assignment
+func placeholderUser(email string) (models.User, error) {
+	password, err := auth.GenerateSecureToken()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	name := email
+	if at := strings.Index(email, "@"); at > 0 {
+		name = email[:at]
+	}
+
+	return models.User{
+		Name:           name,
+		Email:          email,
+		CanonicalEmail: auth.CanonicalEmail(email, auth.EmailCanonicalizationMode()),
+		Password:       password,
+		Role:           "user",
+	}, nil
+}
+
+// AssignTickets assigns unassigned valid tickets of an event to users by email in one
+// transaction (admin only). Each row is reported with its own result; rows that cannot be
+// assigned do not prevent the others.
+func (h *TicketHandler) AssignTickets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var rows []TicketAssignment
+	if err := decodeJSON(r, &rows); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if len(rows) == 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "At least one assignment is required")
+		return
+	}
+	if len(rows) > maxBulkAssignTickets {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("At most %d tickets can be assigned at once", maxBulkAssignTickets))
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	ticketIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ticketIDs = append(ticketIDs, row.TicketID)
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the tickets so a concurrent assignment of the same ticket waits for this one
+	var tickets []models.Ticket
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id IN (?) AND event_id = ?", ticketIDs, event.ID).Find(&tickets).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+	byID := map[uint]models.Ticket{}
+	for _, ticket := range tickets {
+		byID[ticket.ID] = ticket
+	}
+
+	placeholders := createPlaceholderUsers()
+	users := map[string]*models.User{}
+	seen := map[uint]bool{}
+	results := make([]AssignResult, 0, len(rows))
+	var assigned []AssignResult
+	for _, row := range rows {
+		email := strings.TrimSpace(row.Email)
+		result := AssignResult{TicketID: row.TicketID, Email: email}
+
+		if seen[row.TicketID] {
+			result.Result, result.Error = assignResultDuplicate, "Ticket is listed more than once"
+			results = append(results, result)
+			continue
+		}
+		seen[row.TicketID] = true
+
+		ticket, found := byID[row.TicketID]
+		if !found {
+			result.Result, result.Error = assignResultNotFound, "Ticket not found for this event"
+			results = append(results, result)
+			continue
+		}
+		if ticket.UserID != nil || ticket.Status != "valid" {
+			result.Result, result.Error = assignResultNotAssignable, "Only unassigned valid tickets can be assigned"
+			results = append(results, result)
+			continue
+		}
+
+		user, cached := users[email]
+		if !cached {
+			var existing models.User
+			err := tx.Where("email = ?", email).First(&existing).Error
+			switch {
+			case err == nil:
+				user = &existing
+			case !gorm.IsRecordNotFoundError(err):
+				tx.Rollback()
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve users")
+				return
+			case placeholders && email != "":
+				created, err := placeholderUser(email)
+				if err == nil {
+					err = tx.Create(&created).Error
+				}
+				if err != nil {
+					tx.Rollback()
+					respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create placeholder user")
+					return
+				}
+				user = &created
+			}
+			users[email] = user
+		}
+		if user == nil {
+			result.Result, result.Error = assignResultUserNotFound, "No user with this email"
+			results = append(results, result)
+			continue
+		}
+
+		// Guard on the holder and status as well as the row lock, so a change committed first wins
+		update := tx.Model(&models.Ticket{}).Where("id = ? AND user_id IS NULL AND status = ?", ticket.ID, "valid").
+			Update("user_id", user.ID)
+		if update.Error != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to assign tickets")
+			return
+		}
+		if update.RowsAffected == 0 {
+			result.Result, result.Error = assignResultConflict, "Ticket is no longer assignable"
+			results = append(results, result)
+			continue
+		}
+
+		userID := user.ID
+		result.Result, result.UserID = assignResultAssigned, &userID
+		results = append(results, result)
+		assigned = append(assigned, result)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to assign tickets")
+		return
+	}
+
+	for _, result := range assigned {
+		recordAudit(h.db, r, "ticket.assigned", "ticket", result.TicketID, fmt.Sprintf("assigned to user %d", *result.UserID))
+	}
+
+	response := map[string]interface{}{
+		"message":  "Tickets assigned",
+		"assigned": len(assigned),
+		"results":  results,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
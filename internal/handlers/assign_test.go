@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+
+	"github.com/jinzhu/gorm"
+)
+
+// createUnassignedTicket inserts a ticket of the event without a holder
+func createUnassignedTicket(t *testing.T, db *gorm.DB, event models.Event) models.Ticket {
+	t.Helper()
+	ticket := createTestTicket(t, db, event, createTestUser(t, db, "user"))
+	db.Model(&models.Ticket{}).Where("id = ?", ticket.ID).UpdateColumn("user_id", nil)
+	return ticket
+}
+
+// assignTickets submits the assignments of (ticket ID, email) rows as the admin
+func assignTickets(t *testing.T, h *TicketHandler, event models.Event, admin models.User, rows ...TicketAssignment) []AssignResult {
+	t.Helper()
+	body, _ := json.Marshal(rows)
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.AssignTickets(w, authedRequest("POST", "/api/events/"+vars["id"]+"/tickets/assign", string(body), admin, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("assign returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Results []AssignResult `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode assignment results: %v", err)
+	}
+	return response.Results
+}
+
+// ticketHolder returns the stored holder of the ticket, 0 when unassigned
+func ticketHolder(t *testing.T, db *gorm.DB, ticket models.Ticket) uint {
+	t.Helper()
+	var stored models.Ticket
+	if err := db.Where("id = ?", ticket.ID).First(&stored).Error; err != nil {
+		t.Fatalf("reload ticket: %v", err)
+	}
+	return ticketHolderID(stored)
+}
+
+func TestAssignTicketsReportsEachRow(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("ASSIGN_CREATE_PLACEHOLDER_USERS", "false")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	alice := createTestUser(t, db, "user")
+	bob := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 20, 20)
+
+	first := createUnassignedTicket(t, db, event)
+	second := createUnassignedTicket(t, db, event)
+	unknown := createUnassignedTicket(t, db, event)
+	held := createTestTicket(t, db, event, bob)
+	cancelled := createUnassignedTicket(t, db, event)
+	db.Model(&models.Ticket{}).Where("id = ?", cancelled.ID).UpdateColumn("status", "cancelled")
+	elsewhere := createUnassignedTicket(t, db, createTestEvent(t, db, 5, 20))
+
+	results := assignTickets(t, h, event, admin,
+		TicketAssignment{TicketID: first.ID, Email: alice.Email},
+		TicketAssignment{TicketID: second.ID, Email: " " + bob.Email + " "},
+		TicketAssignment{TicketID: first.ID, Email: bob.Email},
+		TicketAssignment{TicketID: held.ID, Email: alice.Email},
+		TicketAssignment{TicketID: cancelled.ID, Email: alice.Email},
+		TicketAssignment{TicketID: elsewhere.ID, Email: alice.Email},
+		TicketAssignment{TicketID: unknown.ID, Email: "nobody@example.com"},
+	)
+
+	want := []string{assignResultAssigned, assignResultAssigned, assignResultDuplicate, assignResultNotAssignable, assignResultNotAssignable, assignResultNotFound, assignResultUserNotFound}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, result := range results {
+		if result.Result != want[i] {
+			t.Errorf("row %d for ticket %d is %q, want %q", i, result.TicketID, result.Result, want[i])
+		}
+	}
+
+	holders := []struct {
+		ticket models.Ticket
+		want   uint
+	}{
+		{ticket: first, want: alice.ID},
+		{ticket: second, want: bob.ID},
+		{ticket: held, want: bob.ID},
+		{ticket: cancelled, want: 0},
+		{ticket: elsewhere, want: 0},
+		{ticket: unknown, want: 0},
+	}
+	for _, holder := range holders {
+		if got := ticketHolder(t, db, holder.ticket); got != holder.want {
+			t.Errorf("ticket %d is held by %d, want %d", holder.ticket.ID, got, holder.want)
+		}
+	}
+}
+
+func TestAssignTicketsCreatesPlaceholderUsers(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("ASSIGN_CREATE_PLACEHOLDER_USERS", "true")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+	first := createUnassignedTicket(t, db, event)
+	second := createUnassignedTicket(t, db, event)
+
+	email := fmt.Sprintf("guest-%d@example.com", first.ID)
+	results := assignTickets(t, h, event, admin,
+		TicketAssignment{TicketID: first.ID, Email: email},
+		TicketAssignment{TicketID: second.ID, Email: email},
+	)
+	for _, result := range results {
+		if result.Result != assignResultAssigned {
+			t.Fatalf("ticket %d was %q: %s", result.TicketID, result.Result, result.Error)
+		}
+	}
+
+	var guests []models.User
+	db.Where("email = ?", email).Find(&guests)
+	if len(guests) != 1 || guests[0].Name != strings.Split(email, "@")[0] {
+		t.Fatalf("created %d placeholder users for %s, want 1", len(guests), email)
+	}
+	for _, ticket := range []models.Ticket{first, second} {
+		if got := ticketHolder(t, db, ticket); got != guests[0].ID {
+			t.Errorf("ticket %d is held by %d, want the placeholder %d", ticket.ID, got, guests[0].ID)
+		}
+	}
+}
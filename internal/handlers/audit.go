@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"event-ticketing-system/internal/logging"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// recordAudit stores an audit log entry for an action performed by the current user.
+// Failures are logged rather than returned so auditing never blocks the request.
+func recordAudit(db *gorm.DB, r *http.Request, action, entityType string, entityID uint, details string) {
+	var actorID uint
+	logger := slog.Default()
+	if r != nil {
+		if id, ok := r.Context().Value("user_id").(uint); ok {
+			actorID = id
+		}
+		logger = logging.FromContext(r.Context())
+	}
+
+	entry := models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		logger.Error("Failed to record audit log", "action", action, "entity_type", entityType, "entity_id", entityID, "error", err)
+	}
+}
@@ -3,22 +3,28 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
+	"event-ticketing-system/internal/apierror"
 	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/logging"
 	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/mailer"
 
 	"github.com/jinzhu/gorm"
 )
 
-
 // AuthHandler handles authentication related requests
 type AuthHandler struct {
-	db *gorm.DB
+	db            *gorm.DB
+	mailer        mailer.Sender
+	resendLimiter *resendLimiter
+	resetLimiter  *resendLimiter
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *gorm.DB, sender mailer.Sender) *AuthHandler {
+	return &AuthHandler{db: db, mailer: sender, resendLimiter: newResendLimiter(), resetLimiter: newResendLimiter()}
 }
 
 // Register handles user registration
@@ -26,53 +32,65 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if h.db == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Database connection not available"})
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
 		return
 	}
 
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
 		return
 	}
 
-	// Check if user already exists
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	// Check if user already exists, comparing canonical forms so address variants such as
+	// plus-addressing resolve to the same account
+	canonicalEmail := auth.CanonicalEmail(req.Email, auth.EmailCanonicalizationMode())
 	var existingUser models.User
-	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		w.WriteHeader(http.StatusConflict)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User already exists with this email"})
+	if err := h.db.Where("email = ? OR canonical_email = ?", req.Email, canonicalEmail).First(&existingUser).Error; err == nil {
+		respondError(w, http.StatusConflict, apierror.CodeEmailAlreadyExists, "User already exists with this email")
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to hash password"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to hash password")
 		return
 	}
 
 	// Create user
 	user := models.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: hashedPassword,
-		Role:     "user", // Default role
+		Name:           req.Name,
+		Email:          req.Email,
+		CanonicalEmail: canonicalEmail,
+		Password:       hashedPassword,
+		Role:           "user", // Default role
 	}
 
 	if err := h.db.Create(&user).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create user"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create user")
 		return
 	}
 
+	// Send verification email, registration still succeeds if delivery fails
+	if err := sendVerificationEmail(h.db, h.mailer, user); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
 	// Generate JWT token
 	token, err := auth.GenerateToken(user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to generate token"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(h.db, user)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
 		return
 	}
 
@@ -80,8 +98,9 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	user.Password = ""
 
 	response := AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
 	}
 
 	w.WriteHeader(http.StatusCreated)
@@ -103,48 +122,131 @@ type LoginRequest struct {
 
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+	User         models.User `json:"user"`
 }
 
+// RefreshRequest represents the refresh token request payload, also accepted by logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
 
 // Login handles user login
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if h.db == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Database connection not available"})
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
 		return
 	}
 
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
 		return
 	}
 
 	// Find user by email
 	var user models.User
 	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid credentials"})
+		respondError(w, http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
 
 	// Check password
 	if !auth.CheckPassword(req.Password, user.Password) {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid credentials"})
+		respondError(w, http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
 
 	// Generate JWT token
 	token, err := auth.GenerateToken(user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to generate token"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(h.db, user)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	// Remove password from response
+	user.Password = ""
+
+	response := AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Refresh exchanges a refresh token for a new access token. The refresh token is rotated:
+// the presented one is revoked and a new one is returned.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
+		return
+	}
+
+	var req RefreshRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	record, err := auth.ValidateRefreshToken(h.db, req.RefreshToken)
+	if err == auth.ErrRefreshTokenInvalid {
+		respondError(w, http.StatusUnauthorized, apierror.CodeRefreshTokenInvalid, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate refresh token")
+		return
+	}
+
+	// Revoking is the check that counts: a token exchanged concurrently is revoked only once,
+	// and only that request gets a new pair
+	err = auth.RevokeRefreshToken(h.db, req.RefreshToken)
+	if err == auth.ErrRefreshTokenInvalid {
+		respondError(w, http.StatusUnauthorized, apierror.CodeRefreshTokenInvalid, "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to rotate refresh token")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Where("id = ?", record.UserID).First(&user).Error; err != nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeRefreshTokenInvalid, "Invalid or expired refresh token")
+		return
+	}
+
+	token, err := auth.GenerateToken(user)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(h.db, user)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
 		return
 	}
 
@@ -152,21 +254,53 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	user.Password = ""
 
 	response := AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
 	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
-// Logout handles user logout
+// Logout handles user logout. The bearer token of the request is blacklisted so it is
+// rejected from now on, and a refresh token in the body is revoked so the session cannot be
+// renewed.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// In a stateless JWT implementation, logout is typically handled on the client side
-	// by removing the token. However, we can implement token blacklisting here if needed.
+	var req RefreshRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+			return
+		}
+
+		if !validateRequest(w, &req) {
+			return
+		}
+	}
+
+	// An expired or invalid token needs no revoking, it is rejected already
+	if tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); tokenString != "" && h.db != nil {
+		if token, err := auth.ValidateToken(tokenString); err == nil {
+			if claims, ok := token.Claims.(*auth.Claims); ok {
+				if err := auth.BlacklistToken(h.db, claims, tokenString); err != nil {
+					respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to revoke token")
+					return
+				}
+			}
+		}
+	}
+
+	if req.RefreshToken != "" && h.db != nil {
+		// A refresh token that is already revoked or expired leaves nothing to end
+		if err := auth.RevokeRefreshToken(h.db, req.RefreshToken); err != nil && err != auth.ErrRefreshTokenInvalid {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to revoke refresh token")
+			return
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out successfully"})
-}
\ No newline at end of file
+}
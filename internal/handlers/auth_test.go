@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/mailer"
+)
+
+// refresh exchanges the refresh token and returns the recorded response
+func refresh(h *AuthHandler, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/auth/refresh", strings.NewReader(`{"refresh_token": "`+token+`"}`))
+	r.Header.Set("Content-Type", "application/json")
+	h.Refresh(w, r)
+	return w
+}
+
+func TestRefreshRejectsRotatedToken(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAuthHandler(db, mailer.LogSender{})
+	user := createTestUser(t, db, "user")
+	token, err := auth.GenerateRefreshToken(db, user)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+
+	w := refresh(h, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh returned %d: %s", w.Code, w.Body.String())
+	}
+	var response AuthResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode refresh response: %v", err)
+	}
+	if response.Token == "" || response.RefreshToken == "" || response.RefreshToken == token {
+		t.Fatalf("refresh returned access token %q and refresh token %q, want a new pair", response.Token, response.RefreshToken)
+	}
+
+	w = refresh(h, token)
+	if w.Code != http.StatusUnauthorized || responseErrorCode(t, w) != apierror.CodeRefreshTokenInvalid {
+		t.Fatalf("second refresh with the rotated token returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := refresh(h, response.RefreshToken); w.Code != http.StatusOK {
+		t.Fatalf("refresh with the new token returned %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRefreshRotatesTokenOnce(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAuthHandler(db, mailer.LogSender{})
+	token, err := auth.GenerateRefreshToken(db, createTestUser(t, db, "user"))
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+
+	const attempts = 8
+	codes := make([]int, attempts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			codes[i] = refresh(h, token).Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	rotated := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusOK:
+			rotated++
+		case http.StatusUnauthorized:
+		default:
+			t.Errorf("refresh %d returned %d", i, code)
+		}
+	}
+	if rotated != 1 {
+		t.Fatalf("%d concurrent refreshes of one token succeeded, want 1", rotated)
+	}
+}
+
+func TestRegisterRejectsEmailVariants(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAuthHandler(db, mailer.LogSender{})
+	t.Setenv("EMAIL_CANONICALIZATION", auth.CanonicalizeGmail)
+
+	register := func(email string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/api/register", strings.NewReader(`{"name": "Buyer", "email": "`+email+`", "password": "correct-horse-battery"}`))
+		r.Header.Set("Content-Type", "application/json")
+		h.Register(w, r)
+		return w
+	}
+
+	if w := register("John.Doe+tickets@gmail.com"); w.Code != http.StatusCreated {
+		t.Fatalf("first registration returned %d: %s", w.Code, w.Body.String())
+	}
+	for _, variant := range []string{"johndoe@gmail.com", "john.doe+2@googlemail.com", "j.o.h.n.d.o.e@gmail.com"} {
+		if w := register(variant); w.Code != http.StatusConflict || responseErrorCode(t, w) != apierror.CodeEmailAlreadyExists {
+			t.Errorf("registering %s returned %d: %s", variant, w.Code, w.Body.String())
+		}
+	}
+
+	// The address is stored as given, for delivery
+	var user models.User
+	db.Where("canonical_email = ?", "johndoe@gmail.com").First(&user)
+	if user.Email != "John.Doe+tickets@gmail.com" {
+		t.Fatalf("stored email is %q, want the address as registered", user.Email)
+	}
+}
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAuthHandler(db, mailer.LogSender{})
+	user := createTestUser(t, db, "user")
+	anyRole := func(next http.Handler) http.Handler { return next }
+
+	token, err := auth.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	other, err := auth.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if code := bearerRequest(db, anyRole, token); code != http.StatusOK {
+		t.Fatalf("token before logout returned %d, want %d", code, http.StatusOK)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/logout", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	h.Logout(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if code := bearerRequest(db, anyRole, token); code != http.StatusUnauthorized {
+		t.Fatalf("token after logout returned %d, want %d", code, http.StatusUnauthorized)
+	}
+	// Other sessions of the user stay signed in
+	if code := bearerRequest(db, anyRole, other); code != http.StatusOK {
+		t.Fatalf("another token after logout returned %d, want %d", code, http.StatusOK)
+	}
+
+	// The entry is purged once the token has expired and would be rejected anyway
+	if purged, err := jobs.PurgeExpiredBlacklistedTokens(db, time.Now()); err != nil || purged != 0 {
+		t.Fatalf("purge before the token expired removed %d entries, err %v", purged, err)
+	}
+	if purged, err := jobs.PurgeExpiredBlacklistedTokens(db, time.Now().Add(25*time.Hour)); err != nil || purged != 1 {
+		t.Fatalf("purge after the token expired removed %d entries, err %v, want 1", purged, err)
+	}
+}
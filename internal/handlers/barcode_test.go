@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// getBarcodeImage requests the ticket's barcode image as the user
+func getBarcodeImage(h *TicketHandler, ticket models.Ticket, user models.User) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.GetTicketBarcodeImage(w, authedRequest("GET", "/api/tickets/"+vars["id"]+"/barcode.png", "", user, vars))
+	return w
+}
+
+// validateByCode validates the ticket matching the scanned QR or barcode body as the admin
+func validateByCode(h *TicketHandler, admin models.User, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ValidateTicketByQR(w, authedRequest("POST", "/api/tickets/validate", body, admin, nil))
+	return w
+}
+
+func TestTicketBarcodeRendersAndValidates(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("TICKET_BARCODES", "true")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	holder := createTestUser(t, db, "user")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), holder)
+	if ticket.Barcode == nil || *ticket.Barcode == "" {
+		t.Fatal("ticket was issued without a barcode")
+	}
+
+	w := getBarcodeImage(h, ticket, holder)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("barcode image returned %d with %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if _, err := png.Decode(bytes.NewReader(w.Body.Bytes())); err != nil {
+		t.Fatalf("decode barcode image: %v", err)
+	}
+
+	if w := validateByCode(h, admin, `{"barcode": "0000000000000000"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown barcode returned %d", w.Code)
+	}
+	if w := validateByCode(h, admin, `{"barcode": "`+*ticket.Barcode+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("barcode validation returned %d: %s", w.Code, w.Body.String())
+	}
+	if got := ticketStatus(t, h, ticket); got != "used" {
+		t.Fatalf("ticket validated by barcode is %q, want used", got)
+	}
+
+	// The QR code and barcode admit the same ticket, so the QR code is now used up too
+	w = validateByCode(h, admin, `{"qr_code": "`+ticket.QRCode+`"}`)
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeTicketNotValid {
+		t.Fatalf("QR scan after the barcode scan returned %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTicketWithoutBarcode(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("TICKET_BARCODES", "false")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	holder := createTestUser(t, db, "user")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), holder)
+	if ticket.Barcode != nil {
+		t.Fatalf("ticket was issued with barcode %q while barcodes are disabled", *ticket.Barcode)
+	}
+
+	if w := getBarcodeImage(h, ticket, holder); w.Code != http.StatusNotFound {
+		t.Fatalf("barcode image of a ticket without one returned %d", w.Code)
+	}
+	if w := validateByCode(h, admin, `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("validation without a code returned %d", w.Code)
+	}
+	if w := validateByCode(h, admin, `{"qr_code": "`+ticket.QRCode+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("QR validation returned %d: %s", w.Code, w.Body.String())
+	}
+}
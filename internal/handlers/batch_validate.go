@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// maxBatchScans caps the number of scans that can be synced in one request
+const maxBatchScans = 1000
+
+// maxScanClockSkew is how far in the future a scanner's clock may run before its scans are
+// rejected
+const maxScanClockSkew = 5 * time.Minute
+
+// scanWindowBeforeEvent is how long before the event starts its gates may admit ticket holders
+const scanWindowBeforeEvent = 24 * time.Hour
+
+// Per-scan outcomes of a batch validation
+const (
+	scanResultValid       = "valid"
+	scanResultAlreadyUsed = "already_used"
+	scanResultNotFound    = "not_found"
+	scanResultInvalid     = "invalid"
+	scanResultError       = "error"
+)
+
+// BatchScan is one scan recorded by an offline gate scanner
+type BatchScan struct {
+	QRCode    string    `json:"qr_code"`
+	ScannedAt time.Time `json:"scanned_at"` // when the scanner read the code; defaults to now, bounded by the clock skew and the event's gate window
+}
+
+// BatchScanResult is the outcome of one scan of a batch
+type BatchScanResult struct {
+	QRCode    string    `json:"qr_code"`
+	ScannedAt time.Time `json:"scanned_at"`
+	Result    string    `json:"result"`
+	TicketID  *uint     `json:"ticket_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// validateScan checks in the ticket of one scan at its scan time. Failures are reported in the
+// result, so they do not stop the rest of the batch. A scan already recorded by an earlier sync
+// is reported valid again instead of being logged twice. Scan times later than now allows for
+// clock skew, or before the event's gates open, are rejected as invalid.
+func (h *TicketHandler) validateScan(r *http.Request, scan BatchScan, now time.Time) BatchScanResult {
+	result := BatchScanResult{QRCode: scan.QRCode, ScannedAt: scan.ScannedAt}
+	if scan.QRCode == "" {
+		result.Result, result.Error = scanResultInvalid, "QR code is required"
+		return result
+	}
+	if scan.ScannedAt.After(now.Add(maxScanClockSkew)) {
+		result.Result, result.Error = scanResultInvalid, "Scan time is in the future"
+		return result
+	}
+	claims, err := verifyQRPayload(scan.QRCode)
+	if err != nil {
+		result.Result, result.Error = scanResultInvalid, "QR code is not authentic"
+		return result
+	}
+
+	var ticket models.Ticket
+	if err := h.db.Preload("Event").Where("id = ? AND qr_code = ?", claims.TicketID, scan.QRCode).First(&ticket).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			result.Result, result.Error = scanResultNotFound, "No ticket matches this QR code"
+			return result
+		}
+		result.Result, result.Error = scanResultError, "Failed to retrieve ticket"
+		return result
+	}
+	result.TicketID = &ticket.ID
+
+	if scan.ScannedAt.Before(ticket.Event.Date.Add(-scanWindowBeforeEvent)) {
+		result.Result, result.Error = scanResultInvalid, "Scan time is before the event's gates open"
+		return result
+	}
+
+	var recorded int
+	if err := h.db.Model(&models.AttendanceLog{}).Where("ticket_id = ? AND checked_in_at = ?", ticket.ID, scan.ScannedAt).
+		Count(&recorded).Error; err != nil {
+		result.Result, result.Error = scanResultError, "Failed to retrieve attendance"
+		return result
+	}
+	if recorded > 0 {
+		result.Result = scanResultValid
+		return result
+	}
+
+	if message := ticketStatusError(ticket.Status); message != "" && ticket.Status != "used" {
+		result.Result, result.Error = scanResultInvalid, message
+		return result
+	}
+
+	_, err = admitTicket(h.db, r, &ticket, ticket.Event, scan.ScannedAt)
+	switch {
+	case err == errTicketAlreadyUsed || err == errReentryNotAllowed || err == errTicketNoLongerValid:
+		result.Result, result.Error = scanResultAlreadyUsed, ticketStatusError("used")
+	case err != nil:
+		result.Result, result.Error = scanResultError, "Failed to validate ticket"
+	default:
+		result.Result = scanResultValid
+	}
+	return result
+}
+
+// ValidateTicketBatch validates the scans synced by an offline gate scanner (admin only). Scans
+// are checked in oldest first at the time they were scanned, and scans repeated with the same
+// QR code and timestamp are processed once. Results are returned in request order.
+func (h *TicketHandler) ValidateTicketBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var scans []BatchScan
+	if err := decodeJSON(r, &scans); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if len(scans) == 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "At least one scan is required")
+		return
+	}
+	if len(scans) > maxBatchScans {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("At most %d scans can be validated at once", maxBatchScans))
+		return
+	}
+
+	// The database stores microseconds, so compare scan times in UTC at that precision
+	now := time.Now()
+	seen := map[BatchScan]bool{}
+	var unique []BatchScan
+	for _, scan := range scans {
+		if scan.ScannedAt.IsZero() {
+			scan.ScannedAt = now
+		}
+		scan.ScannedAt = scan.ScannedAt.UTC().Truncate(time.Microsecond)
+		if seen[scan] {
+			continue
+		}
+		seen[scan] = true
+		unique = append(unique, scan)
+	}
+
+	// Replay the scans in the order they happened, so re-entries follow their check-outs
+	order := make([]int, len(unique))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return unique[order[a]].ScannedAt.Before(unique[order[b]].ScannedAt)
+	})
+
+	results := make([]BatchScanResult, len(unique))
+	counts := map[string]int{}
+	for _, i := range order {
+		results[i] = h.validateScan(r, unique[i], now)
+		counts[results[i].Result]++
+	}
+
+	response := map[string]interface{}{
+		"message":    "Scans processed",
+		"processed":  len(unique),
+		"duplicates": len(scans) - len(unique),
+		"counts":     counts,
+		"results":    results,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestValidateTicketBatchBoundsScanTimes(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 5, 20)
+	now := time.Now()
+	if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumn("date", now.Add(time.Hour)).Error; err != nil {
+		t.Fatalf("move event start: %v", err)
+	}
+
+	var tickets []models.Ticket
+	for i := 0; i < 4; i++ {
+		tickets = append(tickets, createTestTicket(t, db, event, createTestUser(t, db, "user")))
+	}
+	scans := []BatchScan{
+		{QRCode: tickets[0].QRCode, ScannedAt: now.Add(-time.Minute)},
+		{QRCode: tickets[1].QRCode, ScannedAt: now.Add(maxScanClockSkew - time.Minute)},
+		{QRCode: tickets[2].QRCode, ScannedAt: now.Add(time.Hour)},
+		{QRCode: tickets[3].QRCode, ScannedAt: now.Add(-scanWindowBeforeEvent)},
+	}
+	want := []string{scanResultValid, scanResultValid, scanResultInvalid, scanResultInvalid}
+
+	body, err := json.Marshal(scans)
+	if err != nil {
+		t.Fatalf("encode scans: %v", err)
+	}
+	w := httptest.NewRecorder()
+	h.ValidateTicketBatch(w, authedRequest("POST", "/api/tickets/validate/batch", string(body), admin, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("batch validation returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Results []BatchScanResult `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode batch response: %v", err)
+	}
+	if len(response.Results) != len(scans) {
+		t.Fatalf("batch returned %d results, want %d", len(response.Results), len(scans))
+	}
+
+	for i, result := range response.Results {
+		if result.Result != want[i] {
+			t.Errorf("scan %d is %q (%s), want %q", i, result.Result, result.Error, want[i])
+		}
+		var logs int
+		db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", tickets[i].ID).Count(&logs)
+		if admitted := want[i] == scanResultValid; (logs == 1) != admitted {
+			t.Errorf("scan %d left %d attendance logs", i, logs)
+		}
+	}
+}
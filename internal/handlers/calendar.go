@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+)
+
+// CalendarEvent is the minimal event representation used in calendar views
+type CalendarEvent struct {
+	ID    uint      `json:"id"`
+	Title string    `json:"title"`
+	Time  time.Time `json:"time"`
+}
+
+// CalendarDay holds the events taking place on a single day
+type CalendarDay struct {
+	Date   string          `json:"date"`
+	Events []CalendarEvent `json:"events"`
+}
+
+// groupEventsByDay buckets events by their calendar day in UTC, preserving event order
+func groupEventsByDay(events []models.Event) []CalendarDay {
+	days := []CalendarDay{}
+	index := map[string]int{}
+
+	for _, event := range events {
+		day := event.Date.UTC().Format("2006-01-02")
+		i, ok := index[day]
+		if !ok {
+			i = len(days)
+			index[day] = i
+			days = append(days, CalendarDay{Date: day, Events: []CalendarEvent{}})
+		}
+		days[i].Events = append(days[i].Events, CalendarEvent{
+			ID:    event.ID,
+			Title: event.Title,
+			Time:  event.Date,
+		})
+	}
+
+	return days
+}
+
+// GetEventCalendar retrieves the events of a month grouped by day
+func (h *EventHandler) GetEventCalendar(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	now := time.Now().UTC()
+	year := now.Year()
+	month := int(now.Month())
+
+	if value := r.URL.Query().Get("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid year")
+			return
+		}
+		year = parsed
+	}
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 12 {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid month")
+			return
+		}
+		month = parsed
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	var events []models.Event
+	if err := h.db.Where("date >= ? AND date < ? AND status <> ?", start, end, "cancelled").
+		Order(stableOrder("date asc", "id")).Find(&events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve events")
+		return
+	}
+
+	response := map[string]interface{}{
+		"year":  year,
+		"month": month,
+		"days":  groupEventsByDay(events),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
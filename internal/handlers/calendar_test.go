@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestGetEventCalendarBucketsAcrossMonthBoundary(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	user := createTestUser(t, db, "user")
+
+	dates := map[string]time.Time{
+		"end of January":     time.Date(2027, 1, 31, 23, 59, 59, 0, time.UTC),
+		"start of February":  time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC),
+		"later on February":  time.Date(2027, 2, 1, 18, 30, 0, 0, time.UTC),
+		"end of February":    time.Date(2027, 2, 28, 23, 59, 59, 0, time.UTC),
+		"start of March":     time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC),
+		"cancelled February": time.Date(2027, 2, 10, 12, 0, 0, 0, time.UTC),
+	}
+	ids := map[string]uint{}
+	for title, date := range dates {
+		event := createTestEvent(t, db, 10, 20)
+		updates := map[string]interface{}{"title": title, "date": date}
+		if title == "cancelled February" {
+			updates["status"] = "cancelled"
+		}
+		db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumns(updates)
+		ids[title] = event.ID
+	}
+
+	w := httptest.NewRecorder()
+	h.GetEventCalendar(w, authedRequest("GET", "/api/events/calendar?year=2027&month=2", "", user, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("calendar returned %d: %s", w.Code, w.Body)
+	}
+	var response struct {
+		Days []CalendarDay `json:"days"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode calendar: %v", err)
+	}
+
+	want := []struct {
+		date   string
+		events []uint
+	}{
+		{date: "2027-02-01", events: []uint{ids["start of February"], ids["later on February"]}},
+		{date: "2027-02-28", events: []uint{ids["end of February"]}},
+	}
+	if len(response.Days) != len(want) {
+		t.Fatalf("calendar has days %+v, want %d days", response.Days, len(want))
+	}
+	for i, day := range response.Days {
+		if day.Date != want[i].date || len(day.Events) != len(want[i].events) {
+			t.Fatalf("day %d is %+v, want %s with events %v", i, day, want[i].date, want[i].events)
+		}
+		for j, event := range day.Events {
+			if event.ID != want[i].events[j] {
+				t.Errorf("%s event %d is %d, want %d", day.Date, j, event.ID, want[i].events[j])
+			}
+		}
+	}
+}
+
+func TestGetEventCalendarRejectsInvalidMonth(t *testing.T) {
+	h := NewEventHandler(nil)
+	for _, query := range []string{"month=13", "month=0", "year=abc"} {
+		w := httptest.NewRecorder()
+		h.GetEventCalendar(w, authedRequest("GET", "/api/events/calendar?"+query, "", models.User{ID: 1}, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("calendar with %s returned %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// maxBulkCancelTickets caps the number of tickets that can be cancelled in one request
+const maxBulkCancelTickets = 100
+
+// Per-ticket outcomes of a bulk cancellation
+const (
+	cancelResultCancelled      = "cancelled"
+	cancelResultNotFound       = "not_found"
+	cancelResultNotCancellable = "not_cancellable"
+	cancelResultPastEvent      = "past_event"
+	cancelResultConflict       = "conflict"
+)
+
+// CancelTicketsRequest represents the bulk cancel request payload
+type CancelTicketsRequest struct {
+	TicketIDs []uint `json:"ticket_ids" binding:"required,min=1"`
+}
+
+// CancelResult is the outcome of cancelling one ticket of a batch
+type CancelResult struct {
+	TicketID     uint     `json:"ticket_id"`
+	Result       string   `json:"result"`
+	Error        string   `json:"error,omitempty"`
+	RefundAmount *float64 `json:"refund_amount,omitempty"`
+}
+
+// refundAmount applies the refund policy to a cancelled ticket. REFUND_PERCENTAGE of the amount
+// charged for it, after the order discount and tax, is refunded, or nothing when the event
+// starts within REFUND_CUTOFF_HOURS.
+func refundAmount(ticket models.Ticket, event models.Event, now time.Time) float64 {
+	cutoff := time.Duration(config.GetInt("REFUND_CUTOFF_HOURS", 0)) * time.Hour
+	if event.Date.Sub(now) < cutoff {
+		return 0
+	}
+
+	percentage := config.GetFloat("REFUND_PERCENTAGE", 100)
+	return roundCents(ticket.AmountCharged * math.Max(math.Min(percentage, 100), 0) / 100)
+}
+
+// cancelLockedTicket cancels a valid ticket locked by the caller's transaction, releasing its
+// seat and recording a refund per the refund policy, and returns the amount refunded. The update
+// is guarded on the status as well as the row lock, so a check-in that committed first wins and
+// errTicketNoLongerValid is returned. Releasing the sold count is left to the caller.
+func cancelLockedTicket(tx *gorm.DB, ticket models.Ticket, event models.Event, now time.Time) (float64, error) {
+	result := tx.Model(&models.Ticket{}).Where("id = ? AND status = ?", ticket.ID, "valid").Update("status", "cancelled")
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errTicketNoLongerValid
+	}
+
+	// Free the seat so it can be sold again
+	if ticket.SeatID != nil {
+		if err := releaseSeat(tx, *ticket.SeatID); err != nil {
+			return 0, err
+		}
+	}
+
+	amount := refundAmount(ticket, event, now)
+	if amount > 0 {
+		refund := models.Refund{
+			TicketID: ticket.ID,
+			OrderID:  ticket.OrderID,
+			UserID:   ticketHolderID(ticket),
+			Amount:   amount,
+			Status:   "pending",
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return 0, err
+		}
+	}
+	return amount, nil
+}
+
+// CancelTicket cancels one of the current user's tickets. A valid ticket for a future event is
+// cancelled, its seat released and a refund recorded per the refund policy.
+func (h *TicketHandler) CancelTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the ticket so a concurrent check-in or transfer cannot interleave with the cancellation
+	var ticket models.Ticket
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ? AND user_id = ?", ticketID, userID).First(&ticket).Error; err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	if ticket.Status != "valid" {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, "Only valid tickets can be cancelled")
+		return
+	}
+
+	var event models.Event
+	if err := tx.Where("id = ?", ticket.EventID).First(&event).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	now := time.Now()
+	if event.Date.Before(now) {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeEventPast, "Cannot cancel tickets for past events")
+		return
+	}
+
+	amount, err := cancelLockedTicket(tx, ticket, event, now)
+	if err == errTicketNoLongerValid {
+		tx.Rollback()
+		respondError(w, http.StatusConflict, apierror.CodeTicketNoLongerValid, "Ticket is no longer valid")
+		return
+	}
+	if err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to cancel ticket")
+		return
+	}
+
+	// Give the freed seat back to the event
+	if err := releaseSoldCount(tx, event.ID, 1); err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to release ticket")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to cancel ticket")
+		return
+	}
+
+	recordAudit(h.db, r, "ticket.status_changed", "ticket", ticket.ID, "valid -> cancelled")
+
+	// Offer the freed seat to the event's waitlist
+	go h.serveWaitlist(event.ID, 1)
+
+	response := map[string]interface{}{
+		"message":       "Ticket cancelled",
+		"ticket_id":     ticket.ID,
+		"refund_amount": amount,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CancelMyTickets cancels several of the current user's tickets in one transaction. Each valid
+// ticket for a future event is cancelled, its seat released and a refund recorded per the
+// refund policy; the other tickets are reported with an error entry.
+func (h *TicketHandler) CancelMyTickets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var req CancelTicketsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if len(req.TicketIDs) == 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "At least one ticket ID is required")
+		return
+	}
+	if len(req.TicketIDs) > maxBulkCancelTickets {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("At most %d tickets can be cancelled at once", maxBulkCancelTickets))
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the tickets so a concurrent check-in or transfer cannot interleave with the cancellation
+	var tickets []models.Ticket
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id IN (?)", req.TicketIDs).Find(&tickets).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	byID := map[uint]models.Ticket{}
+	var eventIDs []uint
+	for _, ticket := range tickets {
+		byID[ticket.ID] = ticket
+		eventIDs = append(eventIDs, ticket.EventID)
+	}
+
+	var events []models.Event
+	if len(eventIDs) > 0 {
+		if err := tx.Where("id IN (?)", eventIDs).Find(&events).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve events")
+			return
+		}
+	}
+	eventsByID := map[uint]models.Event{}
+	for _, event := range events {
+		eventsByID[event.ID] = event
+	}
+
+	now := time.Now()
+	results := make([]CancelResult, 0, len(req.TicketIDs))
+	released := map[uint]int{}
+	seen := map[uint]bool{}
+	var cancelled []uint
+	var refundTotal float64
+	for _, ticketID := range req.TicketIDs {
+		if seen[ticketID] {
+			continue
+		}
+		seen[ticketID] = true
+
+		// Tickets of other users are reported the same as missing ones
+		ticket, found := byID[ticketID]
+		if !found || ticket.UserID == nil || *ticket.UserID != userID {
+			results = append(results, CancelResult{TicketID: ticketID, Result: cancelResultNotFound, Error: "Ticket not found"})
+			continue
+		}
+
+		if ticket.Status != "valid" {
+			results = append(results, CancelResult{TicketID: ticketID, Result: cancelResultNotCancellable, Error: "Only valid tickets can be cancelled"})
+			continue
+		}
+
+		event := eventsByID[ticket.EventID]
+		if event.Date.Before(now) {
+			results = append(results, CancelResult{TicketID: ticketID, Result: cancelResultPastEvent, Error: "Cannot cancel tickets for past events"})
+			continue
+		}
+
+		amount, err := cancelLockedTicket(tx, ticket, event, now)
+		if err == errTicketNoLongerValid {
+			results = append(results, CancelResult{TicketID: ticketID, Result: cancelResultConflict, Error: "Ticket is no longer valid"})
+			continue
+		}
+		if err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to cancel tickets")
+			return
+		}
+		refundTotal += amount
+
+		released[ticket.EventID]++
+		cancelled = append(cancelled, ticket.ID)
+		results = append(results, CancelResult{TicketID: ticketID, Result: cancelResultCancelled, RefundAmount: &amount})
+	}
+
+	// Give the freed seats back to the events
+	for eventID, quantity := range released {
+		if err := releaseSoldCount(tx, eventID, quantity); err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to release tickets")
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to cancel tickets")
+		return
+	}
+
+	for _, ticketID := range cancelled {
+		recordAudit(h.db, r, "ticket.status_changed", "ticket", ticketID, "valid -> cancelled")
+	}
+
+	// Offer the freed seats to the waitlists of the events
+	for eventID, quantity := range released {
+		go h.serveWaitlist(eventID, quantity)
+	}
+
+	response := map[string]interface{}{
+		"message":      "Tickets cancelled",
+		"cancelled":    len(cancelled),
+		"refund_total": roundCents(refundTotal),
+		"results":      results,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
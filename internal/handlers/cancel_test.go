@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestRefundAmount(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	ticket := models.Ticket{PricePaid: 100, AmountCharged: 88.55}
+
+	tests := []struct {
+		name       string
+		percentage string
+		cutoff     string
+		eventDate  time.Time
+		want       float64
+	}{
+		{name: "full refund of the amount charged", percentage: "100", cutoff: "0", eventDate: now.Add(48 * time.Hour), want: 88.55},
+		{name: "partial refund is rounded to cents", percentage: "50", cutoff: "0", eventDate: now.Add(48 * time.Hour), want: 44.28},
+		{name: "nothing within the cutoff", percentage: "100", cutoff: "24", eventDate: now.Add(23 * time.Hour), want: 0},
+		{name: "full refund just outside the cutoff", percentage: "100", cutoff: "24", eventDate: now.Add(24 * time.Hour), want: 88.55},
+		{name: "percentage is capped at 100", percentage: "150", cutoff: "0", eventDate: now.Add(48 * time.Hour), want: 88.55},
+		{name: "negative percentage refunds nothing", percentage: "-10", cutoff: "0", eventDate: now.Add(48 * time.Hour), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REFUND_PERCENTAGE", tt.percentage)
+			t.Setenv("REFUND_CUTOFF_HOURS", tt.cutoff)
+			if got := refundAmount(ticket, models.Event{Date: tt.eventDate}, now); got != tt.want {
+				t.Errorf("refundAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCancelTicketRacesValidation cancels a ticket while it is being checked in. Exactly one of
+// the two may succeed, and the ticket must end up in the state of the winner.
+func TestCancelTicketRacesValidation(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	holder := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+
+	for i := 0; i < 20; i++ {
+		ticket := createTestTicket(t, db, event, holder)
+		vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+		target := "/api/tickets/" + vars["id"]
+
+		cancel := httptest.NewRecorder()
+		validate := httptest.NewRecorder()
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			h.CancelTicket(cancel, authedRequest("POST", target+"/cancel", "", holder, vars))
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			h.ValidateTicket(validate, authedRequest("POST", target+"/validate", "", admin, vars))
+		}()
+		close(start)
+		wg.Wait()
+
+		cancelled := cancel.Code == http.StatusOK
+		validated := validate.Code == http.StatusOK
+		if cancelled == validated {
+			t.Fatalf("ticket %d: cancel returned %d and validate %d, want exactly one to succeed", ticket.ID, cancel.Code, validate.Code)
+		}
+
+		var stored models.Ticket
+		if err := db.Where("id = ?", ticket.ID).First(&stored).Error; err != nil {
+			t.Fatalf("reload ticket: %v", err)
+		}
+		var checkIns, refunds int
+		db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", ticket.ID).Count(&checkIns)
+		db.Model(&models.Refund{}).Where("ticket_id = ?", ticket.ID).Count(&refunds)
+
+		switch {
+		case cancelled && (stored.Status != "cancelled" || checkIns != 0 || refunds != 1):
+			t.Fatalf("ticket %d: cancel won but status is %q with %d check-ins and %d refunds", ticket.ID, stored.Status, checkIns, refunds)
+		case validated && (stored.Status != "used" || checkIns != 1 || refunds != 0):
+			t.Fatalf("ticket %d: validate won but status is %q with %d check-ins and %d refunds", ticket.ID, stored.Status, checkIns, refunds)
+		}
+	}
+}
+
+func TestCancelMyTicketsMixedOwnership(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("REFUND_PERCENTAGE", "50")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	holder := createTestUser(t, db, "user")
+	other := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+
+	first := createTestTicket(t, db, event, holder)
+	second := createTestTicket(t, db, event, holder)
+	used := createTestTicket(t, db, event, holder)
+	db.Model(&models.Ticket{}).Where("id = ?", used.ID).UpdateColumn("status", "used")
+	foreign := createTestTicket(t, db, event, other)
+	missing := foreign.ID + 1000
+
+	ids := []uint{first.ID, foreign.ID, second.ID, used.ID, missing, first.ID}
+	body, _ := json.Marshal(CancelTicketsRequest{TicketIDs: ids})
+	w := httptest.NewRecorder()
+	h.CancelMyTickets(w, authedRequest("POST", "/api/me/tickets/cancel", string(body), holder, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bulk cancel returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Cancelled   int            `json:"cancelled"`
+		RefundTotal float64        `json:"refund_total"`
+		Results     []CancelResult `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode bulk cancel response: %v", err)
+	}
+
+	// The repeated ID is reported once, and other users' tickets look missing
+	want := []struct {
+		id     uint
+		result string
+	}{
+		{first.ID, cancelResultCancelled},
+		{foreign.ID, cancelResultNotFound},
+		{second.ID, cancelResultCancelled},
+		{used.ID, cancelResultNotCancellable},
+		{missing, cancelResultNotFound},
+	}
+	if len(response.Results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(response.Results), len(want), response.Results)
+	}
+	for i, result := range response.Results {
+		if result.TicketID != want[i].id || result.Result != want[i].result {
+			t.Errorf("result %d is %+v, want %q for ticket %d", i, result, want[i].result, want[i].id)
+		}
+		if result.Result == cancelResultCancelled && (result.RefundAmount == nil || *result.RefundAmount != 10) {
+			t.Errorf("ticket %d refund is %v, want half of 20", result.TicketID, result.RefundAmount)
+		}
+	}
+	if response.Cancelled != 2 || response.RefundTotal != 20 {
+		t.Fatalf("cancelled %d tickets refunding %.2f, want 2 refunding 20.00", response.Cancelled, response.RefundTotal)
+	}
+
+	var refunds int
+	db.Model(&models.Refund{}).Where("user_id = ?", holder.ID).Count(&refunds)
+	if refunds != 2 {
+		t.Errorf("recorded %d refunds, want 2", refunds)
+	}
+	for ticket, status := range map[uint]string{first.ID: "cancelled", second.ID: "cancelled", used.ID: "used", foreign.ID: "valid"} {
+		var stored models.Ticket
+		db.Where("id = ?", ticket).First(&stored)
+		if stored.Status != status {
+			t.Errorf("ticket %d is %q, want %q", ticket, stored.Status, status)
+		}
+	}
+	if got := soldCount(t, h, event); got != 2 {
+		t.Fatalf("sold count after cancelling 2 of 4 tickets is %d", got)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+)
+
+// CategoryCount is the number of events in one category
+type CategoryCount struct {
+	Category   string `json:"category"`
+	EventCount int64  `json:"event_count"`
+}
+
+// allowedCategories returns the categories listed in EVENT_CATEGORIES, comma separated. An
+// empty set allows any category.
+func allowedCategories() map[string]bool {
+	allowed := map[string]bool{}
+	for _, category := range strings.Split(config.GetEnv("EVENT_CATEGORIES", ""), ",") {
+		if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+			allowed[category] = true
+		}
+	}
+	return allowed
+}
+
+// normalizeCategory lower-cases and trims a category, rejecting it when EVENT_CATEGORIES is
+// configured and does not list it. An empty category leaves the event uncategorized.
+func normalizeCategory(value string) (string, error) {
+	category := strings.ToLower(strings.TrimSpace(value))
+	if category == "" {
+		return "", nil
+	}
+
+	allowed := allowedCategories()
+	if len(allowed) > 0 && !allowed[category] {
+		names := make([]string, 0, len(allowed))
+		for name := range allowed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", errors.New("category must be one of " + strings.Join(names, ", "))
+	}
+	return category, nil
+}
+
+// GetCategories lists the categories in use with the number of events in each, largest first
+func (h *EventHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categories := []CategoryCount{}
+	if err := h.db.Model(&models.Event{}).
+		Select("category, COUNT(*) AS event_count").
+		Where("category <> ''").
+		Group("category").Order("event_count desc, category asc").
+		Scan(&categories).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve categories")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(categories)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// errTicketAlreadyUsed is returned when a used ticket is scanned again while its holder has
+// not checked out
+var errTicketAlreadyUsed = errors.New("ticket has already been used")
+
+// errReentryNotAllowed is returned when a checked out ticket is scanned again for an event that
+// does not allow re-entry
+var errReentryNotAllowed = errors.New("re-entry is not allowed")
+
+// errTicketNotCheckedIn is returned when checking out a ticket without an open check-in
+var errTicketNotCheckedIn = errors.New("ticket is not checked in")
+
+// latestAttendanceLog locks and returns the most recent attendance log of a ticket
+func latestAttendanceLog(tx *gorm.DB, ticketID uint) (models.AttendanceLog, error) {
+	var attendanceLog models.AttendanceLog
+	err := tx.Set("gorm:query_option", "FOR UPDATE").Where("ticket_id = ?", ticketID).
+		Order("checked_in_at desc, id desc").First(&attendanceLog).Error
+	return attendanceLog, err
+}
+
+// reenterTicket records a new check-in for a used ticket, so attendees can leave and come back.
+// Unless allowReentry is set the ticket is single-use: a holder who checked out gets
+// errReentryNotAllowed and one still inside errTicketAlreadyUsed. The ticket stays used.
+func reenterTicket(db *gorm.DB, r *http.Request, ticket *models.Ticket, allowReentry bool, checkedInAt time.Time) (models.AttendanceLog, error) {
+	tx := db.Begin()
+
+	// Lock the latest log even when every scan is admitted, so concurrent scans run one at a time
+	last, err := latestAttendanceLog(tx, ticket.ID)
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return models.AttendanceLog{}, err
+	}
+	if !allowReentry {
+		tx.Rollback()
+		if err == nil && last.CheckedOutAt != nil {
+			return models.AttendanceLog{}, errReentryNotAllowed
+		}
+		return models.AttendanceLog{}, errTicketAlreadyUsed
+	}
+
+	attendanceLog := models.AttendanceLog{
+		TicketID:    ticket.ID,
+		CheckedInAt: checkedInAt,
+		CheckedInBy: checkedInBy(r),
+	}
+	if err := tx.Create(&attendanceLog).Error; err != nil {
+		tx.Rollback()
+		return models.AttendanceLog{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return models.AttendanceLog{}, err
+	}
+
+	recordAudit(db, r, "ticket.reentered", "ticket", ticket.ID, "")
+	queueWebhookDeliveries(db, ticketValidatedWebhookEvent, TicketValidatedData{TicketID: ticket.ID, EventID: ticket.EventID, UserID: ticket.UserID, CheckedInAt: checkedInAt})
+	return attendanceLog, nil
+}
+
+// admitTicket checks in a valid ticket, or lets a used ticket back in on every scan for events
+// allowing re-entry. Used tickets of other events return errTicketAlreadyUsed, or
+// errReentryNotAllowed once checked out.
+func admitTicket(db *gorm.DB, r *http.Request, ticket *models.Ticket, event models.Event, checkedInAt time.Time) (models.AttendanceLog, error) {
+	if ticket.Status == "used" {
+		return reenterTicket(db, r, ticket, event.AllowReentry, checkedInAt)
+	}
+	return checkInTicket(db, r, ticket, checkedInAt)
+}
+
+// countScans returns the number of times a ticket was admitted
+func countScans(db *gorm.DB, ticketID uint) (int, error) {
+	var count int
+	err := db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", ticketID).Count(&count).Error
+	return count, err
+}
+
+// orderAttendanceLogs preloads attendance logs oldest first, so the export reads the first
+// check-in and the last check-out from the ends of the slice
+func orderAttendanceLogs(db *gorm.DB) *gorm.DB {
+	return db.Order("checked_in_at asc, id asc")
+}
+
+// timeOnSite sums the time between check-in and check-out of the ticket's closed attendance
+// logs. Logs still open are not counted.
+func timeOnSite(logs []models.AttendanceLog) time.Duration {
+	var total time.Duration
+	for _, attendanceLog := range logs {
+		if attendanceLog.CheckedOutAt != nil && attendanceLog.CheckedOutAt.After(attendanceLog.CheckedInAt) {
+			total += attendanceLog.CheckedOutAt.Sub(attendanceLog.CheckedInAt)
+		}
+	}
+	return total
+}
+
+// CheckOutTicket stamps the check-out time on the open attendance log of a ticket, so its
+// holder can later re-enter events allowing re-entry (admin only)
+func (h *TicketHandler) CheckOutTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	var ticket models.Ticket
+	if err := h.db.Where("id = ?", ticketID).First(&ticket).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	now := time.Now()
+	tx := h.db.Begin()
+
+	attendanceLog, err := latestAttendanceLog(tx, ticket.ID)
+	if err == nil && attendanceLog.CheckedOutAt != nil {
+		err = errTicketNotCheckedIn
+	}
+	if err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) || err == errTicketNotCheckedIn {
+			respondError(w, http.StatusBadRequest, apierror.CodeTicketNotCheckedIn, "Ticket is not checked in")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check out ticket")
+		return
+	}
+
+	if err := tx.Model(&models.AttendanceLog{}).Where("id = ?", attendanceLog.ID).
+		UpdateColumns(map[string]interface{}{"checked_out_at": now, "updated_at": now}).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check out ticket")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check out ticket")
+		return
+	}
+	attendanceLog.CheckedOutAt = &now
+
+	recordAudit(h.db, r, "ticket.checked_out", "ticket", ticket.ID, "")
+
+	response := map[string]interface{}{
+		"message":        "Ticket checked out successfully",
+		"attendance_log": attendanceLog,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
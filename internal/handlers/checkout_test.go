@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// scanTicket validates the ticket as the admin and returns the recorded response
+func scanTicket(h *TicketHandler, ticket models.Ticket, admin models.User) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.ValidateTicket(w, authedRequest("POST", "/api/tickets/"+vars["id"]+"/validate", "", admin, vars))
+	return w
+}
+
+// checkOutTicket checks the ticket out as the admin and returns the response code
+func checkOutTicket(h *TicketHandler, ticket models.Ticket, admin models.User) int {
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.CheckOutTicket(w, authedRequest("POST", "/api/tickets/"+vars["id"]+"/checkout", "", admin, vars))
+	return w.Code
+}
+
+// scanCount returns the scan count of a successful validation
+func scanCount(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var response struct {
+		ScanCount int `json:"scan_count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode validation response: %v", err)
+	}
+	return response.ScanCount
+}
+
+func TestValidateTicketIsSingleUseWithoutReentry(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), createTestUser(t, db, "user"))
+
+	w := scanTicket(h, ticket, admin)
+	if w.Code != http.StatusOK || scanCount(t, w) != 1 {
+		t.Fatalf("first scan returned %d: %s", w.Code, w.Body.String())
+	}
+
+	w = scanTicket(h, ticket, admin)
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeTicketNotValid {
+		t.Fatalf("second scan returned %d: %s", w.Code, w.Body.String())
+	}
+
+	// Checking out does not let the holder back in
+	if code := checkOutTicket(h, ticket, admin); code != http.StatusOK {
+		t.Fatalf("check-out returned %d", code)
+	}
+	w = scanTicket(h, ticket, admin)
+	if w.Code != http.StatusConflict || responseErrorCode(t, w) != apierror.CodeReentryNotAllowed {
+		t.Fatalf("scan after check-out returned %d: %s", w.Code, w.Body.String())
+	}
+
+	var logs int
+	db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", ticket.ID).Count(&logs)
+	if logs != 1 {
+		t.Fatalf("ticket has %d attendance logs, want 1", logs)
+	}
+}
+
+func TestValidateTicketCountsReentryScans(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 5, 20)
+	if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumn("allow_reentry", true).Error; err != nil {
+		t.Fatalf("allow re-entry: %v", err)
+	}
+	ticket := createTestTicket(t, db, event, createTestUser(t, db, "user"))
+
+	// Every scan is admitted, with or without a check-out in between
+	for scan := 1; scan <= 3; scan++ {
+		w := scanTicket(h, ticket, admin)
+		if w.Code != http.StatusOK {
+			t.Fatalf("scan %d returned %d: %s", scan, w.Code, w.Body.String())
+		}
+		if got := scanCount(t, w); got != scan {
+			t.Fatalf("scan %d reported scan count %d", scan, got)
+		}
+		if scan == 2 {
+			if code := checkOutTicket(h, ticket, admin); code != http.StatusOK {
+				t.Fatalf("check-out returned %d", code)
+			}
+		}
+	}
+
+	var stored models.Ticket
+	db.Where("id = ?", ticket.ID).First(&stored)
+	if stored.Status != "used" {
+		t.Fatalf("ticket is %q after re-entry, want used", stored.Status)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// ticketStatus returns the stored status of the ticket
+func ticketStatus(t *testing.T, h *TicketHandler, ticket models.Ticket) string {
+	t.Helper()
+	var stored models.Ticket
+	if err := h.db.Where("id = ?", ticket.ID).First(&stored).Error; err != nil {
+		t.Fatalf("reload ticket: %v", err)
+	}
+	return stored.Status
+}
+
+func TestEventCompletionPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		status string
+		admit  bool
+	}{
+		{policy: jobs.PostEventExpire, status: "expired", admit: false},
+		{policy: jobs.PostEventKeep, status: "valid", admit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			db := openTestDB(t)
+			h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+			admin := createTestUser(t, db, "admin")
+			past := createTestEvent(t, db, 5, 20)
+			upcoming := createTestEvent(t, db, 5, 20)
+			db.Model(&models.Event{}).Where("id = ?", past.ID).UpdateColumn("date", time.Now().Add(-6*time.Hour))
+
+			unused := createTestTicket(t, db, past, createTestUser(t, db, "user"))
+			used := createTestTicket(t, db, past, createTestUser(t, db, "user"))
+			db.Model(&models.Ticket{}).Where("id = ?", used.ID).UpdateColumn("status", "used")
+			future := createTestTicket(t, db, upcoming, createTestUser(t, db, "user"))
+
+			stop := jobs.StartEventCompletionWorker(db, 10*time.Millisecond, 4*time.Hour, tt.policy)
+			// Give the worker several runs, then wait for it to settle on the policy's status
+			time.Sleep(100 * time.Millisecond)
+			deadline := time.Now().Add(2 * time.Second)
+			for ticketStatus(t, h, unused) != tt.status && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			stop()
+
+			if got := ticketStatus(t, h, unused); got != tt.status {
+				t.Fatalf("unused ticket of the past event is %q, want %q", got, tt.status)
+			}
+			if got := ticketStatus(t, h, used); got != "used" {
+				t.Errorf("used ticket of the past event became %q", got)
+			}
+			if got := ticketStatus(t, h, future); got != "valid" {
+				t.Errorf("ticket of an upcoming event became %q", got)
+			}
+
+			// Only a kept ticket still admits its holder late
+			if w := scanTicket(h, unused, admin); (w.Code == http.StatusOK) != tt.admit {
+				t.Fatalf("late scan returned %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// customFieldTypes lists the accepted custom field types
+var customFieldTypes = map[string]bool{
+	"text":    true,
+	"number":  true,
+	"boolean": true,
+}
+
+// customFieldNamePattern keeps field names usable as export column keys
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CreateCustomFieldRequest represents the create custom field request payload
+type CreateCustomFieldRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type" binding:"omitempty,oneof=text number boolean"`
+	Required bool   `json:"required"`
+}
+
+// validateCustomFields checks the submitted answers against the event's custom fields and
+// returns them as strings keyed by field ID. Unknown fields, missing required fields and
+// values of the wrong type are rejected.
+func validateCustomFields(fields []models.CustomField, values map[string]interface{}) (map[uint]string, error) {
+	byName := map[string]models.CustomField{}
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	for name := range values {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("unknown custom field: %s", name)
+		}
+	}
+
+	answers := map[uint]string{}
+	for _, field := range fields {
+		value, ok := values[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required {
+				return nil, fmt.Errorf("custom field %s is required", field.Name)
+			}
+			continue
+		}
+
+		switch field.Type {
+		case "number":
+			number, ok := value.(float64)
+			if !ok {
+				return nil, fmt.Errorf("custom field %s must be a number", field.Name)
+			}
+			answers[field.ID] = strconv.FormatFloat(number, 'f', -1, 64)
+		case "boolean":
+			flag, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("custom field %s must be true or false", field.Name)
+			}
+			answers[field.ID] = strconv.FormatBool(flag)
+		default:
+			text, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("custom field %s must be text", field.Name)
+			}
+			answers[field.ID] = strings.TrimSpace(text)
+		}
+	}
+	return answers, nil
+}
+
+// customFieldColumns returns an export column per custom field, keyed and headed by field name
+func customFieldColumns(fields []models.CustomField) []exportColumn {
+	columns := make([]exportColumn, 0, len(fields))
+	for _, field := range fields {
+		fieldID := field.ID
+		columns = append(columns, exportColumn{Key: field.Name, Header: field.Name, Value: func(ticket models.Ticket, redact map[string]bool) string {
+			for _, answer := range ticket.Fields {
+				if answer.CustomFieldID == fieldID {
+					return answer.Value
+				}
+			}
+			return ""
+		}})
+	}
+	return columns
+}
+
+// attendeeExportColumnsFor returns the built-in export columns followed by the event's custom fields
+func attendeeExportColumnsFor(db *gorm.DB, eventID uint64) ([]exportColumn, error) {
+	var fields []models.CustomField
+	if err := db.Where("event_id = ?", eventID).Order("id asc").Find(&fields).Error; err != nil {
+		return nil, err
+	}
+
+	columns := make([]exportColumn, 0, len(attendeeExportColumns)+len(fields))
+	columns = append(columns, attendeeExportColumns...)
+	return append(columns, customFieldColumns(fields)...), nil
+}
+
+// GetCustomFields lists the custom registration fields of an event
+func (h *EventHandler) GetCustomFields(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	fields := []models.CustomField{}
+	if err := h.db.Where("event_id = ?", eventID).Order("id asc").Find(&fields).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve custom fields")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fields)
+}
+
+// CreateCustomField adds a custom registration field to an event (admin only)
+func (h *EventHandler) CreateCustomField(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var req CreateCustomFieldRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !customFieldNamePattern.MatchString(name) {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Field name must start with a letter and contain only lowercase letters, digits and underscores")
+		return
+	}
+
+	// Names double as export column keys, so they cannot shadow the built-in columns
+	for _, column := range attendeeExportColumns {
+		if column.Key == name {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("Field name %s is reserved", name))
+			return
+		}
+	}
+
+	fieldType := req.Type
+	if fieldType == "" {
+		fieldType = "text"
+	}
+	if !customFieldTypes[fieldType] {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Field type must be text, number or boolean")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	var existing int64
+	if err := h.db.Model(&models.CustomField{}).Where("event_id = ? AND name = ?", event.ID, name).Count(&existing).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create custom field")
+		return
+	}
+	if existing > 0 {
+		respondError(w, http.StatusConflict, apierror.CodeAlreadyExists, "A custom field with this name already exists")
+		return
+	}
+
+	field := models.CustomField{
+		EventID:  event.ID,
+		Name:     name,
+		Type:     fieldType,
+		Required: req.Required,
+	}
+	if err := h.db.Create(&field).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create custom field")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(field)
+}
+
+// DeleteCustomField removes a custom registration field and its answers (admin only)
+func (h *EventHandler) DeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+	fieldID, err := strconv.ParseUint(vars["fieldId"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid custom field ID")
+		return
+	}
+
+	var field models.CustomField
+	if err := h.db.Where("id = ? AND event_id = ?", fieldID, eventID).First(&field).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeCustomFieldNotFound, "Custom field not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve custom field")
+		return
+	}
+
+	tx := h.db.Begin()
+	if err := tx.Where("custom_field_id = ?", field.ID).Delete(&models.TicketField{}).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete custom field")
+		return
+	}
+	if err := tx.Delete(&field).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete custom field")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete custom field")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Custom field deleted successfully"})
+}
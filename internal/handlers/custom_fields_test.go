@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestPurchaseEnforcesRequiredCustomFields(t *testing.T) {
+	db := openTestDB(t)
+	events := NewEventHandler(db)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	for _, body := range []string{
+		`{"name": "tshirt_size", "required": true}`,
+		`{"name": "age", "type": "number"}`,
+	} {
+		w := httptest.NewRecorder()
+		events.CreateCustomField(w, authedRequest("POST", "/api/events/"+vars["id"]+"/custom-fields", body, admin, vars))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create custom field %s returned %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	rejected := []string{
+		`{"quantity": 1, "payment_token": "tok_test"}`,
+		`{"quantity": 1, "payment_token": "tok_test", "custom_fields": {"tshirt_size": ""}}`,
+		`{"quantity": 1, "payment_token": "tok_test", "custom_fields": {"tshirt_size": "M", "age": "thirty"}}`,
+		`{"quantity": 1, "payment_token": "tok_test", "custom_fields": {"tshirt_size": "M", "shoe_size": "42"}}`,
+	}
+	for _, body := range rejected {
+		if code := purchaseOne(h, event, buyer, body); code != http.StatusBadRequest {
+			t.Errorf("purchase with %s returned %d, want 400", body, code)
+		}
+	}
+	var count int
+	db.Model(&models.Ticket{}).Where("event_id = ?", event.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("rejected purchases created %d tickets", count)
+	}
+
+	if code := purchaseOne(h, event, buyer, `{"quantity": 2, "payment_token": "tok_test", "custom_fields": {"tshirt_size": " M ", "age": 30}}`); code != http.StatusCreated {
+		t.Fatalf("purchase with the required fields returned %d", code)
+	}
+
+	var answers []models.TicketField
+	db.Joins("JOIN tickets ON tickets.id = ticket_fields.ticket_id").Where("tickets.event_id = ?", event.ID).Find(&answers)
+	values := map[string]int{}
+	for _, answer := range answers {
+		values[answer.Value]++
+	}
+	if len(answers) != 4 || values["M"] != 2 || values["30"] != 2 {
+		t.Fatalf("stored answers %v, want M and 30 for each of the 2 tickets", values)
+	}
+}
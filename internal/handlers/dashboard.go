@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+)
+
+// Event lifecycle buckets used by the organizer dashboard
+const (
+	lifecycleUpcoming  = "upcoming"
+	lifecycleOngoing   = "ongoing"
+	lifecyclePast      = "past"
+	lifecycleCancelled = "cancelled"
+)
+
+// DashboardEvent is the minimal event representation used in dashboard buckets
+type DashboardEvent struct {
+	ID       uint      `json:"id"`
+	Title    string    `json:"title"`
+	Date     time.Time `json:"date"`
+	Location string    `json:"location"`
+}
+
+// EventBucket holds the events in one lifecycle bucket
+type EventBucket struct {
+	Count  int              `json:"count"`
+	Events []DashboardEvent `json:"events"`
+}
+
+// EventDuration returns how long an event is considered ongoing after its start date
+func EventDuration() time.Duration {
+	return time.Duration(config.GetInt("EVENT_DURATION_HOURS", 4)) * time.Hour
+}
+
+// eventLifecycle computes the lifecycle bucket of an event at the given time
+func eventLifecycle(event models.Event, now time.Time, duration time.Duration) string {
+	switch {
+	case event.Status == "cancelled":
+		return lifecycleCancelled
+	case now.Before(event.Date):
+		return lifecycleUpcoming
+	case now.Before(event.Date.Add(duration)):
+		return lifecycleOngoing
+	default:
+		return lifecyclePast
+	}
+}
+
+// groupEventsByLifecycle buckets events by lifecycle, preserving event order. Every
+// bucket is present in the result even when empty.
+func groupEventsByLifecycle(events []models.Event, now time.Time, duration time.Duration) map[string]*EventBucket {
+	buckets := map[string]*EventBucket{}
+	for _, name := range []string{lifecycleUpcoming, lifecycleOngoing, lifecyclePast, lifecycleCancelled} {
+		buckets[name] = &EventBucket{Events: []DashboardEvent{}}
+	}
+
+	for _, event := range events {
+		bucket := buckets[eventLifecycle(event, now, duration)]
+		bucket.Count++
+		bucket.Events = append(bucket.Events, DashboardEvent{
+			ID:       event.ID,
+			Title:    event.Title,
+			Date:     event.Date,
+			Location: event.Location,
+		})
+	}
+
+	return buckets
+}
+
+// GetMyEventsGrouped retrieves the current organizer's events grouped into upcoming,
+// ongoing, past and cancelled. Admins see all events.
+func (h *EventHandler) GetMyEventsGrouped(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	query := h.db.Order("date asc, id asc")
+	if r.Context().Value("user_role") != "admin" {
+		query = query.Where("organizer_id = ?", userID)
+	}
+
+	var events []models.Event
+	if err := query.Find(&events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve events")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(groupEventsByLifecycle(events, time.Now(), EventDuration()))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestGetMyEventsGroupedBucketsEachLifecycle(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("EVENT_DURATION_HOURS", "4")
+	h := NewEventHandler(db)
+	organizer := createTestUser(t, db, "organizer")
+	other := createTestUser(t, db, "organizer")
+	now := time.Now()
+
+	events := map[string]models.Event{}
+	for bucket, update := range map[string]map[string]interface{}{
+		lifecycleUpcoming:  {"date": now.Add(24 * time.Hour)},
+		lifecycleOngoing:   {"date": now.Add(-time.Hour)},
+		lifecyclePast:      {"date": now.Add(-48 * time.Hour)},
+		lifecycleCancelled: {"date": now.Add(24 * time.Hour), "status": "cancelled"},
+	} {
+		event := createTestEvent(t, db, 10, 20)
+		update["organizer_id"] = organizer.ID
+		if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumns(update).Error; err != nil {
+			t.Fatalf("set up %s event: %v", bucket, err)
+		}
+		events[bucket] = event
+	}
+	foreign := createTestEvent(t, db, 10, 20)
+	db.Model(&models.Event{}).Where("id = ?", foreign.ID).UpdateColumn("organizer_id", other.ID)
+
+	w := httptest.NewRecorder()
+	h.GetMyEventsGrouped(w, authedRequest("GET", "/api/me/events/grouped", "", organizer, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("grouped events returned %d: %s", w.Code, w.Body.String())
+	}
+	var buckets map[string]EventBucket
+	if err := json.NewDecoder(w.Body).Decode(&buckets); err != nil {
+		t.Fatalf("decode buckets: %v", err)
+	}
+
+	if len(buckets) != len(events) {
+		t.Fatalf("got %d buckets, want %d", len(buckets), len(events))
+	}
+	for name, event := range events {
+		bucket := buckets[name]
+		if bucket.Count != 1 || len(bucket.Events) != 1 || bucket.Events[0].ID != event.ID {
+			t.Errorf("%s bucket is %+v, want only event %d", name, bucket, event.ID)
+		}
+	}
+
+	// Admins see every organizer's events
+	w = httptest.NewRecorder()
+	h.GetMyEventsGrouped(w, authedRequest("GET", "/api/me/events/grouped", "", createTestUser(t, db, "admin"), nil))
+	if err := json.NewDecoder(w.Body).Decode(&buckets); err != nil {
+		t.Fatalf("decode admin buckets: %v", err)
+	}
+	if got := buckets[lifecycleUpcoming].Count; got != 2 {
+		t.Fatalf("admin sees %d upcoming events, want 2", got)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"event-ticketing-system/internal/config"
+)
+
+// strictJSON reports whether request bodies with unknown fields are rejected
+func strictJSON() bool {
+	return config.GetEnv("STRICT_JSON", "true") == "true"
+}
+
+// maxRequestBodyBytes is the largest JSON request body accepted
+func maxRequestBodyBytes() int64 {
+	return int64(config.GetInt("MAX_REQUEST_BODY_BYTES", 1<<20))
+}
+
+// decodeJSON decodes the request body into v, reading at most MAX_REQUEST_BODY_BYTES. Unless
+// STRICT_JSON=false, fields that v does not declare are rejected with an error naming the
+// field so client typos do not go unnoticed.
+func decodeJSON(r *http.Request, v interface{}) error {
+	limit := maxRequestBodyBytes()
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, limit))
+	if strictJSON() {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fmt.Errorf("request body must not exceed %d bytes", limit)
+		}
+		// The decoder reports unknown fields as `json: unknown field "name"`
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+		}
+		return err
+	}
+	return nil
+}
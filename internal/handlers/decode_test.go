@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+)
+
+func TestDecodeJSONUnknownFields(t *testing.T) {
+	const body = `{"title": "Concert", "capcity": 100}`
+
+	tests := []struct {
+		name    string
+		strict  string
+		wantErr string
+	}{
+		{name: "strict rejects the typo", strict: "true", wantErr: `unknown field "capcity"`},
+		{name: "lenient ignores the typo", strict: "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STRICT_JSON", tt.strict)
+
+			var req CreateEventRequest
+			err := decodeJSON(httptest.NewRequest("POST", "/api/events", strings.NewReader(body)), &req)
+			if tt.wantErr == "" {
+				if err != nil || req.Title != "Concert" || req.Capacity != 0 {
+					t.Fatalf("decoded %+v with error %v, want the known fields only", req, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateEventRejectsUnknownField(t *testing.T) {
+	t.Setenv("STRICT_JSON", "true")
+	h := NewEventHandler(nil)
+
+	w := httptest.NewRecorder()
+	h.CreateEvent(w, authedRequest("POST", "/api/events", `{"title": "Concert", "capcity": 100}`, models.User{ID: 1, Role: "organizer"}, nil))
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeInvalidRequestBody {
+		t.Fatalf("typo'd field returned %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `unknown field \"capcity\"`) {
+		t.Fatalf("error %s does not name the field", w.Body.String())
+	}
+}
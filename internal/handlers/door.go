@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// doorPaymentMethods lists the accepted payment methods at the door
+var doorPaymentMethods = map[string]bool{
+	"cash":  true,
+	"card":  true,
+	"other": true,
+}
+
+// CheckInAndPayRequest represents the check-in and pay request payload
+type CheckInAndPayRequest struct {
+	Amount float64 `json:"amount" binding:"min=0"`
+	Method string  `json:"method" binding:"required,oneof=cash card other"`
+}
+
+// CheckInAndPay records a payment collected at the door and checks the ticket in, both in
+// one transaction so a ticket is never checked in without its payment or vice versa (admin only)
+func (h *TicketHandler) CheckInAndPay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	var req CheckInAndPayRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if req.Amount < 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Amount cannot be negative")
+		return
+	}
+
+	if !doorPaymentMethods[req.Method] {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Method must be cash, card or other")
+		return
+	}
+
+	var ticket models.Ticket
+	if err := h.db.Where("id = ?", ticketID).First(&ticket).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	if message := ticketStatusError(ticket.Status); message != "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, message)
+		return
+	}
+
+	now := time.Now()
+	tx := h.db.Begin()
+
+	// Only transition tickets that are still valid, so a concurrent check-in cannot be paid twice
+	result := tx.Model(&models.Ticket{}).Where("id = ? AND status = ?", ticket.ID, "valid").Updates(map[string]interface{}{
+		"status":              "used",
+		"door_payment_amount": req.Amount,
+		"door_payment_method": req.Method,
+		"door_paid_at":        now,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to record payment")
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		respondError(w, http.StatusConflict, apierror.CodeTicketNoLongerValid, "Ticket is no longer valid")
+		return
+	}
+
+	attendanceLog := models.AttendanceLog{
+		TicketID:    ticket.ID,
+		CheckedInAt: now,
+		CheckedInBy: checkedInBy(r),
+	}
+	if err := tx.Create(&attendanceLog).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create attendance log")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check in ticket")
+		return
+	}
+
+	recordAudit(h.db, r, "ticket.status_changed", "ticket", ticket.ID, "valid -> used")
+	queueWebhookDeliveries(h.db, ticketValidatedWebhookEvent, TicketValidatedData{TicketID: ticket.ID, EventID: ticket.EventID, UserID: ticket.UserID, CheckedInAt: now})
+
+	ticket.Status = "used"
+	ticket.DoorPaymentAmount = &req.Amount
+	ticket.DoorPaymentMethod = req.Method
+	ticket.DoorPaidAt = &now
+
+	response := map[string]interface{}{
+		"message": "Payment recorded and ticket checked in successfully",
+		"ticket":  ticket,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
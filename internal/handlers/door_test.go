@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// checkInAndPay settles the ticket at the door as the admin and returns the recorded response
+func checkInAndPay(h *TicketHandler, ticket models.Ticket, admin models.User, body string) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.CheckInAndPay(w, authedRequest("POST", "/api/tickets/"+vars["id"]+"/checkin-and-pay", body, admin, vars))
+	return w
+}
+
+func TestCheckInAndPayRecordsPaymentAndCheckIn(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), createTestUser(t, db, "user"))
+
+	if w := checkInAndPay(h, ticket, admin, `{"amount": 15.5, "method": "cash"}`); w.Code != http.StatusOK {
+		t.Fatalf("check-in-and-pay returned %d: %s", w.Code, w.Body.String())
+	}
+
+	var stored models.Ticket
+	db.Where("id = ?", ticket.ID).First(&stored)
+	if stored.Status != "used" || stored.DoorPaymentAmount == nil || *stored.DoorPaymentAmount != 15.5 ||
+		stored.DoorPaymentMethod != "cash" || stored.DoorPaidAt == nil {
+		t.Fatalf("ticket after check-in-and-pay is %+v", stored)
+	}
+	var logs int
+	db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", ticket.ID).Count(&logs)
+	if logs != 1 {
+		t.Fatalf("ticket has %d attendance logs, want 1", logs)
+	}
+
+	// A settled ticket cannot be paid for again
+	if w := checkInAndPay(h, ticket, admin, `{"amount": 15.5, "method": "cash"}`); w.Code == http.StatusOK {
+		t.Fatal("second check-in-and-pay succeeded")
+	}
+}
+
+func TestCheckInAndPayRollsBackPaymentWhenCheckInFails(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), createTestUser(t, db, "user"))
+
+	// Make the attendance log insert fail after the payment has been written
+	if err := db.Exec("ALTER TABLE attendance_logs ADD CONSTRAINT reject_test_ticket CHECK (ticket_id <> " + strconv.Itoa(int(ticket.ID)) + ")").Error; err != nil {
+		t.Fatalf("add failing constraint: %v", err)
+	}
+
+	if w := checkInAndPay(h, ticket, admin, `{"amount": 15.5, "method": "card"}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("check-in-and-pay with a failing check-in returned %d: %s", w.Code, w.Body.String())
+	}
+
+	var stored models.Ticket
+	db.Where("id = ?", ticket.ID).First(&stored)
+	if stored.Status != "valid" || stored.DoorPaymentAmount != nil || stored.DoorPaymentMethod != "" || stored.DoorPaidAt != nil {
+		t.Fatalf("payment was kept without a check-in: %+v", stored)
+	}
+	var logs int
+	db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", ticket.ID).Count(&logs)
+	if logs != 0 {
+		t.Fatalf("ticket has %d attendance logs, want none", logs)
+	}
+}
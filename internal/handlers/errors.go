@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+// respondError writes an error response in the shared {"error": {"code", "message"}} envelope.
+// Codes are the apierror constants, so clients can branch on them.
+func respondError(w http.ResponseWriter, status int, code, message string) {
+	apierror.Respond(w, status, code, message)
+}
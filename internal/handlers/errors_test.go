@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRespondErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		code    string
+		message string
+		want    string
+	}{
+		{name: "not found", status: http.StatusNotFound, code: apierror.CodeEventNotFound, message: "Event not found",
+			want: `{"error":{"code":"EVENT_NOT_FOUND","message":"Event not found"}}`},
+		{name: "expired token", status: http.StatusUnauthorized, code: apierror.CodeTokenExpired, message: "Token has expired",
+			want: `{"error":{"code":"token_expired","message":"Token has expired"}}`},
+		{name: "invalid token", status: http.StatusUnauthorized, code: apierror.CodeTokenInvalid, message: "Invalid token",
+			want: `{"error":{"code":"token_invalid","message":"Invalid token"}}`},
+		{name: "invalid refresh token", status: http.StatusUnauthorized, code: apierror.CodeRefreshTokenInvalid, message: "Invalid or expired refresh token",
+			want: `{"error":{"code":"REFRESH_TOKEN_INVALID","message":"Invalid or expired refresh token"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			respondError(w, tt.status, tt.code, tt.message)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandlerErrorEnvelope checks the envelope handlers answer with before reaching the database
+func TestHandlerErrorEnvelope(t *testing.T) {
+	h := NewTicketHandler(nil, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	user := models.User{ID: 1, Role: "user"}
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		status  int
+		want    string
+	}{
+		{name: "invalid ID", request: authedRequest("POST", "/api/orders/abc/pay", `{}`, user, map[string]string{"id": "abc"}),
+			status: http.StatusBadRequest, want: `{"error":{"code":"INVALID_ID","message":"Invalid order ID"}}`},
+		{name: "unauthenticated", request: mux.SetURLVars(httptest.NewRequest("POST", "/api/orders/1/pay", strings.NewReader(`{}`)), map[string]string{"id": "1"}),
+			status: http.StatusUnauthorized, want: `{"error":{"code":"UNAUTHENTICATED","message":"User not authenticated"}}`},
+		{name: "validation failed", request: authedRequest("POST", "/api/orders/1/pay", `{}`, user, map[string]string{"id": "1"}),
+			status: http.StatusUnprocessableEntity, want: `{"error":{"code":"VALIDATION_FAILED","message":"Request validation failed","fields":[{"field":"payment_token","message":"is required"}]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.PayOrder(w, tt.request)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
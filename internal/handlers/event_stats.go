@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// EventStats summarises capacity, sales and check-ins of one event
+type EventStats struct {
+	EventID          uint    `json:"event_id"`
+	Capacity         *int    `json:"capacity"` // nil for unlimited events
+	TicketsSold      int64   `json:"tickets_sold"`
+	TicketsAvailable *int64  `json:"tickets_available"` // nil for unlimited events
+	TicketsUsed      int64   `json:"tickets_used"`
+	Revenue          float64 `json:"revenue"`
+	CheckInRate      float64 `json:"check_in_rate"` // percentage of sold tickets checked in
+}
+
+// buildEventStats derives the availability and check-in rate of an event from its sales
+func buildEventStats(event models.Event, sales EventSales) EventStats {
+	stats := EventStats{
+		EventID:     event.ID,
+		TicketsSold: sales.Sold,
+		TicketsUsed: sales.CheckedIn,
+		Revenue:     roundCents(sales.Revenue),
+	}
+
+	if !event.Unlimited {
+		capacity := event.Capacity
+		available := int64(event.Capacity) - sales.Sold
+		if available < 0 {
+			available = 0
+		}
+		stats.Capacity = &capacity
+		stats.TicketsAvailable = &available
+	}
+
+	if sales.Sold > 0 {
+		stats.CheckInRate = roundCents(float64(sales.CheckedIn) / float64(sales.Sold) * 100)
+	}
+	return stats
+}
+
+// GetEventStats retrieves the capacity, sales, revenue and check-in rate of an event (admin only)
+func (h *EventHandler) GetEventStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	// Counted in the database, so large events are not loaded ticket by ticket
+	sales, err := eventSales(h.db, []uint{event.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute event stats")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(buildEventStats(event, sales[event.ID]))
+}
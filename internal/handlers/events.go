@@ -2,17 +2,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
 	"event-ticketing-system/internal/models"
 
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 )
 
-
 // EventHandler handles event related requests
 type EventHandler struct {
 	db *gorm.DB
@@ -25,37 +30,193 @@ func NewEventHandler(db *gorm.DB) *EventHandler {
 
 // CreateEventRequest represents the create event request payload
 type CreateEventRequest struct {
-	Title       string    `json:"title" binding:"required"`
-	Description string    `json:"description" binding:"required"`
-	Date        time.Time `json:"date" binding:"required"`
-	Location    string    `json:"location" binding:"required"`
-	Capacity    int       `json:"capacity" binding:"required,min=1"`
-	Price       float64   `json:"price" binding:"required,min=0"`
+	Title        string    `json:"title" binding:"required"`
+	Description  string    `json:"description" binding:"required"`
+	Date         time.Time `json:"date" binding:"required"`
+	Location     string    `json:"location" binding:"required"`
+	Category     string    `json:"category"` // checked against EVENT_CATEGORIES when configured
+	Capacity     int       `json:"capacity" binding:"omitempty,min=1"`
+	Unlimited    bool      `json:"unlimited"`
+	Price        float64   `json:"price" binding:"min=0"`
+	MaxTransfers int       `json:"max_transfers" binding:"min=0"`
+	MaxPerUser   int       `json:"max_per_user" binding:"min=0"`
+
+	// PurchaseRateLimit opts the event into purchase throttling, in purchases per second
+	PurchaseRateLimit int `json:"purchase_rate_limit" binding:"min=0"`
+
+	// AllowReentry lets tickets be scanned on every entry instead of only once
+	AllowReentry bool `json:"allow_reentry"`
+
+	// ReservationTTLMinutes overrides PENDING_ORDER_TTL_MINUTES for the event's unpaid orders
+	ReservationTTLMinutes int `json:"reservation_ttl_minutes" binding:"min=0"`
+
+	// TicketTypes replace the single price and capacity; when given, the event capacity is their
+	// combined capacity and its price the lowest type price
+	TicketTypes []TicketTypeRequest `json:"ticket_types"`
 }
 
 // UpdateEventRequest represents the update event request payload
 type UpdateEventRequest struct {
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
-	Location    string    `json:"location"`
-	Capacity    int       `json:"capacity"`
-	Price       float64   `json:"price"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Date         time.Time `json:"date"`
+	Location     string    `json:"location"`
+	Category     *string   `json:"category"` // an empty string clears the category
+	Capacity     int       `json:"capacity"`
+	Unlimited    *bool     `json:"unlimited"`
+	Price        *float64  `json:"price"`
+	MaxTransfers *int      `json:"max_transfers"`
+	MaxPerUser   *int      `json:"max_per_user"` // 0 removes the limit
+	Status       string    `json:"status" binding:"omitempty,oneof=active cancelled"`
+
+	PurchaseRateLimit *int  `json:"purchase_rate_limit"` // 0 turns purchase throttling off
+	AllowReentry      *bool `json:"allow_reentry"`
+
+	ReservationTTLMinutes *int `json:"reservation_ttl_minutes"` // 0 falls back to PENDING_ORDER_TTL_MINUTES
+
+	// TicketTypes, when given, replace the event's ticket types, see planTicketTypes
+	TicketTypes []TicketTypeRequest `json:"ticket_types"`
+
+	// Version of the event the client last read; the update is rejected when it has changed since
+	Version *int `json:"version"`
+}
+
+// maxBulkEventIDs caps the number of events that can be fetched at once with ?ids=
+const maxBulkEventIDs = 100
+
+// parseIDList parses a comma separated list of IDs, ignoring duplicates
+func parseIDList(value string, max int) ([]uint, error) {
+	ids := []uint{}
+	seen := map[uint]bool{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID: %s", part)
+		}
+		if !seen[uint(id)] {
+			seen[uint(id)] = true
+			ids = append(ids, uint(id))
+		}
+	}
+
+	if len(ids) > max {
+		return nil, fmt.Errorf("at most %d IDs may be requested at once", max)
+	}
+	return ids, nil
+}
+
+// orderEventsByIDs returns the events in the order their IDs were requested
+func orderEventsByIDs(events []models.Event, ids []uint) []models.Event {
+	byID := make(map[uint]models.Event, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+
+	ordered := make([]models.Event, 0, len(events))
+	for _, id := range ids {
+		if event, ok := byID[id]; ok {
+			ordered = append(ordered, event)
+		}
+	}
+	return ordered
 }
 
-// GetEvents retrieves all events
+// filterEvents applies the ?search=, ?location=, ?from= and ?to= event list filters to the
+// query. Empty parameters are ignored, so the filters combine freely.
+func filterEvents(query *gorm.DB, values url.Values) (*gorm.DB, error) {
+	if search := strings.TrimSpace(values.Get("search")); search != "" {
+		contains := "%" + escapeLike(search) + "%"
+		query = query.Where("title ILIKE ? OR description ILIKE ?", contains, contains)
+	}
+
+	if location := strings.TrimSpace(values.Get("location")); location != "" {
+		query = query.Where("location ILIKE ?", "%"+escapeLike(location)+"%")
+	}
+
+	if category := strings.ToLower(strings.TrimSpace(values.Get("category"))); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	if value := values.Get("from"); value != "" {
+		from, err := parseDateParam(value)
+		if err != nil {
+			return nil, errors.New("invalid from date")
+		}
+		query = query.Where("date >= ?", from)
+	}
+
+	if value := values.Get("to"); value != "" {
+		to, err := parseDateParam(value)
+		if err != nil {
+			return nil, errors.New("invalid to date")
+		}
+		query = query.Where("date < ?", to)
+	}
+
+	return query, nil
+}
+
+// GetEvents retrieves all events, or only the events listed in ?ids=, narrowed by the
+// search, location, category and date filters
 func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	expand, ok := parseEventExpand(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := parseSort(r, eventSortKeys, "", "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	// Deleted events are archived rather than removed, admins may list them too
+	base := h.db
+	if r.URL.Query().Get("include_deleted") == "true" {
+		if r.Context().Value("user_role") != "admin" {
+			respondError(w, http.StatusForbidden, apierror.CodeForbidden, "Only admins can include deleted events")
+			return
+		}
+		base = base.Unscoped()
+	}
+
+	query, err := filterEvents(preloadExpansions(base, expand, eventExpansions).Order(order), r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	// Optionally restrict to specific events, silently skipping IDs that do not exist
+	var ids []uint
+	if value := r.URL.Query().Get("ids"); value != "" {
+		parsed, err := parseIDList(value, maxBulkEventIDs)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+			return
+		}
+		ids = parsed
+		query = query.Where("id IN (?)", ids)
+	}
+
 	var events []models.Event
-	if err := h.db.Preload("Tickets").Find(&events).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve events"})
+	if err := query.Find(&events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve events")
 		return
 	}
 
+	// Without an explicit sort, events fetched by ID come back in the requested order
+	if ids != nil && r.URL.Query().Get("sort") == "" {
+		events = orderEventsByIDs(events, ids)
+	}
+
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(events)
+	json.NewEncoder(w).Encode(newEventResponses(events, expand))
 }
 
 // GetEvent retrieves a specific event by ID
@@ -67,58 +228,228 @@ func (h *EventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 	eventID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid event ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	expand, ok := parseEventExpand(w, r)
+	if !ok {
 		return
 	}
 
 	var event models.Event
-	if err := h.db.Preload("Tickets").Where("id = ?", eventID).First(&event).Error; err != nil {
+	if err := preloadExpansions(h.db, expand, eventExpansions).Where("id = ?", eventID).First(&event).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Event not found"})
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve event"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(event)
+	json.NewEncoder(w).Encode(newEventResponse(event, expand))
+}
+
+// EventLocation is a distinct event location with the number of upcoming events held there
+type EventLocation struct {
+	Location string `json:"location"`
+	Count    int64  `json:"count"`
+}
+
+// GetEventLocations retrieves the distinct, trimmed locations of upcoming events in alphabetical order
+func (h *EventHandler) GetEventLocations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	locations := []EventLocation{}
+	if err := h.db.Model(&models.Event{}).
+		Select("TRIM(location) AS location, COUNT(*) AS count").
+		Where("date > ? AND status <> ? AND TRIM(location) <> ''", time.Now(), "cancelled").
+		Group("TRIM(location)").Order("location asc").
+		Scan(&locations).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve locations")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(locations)
 }
 
-// CreateEvent creates a new event (admin only)
+// parseEventExpand parses ?expand= for event endpoints, writing the error response on failure.
+// Expanded tickets carry other attendees' QR codes, so only admins may expand them.
+func parseEventExpand(w http.ResponseWriter, r *http.Request) (map[string]bool, bool) {
+	expand, err := parseExpand(r, eventExpansions, "")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return nil, false
+	}
+
+	if expand["tickets"] && r.Context().Value("user_role") != "admin" {
+		respondError(w, http.StatusForbidden, apierror.CodeForbidden, "Only admins may expand event tickets")
+		return nil, false
+	}
+
+	return expand, true
+}
+
+// maxActiveEventsPerOrganizer returns the cap on an organizer's active events, 0 means unlimited
+func maxActiveEventsPerOrganizer() int {
+	return config.GetInt("MAX_ACTIVE_EVENTS_PER_ORGANIZER", 0)
+}
+
+// eventDateInPast reports whether an event date is earlier than now, allowing
+// EVENT_DATE_GRACE_SECONDS of clock skew between the client and the server
+func eventDateInPast(date time.Time) bool {
+	grace := time.Duration(config.GetInt("EVENT_DATE_GRACE_SECONDS", 300)) * time.Second
+	return date.Before(time.Now().Add(-grace))
+}
+
+// CreateEvent creates a new event (organizer or admin)
 func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
 	var req CreateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if eventDateInPast(req.Date) {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Event date must be in the future")
 		return
 	}
 
+	if err := validateTicketTypes(req.TicketTypes); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+
+	category, err := normalizeCategory(req.Category)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+
+	ticketTypes := make([]models.TicketType, 0, len(req.TicketTypes))
+	for _, ticketType := range req.TicketTypes {
+		ticketTypes = append(ticketTypes, models.TicketType{Name: strings.TrimSpace(ticketType.Name), Price: ticketType.Price, Capacity: ticketType.Capacity})
+	}
+	if len(ticketTypes) > 0 {
+		req.Capacity, req.Price = ticketTypeTotals(ticketTypes)
+	}
+
+	if !req.Unlimited && req.Capacity < 1 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Capacity must be at least 1 unless the event is unlimited")
+		return
+	}
+
+	if req.PurchaseRateLimit < 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Purchase rate limit cannot be negative")
+		return
+	}
+
+	if req.MaxPerUser < 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Tickets per user cannot be negative")
+		return
+	}
+
+	if req.ReservationTTLMinutes < 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Reservation TTL cannot be negative")
+		return
+	}
+
+	// Events created without ticket types get a single type from the legacy price and capacity.
+	// A single type cannot express unlimited capacity, so unlimited events are left without one.
+	if len(ticketTypes) == 0 && !req.Unlimited {
+		ticketTypes = append(ticketTypes, models.TicketType{Name: generalTicketTypeName, Price: req.Price, Capacity: req.Capacity})
+	}
+
+	// Organizers may only have a limited number of active events, admins are exempt
+	if limit := maxActiveEventsPerOrganizer(); limit > 0 && r.Context().Value("user_role") != "admin" {
+		var activeEvents int64
+		if err := h.db.Model(&models.Event{}).
+			Where("organizer_id = ? AND date > ? AND status <> ?", userID, time.Now(), "cancelled").
+			Count(&activeEvents).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count active events")
+			return
+		}
+		if activeEvents >= int64(limit) {
+			respondError(w, http.StatusForbidden, apierror.CodeActiveEventLimit,
+				fmt.Sprintf("Active event limit reached: organizers may have at most %d active events", limit))
+			return
+		}
+	}
+
 	event := models.Event{
-		Title:       req.Title,
-		Description: req.Description,
-		Date:        req.Date,
-		Location:    req.Location,
-		Capacity:    req.Capacity,
-		Price:       req.Price,
+		Title:        req.Title,
+		Description:  req.Description,
+		Date:         req.Date,
+		Location:     req.Location,
+		Category:     category,
+		Capacity:     req.Capacity,
+		Unlimited:    req.Unlimited,
+		Price:        req.Price,
+		MaxTransfers: req.MaxTransfers,
+		MaxPerUser:   req.MaxPerUser,
+		OrganizerID:  userID.(uint),
+
+		PurchaseRateLimit:     req.PurchaseRateLimit,
+		AllowReentry:          req.AllowReentry,
+		ReservationTTLMinutes: req.ReservationTTLMinutes,
 	}
 
-	if err := h.db.Create(&event).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create event"})
+	tx := h.db.Begin()
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create event")
+		return
+	}
+	for i := range ticketTypes {
+		ticketTypes[i].EventID = event.ID
+		if err := tx.Create(&ticketTypes[i]).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create ticket types")
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create event")
 		return
 	}
+	event.TicketTypes = ticketTypes
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(event)
 }
 
-// UpdateEvent updates an existing event (admin only)
+// errEventVersionConflict is returned when an event was updated after the copy being saved was read
+var errEventVersionConflict = errors.New("event was changed by another update")
+
+// claimEventVersion increments the version of an event if it is still the given one, reporting
+// whether it was. Call it inside the update transaction: the row stays locked until commit, so
+// updates of the same event apply one after the other and a stale one is detected.
+func claimEventVersion(tx *gorm.DB, eventID uint, version int) (bool, error) {
+	result := tx.Model(&models.Event{}).Where("id = ? AND version = ?", eventID, version).
+		UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateEvent updates an existing event (admin only). Clients may send the version they read;
+// the update is rejected with 409 when the event has changed since, and the client should reload
+// it and retry.
 func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -127,27 +458,32 @@ func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 	eventID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid event ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
 		return
 	}
 
 	var event models.Event
 	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Event not found"})
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve event"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
 		return
 	}
 
 	var req UpdateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if req.Version != nil && *req.Version != event.Version {
+		respondError(w, http.StatusConflict, apierror.CodeConflict, "Event was changed by another update, reload it and retry")
 		return
 	}
 
@@ -159,29 +495,237 @@ func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		event.Description = req.Description
 	}
 	if !req.Date.IsZero() {
+		if eventDateInPast(req.Date) {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Event date must be in the future")
+			return
+		}
 		event.Date = req.Date
 	}
 	if req.Location != "" {
 		event.Location = req.Location
 	}
+	if req.Category != nil {
+		category, err := normalizeCategory(*req.Category)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+			return
+		}
+		event.Category = category
+	}
 	if req.Capacity > 0 {
+		if req.Capacity < event.SoldCount {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Capacity cannot be lower than the number of tickets sold")
+			return
+		}
 		event.Capacity = req.Capacity
 	}
-	if req.Price >= 0 {
-		event.Price = req.Price
+	wasUnlimited := event.Unlimited
+	if req.Unlimited != nil {
+		// Removing the flag needs a capacity that covers the tickets already sold
+		if !*req.Unlimited && (event.Capacity < 1 || event.Capacity < event.SoldCount) {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "A capacity covering the tickets sold is required for limited events")
+			return
+		}
+		event.Unlimited = *req.Unlimited
+	}
+	if req.Price != nil {
+		if *req.Price < 0 {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Price cannot be negative")
+			return
+		}
+		event.Price = *req.Price
+	}
+	if req.MaxTransfers != nil && *req.MaxTransfers >= 0 {
+		event.MaxTransfers = *req.MaxTransfers
 	}
+	if req.MaxPerUser != nil {
+		if *req.MaxPerUser < 0 {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Tickets per user cannot be negative")
+			return
+		}
+		event.MaxPerUser = *req.MaxPerUser
+	}
+	if req.PurchaseRateLimit != nil {
+		if *req.PurchaseRateLimit < 0 {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Purchase rate limit cannot be negative")
+			return
+		}
+		event.PurchaseRateLimit = *req.PurchaseRateLimit
+	}
+	if req.AllowReentry != nil {
+		event.AllowReentry = *req.AllowReentry
+	}
+	if req.ReservationTTLMinutes != nil {
+		if *req.ReservationTTLMinutes < 0 {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Reservation TTL cannot be negative")
+			return
+		}
+		event.ReservationTTLMinutes = *req.ReservationTTLMinutes
+	}
+	if req.Status != "" {
+		if req.Status != "active" && req.Status != "cancelled" {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid event status")
+			return
+		}
+		// Cancelling an event is destructive and may require a step-up token
+		if req.Status == "cancelled" && event.Status != "cancelled" && !stepUpSatisfied(r) {
+			respondError(w, http.StatusForbidden, apierror.CodeStepUpRequired, "Re-authentication required for this action")
+			return
+		}
+		event.Status = req.Status
+	}
+
+	if err := validateTicketTypes(req.TicketTypes); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Bump the version first: an update that committed since the event was loaded would be
+	// overwritten by saving this copy
+	claimed, err := claimEventVersion(tx, event.ID, event.Version)
+	if err == nil && !claimed {
+		err = errEventVersionConflict
+	}
+	if err != nil {
+		tx.Rollback()
+		if err == errEventVersionConflict {
+			respondError(w, http.StatusConflict, apierror.CodeConflict, "Event was changed by another update, reload it and retry")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update event")
+		return
+	}
+	event.Version++
+
+	// Lock the event before its types, in the order purchases take them. The sold count read
+	// under the lock cannot change until the update commits, so the capacity is checked against
+	// it rather than the copy loaded above.
+	var locked models.Event
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Select("id, sold_count").Where("id = ?", event.ID).First(&locked).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+	if (req.Capacity > 0 || req.Unlimited != nil) && !event.Unlimited && event.Capacity < locked.SoldCount {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Capacity cannot be lower than the number of tickets sold")
+		return
+	}
+
+	var ticketTypes []models.TicketType
+	priceOrCapacity := req.Capacity > 0 || req.Price != nil
+	if req.TicketTypes == nil && (priceOrCapacity || event.Unlimited != wasUnlimited) {
+		// Without ticket_types the event's own price and capacity change, and its single type must
+		// follow in the same transaction
+		var existing []models.TicketType
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("event_id = ?", event.ID).Order("id asc").Find(&existing).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket types")
+			return
+		}
+		sold, err := ticketTypeSoldCounts(tx, event.ID)
+		if err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count ticket type sales")
+			return
+		}
+
+		var removed []uint
+		ticketTypes, removed, err = planEventTicketType(existing, sold, event, priceOrCapacity)
+		if err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+			return
+		}
+
+		if len(removed) > 0 {
+			if err := tx.Where("id IN (?)", removed).Delete(&models.TicketType{}).Error; err != nil {
+				tx.Rollback()
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update ticket types")
+				return
+			}
+		}
+		for i := range ticketTypes {
+			if err := tx.Save(&ticketTypes[i]).Error; err != nil {
+				tx.Rollback()
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update ticket types")
+				return
+			}
+		}
+	} else if req.TicketTypes != nil {
+		// Lock the types too, so a concurrent purchase cannot sell into a type being shrunk or
+		// removed
+		var existing []models.TicketType
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("event_id = ?", event.ID).Order("id asc").Find(&existing).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket types")
+			return
+		}
+		sold, err := ticketTypeSoldCounts(tx, event.ID)
+		if err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count ticket type sales")
+			return
+		}
+
+		var removed []uint
+		ticketTypes, removed, err = planTicketTypes(existing, sold, req.TicketTypes)
+		if err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+			return
+		}
+
+		if len(ticketTypes) > 0 {
+			capacity, price := ticketTypeTotals(ticketTypes)
+			if !event.Unlimited && capacity < locked.SoldCount {
+				tx.Rollback()
+				respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Capacity cannot be lower than the number of tickets sold")
+				return
+			}
+			event.Capacity = capacity
+			event.Price = price
+		}
 
-	if err := h.db.Save(&event).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update event"})
+		if len(removed) > 0 {
+			if err := tx.Where("id IN (?)", removed).Delete(&models.TicketType{}).Error; err != nil {
+				tx.Rollback()
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update ticket types")
+				return
+			}
+		}
+		for i := range ticketTypes {
+			ticketTypes[i].EventID = event.ID
+			if err := tx.Save(&ticketTypes[i]).Error; err != nil {
+				tx.Rollback()
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update ticket types")
+				return
+			}
+		}
+	}
+
+	// The sold count is maintained by purchases, never overwrite it with the loaded value
+	if err := tx.Omit("sold_count").Save(&event).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update event")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update event")
 		return
 	}
+	if req.TicketTypes != nil || ticketTypes != nil {
+		event.TicketTypes = ticketTypes
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(event)
 }
 
-// DeleteEvent deletes an event (admin only)
+// DeleteEvent soft deletes an event, archiving it with its tickets (admin only)
 func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -190,8 +734,7 @@ func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 	eventID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid event ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
 		return
 	}
 
@@ -199,30 +742,20 @@ func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	var event models.Event
 	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Event not found"})
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve event"})
-		return
-	}
-
-	// Check if there are any tickets for this event
-	var ticketCount int64
-	h.db.Model(&models.Ticket{}).Where("event_id = ?", eventID).Count(&ticketCount)
-	if ticketCount > 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot delete event with existing tickets"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
 		return
 	}
 
+	// Soft delete: the event is hidden from listings and purchases, while its tickets, ticket
+	// types and attendance history are kept
 	if err := h.db.Delete(&event).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to delete event"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete event")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Event deleted successfully"})
-}
\ No newline at end of file
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// createEvent creates an event a week away as the user and returns the recorded response
+func createEvent(h *EventHandler, user models.User) *httptest.ResponseRecorder {
+	date := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	body := `{"title": "Concert", "description": "Concert", "date": "` + date + `", "location": "Hall", "capacity": 10, "price": 20}`
+	w := httptest.NewRecorder()
+	h.CreateEvent(w, authedRequest("POST", "/api/events", body, user, nil))
+	return w
+}
+
+func TestUpdateEventRejectsStaleVersion(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	target := "/api/events/" + vars["id"]
+
+	first := httptest.NewRecorder()
+	h.UpdateEvent(first, authedRequest("PUT", target, `{"title": "First", "version": 1}`, admin, vars))
+	if first.Code != http.StatusOK {
+		t.Fatalf("update from the current version returned %d: %s", first.Code, first.Body)
+	}
+
+	stale := httptest.NewRecorder()
+	h.UpdateEvent(stale, authedRequest("PUT", target, `{"title": "Stale", "version": 1}`, admin, vars))
+	if stale.Code != http.StatusConflict {
+		t.Fatalf("update from a stale version returned %d, want %d", stale.Code, http.StatusConflict)
+	}
+
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	if stored.Title != "First" || stored.Version != 2 {
+		t.Fatalf("event is %q at version %d, want the first update at version 2", stored.Title, stored.Version)
+	}
+}
+
+// TestUpdateEventConcurrentVersions sends updates from the same version at once. The first to
+// commit wins and the others must be told to reload.
+func TestUpdateEventConcurrentVersions(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	target := "/api/events/" + vars["id"]
+
+	const updates = 8
+	codes := make([]int, updates)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			w := httptest.NewRecorder()
+			body := `{"title": "Update ` + strconv.Itoa(i) + `", "version": 1}`
+			h.UpdateEvent(w, authedRequest("PUT", target, body, admin, vars))
+			codes[i] = w.Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusConflict:
+		default:
+			t.Errorf("update %d returned %d", i, code)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d updates succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestUpdateEventCapacityBelowSold(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	admin := createTestUser(t, db, "admin")
+	holder := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	for i := 0; i < 3; i++ {
+		createTestTicket(t, db, event, holder)
+	}
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	target := "/api/events/" + vars["id"]
+
+	w := httptest.NewRecorder()
+	h.UpdateEvent(w, authedRequest("PUT", target, `{"capacity": 2}`, admin, vars))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("capacity below the tickets sold returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = httptest.NewRecorder()
+	h.UpdateEvent(w, authedRequest("PUT", target, `{"capacity": 3}`, admin, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("capacity equal to the tickets sold returned %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestCreateEventActiveEventLimit(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	t.Setenv("MAX_ACTIVE_EVENTS_PER_ORGANIZER", "2")
+	organizer := createTestUser(t, db, "organizer")
+
+	// Past and cancelled events are not active and do not count towards the limit
+	past := createTestEvent(t, db, 10, 20)
+	cancelled := createTestEvent(t, db, 10, 20)
+	db.Model(&models.Event{}).Where("id = ?", past.ID).UpdateColumns(map[string]interface{}{"organizer_id": organizer.ID, "date": time.Now().Add(-time.Hour)})
+	db.Model(&models.Event{}).Where("id = ?", cancelled.ID).UpdateColumns(map[string]interface{}{"organizer_id": organizer.ID, "status": "cancelled"})
+
+	for i := 1; i <= 2; i++ {
+		if w := createEvent(h, organizer); w.Code != http.StatusCreated {
+			t.Fatalf("active event %d returned %d: %s", i, w.Code, w.Body)
+		}
+	}
+
+	w := createEvent(h, organizer)
+	if w.Code != http.StatusForbidden || responseErrorCode(t, w) != apierror.CodeActiveEventLimit {
+		t.Fatalf("event over the limit returned %d: %s", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "at most 2 active events") {
+		t.Errorf("limit error %s does not name the cap", w.Body)
+	}
+
+	// Other organizers and admins are not held to this organizer's events
+	if w := createEvent(h, createTestUser(t, db, "organizer")); w.Code != http.StatusCreated {
+		t.Fatalf("another organizer's event returned %d: %s", w.Code, w.Body)
+	}
+	admin := createTestUser(t, db, "admin")
+	for i := 1; i <= 3; i++ {
+		if w := createEvent(h, admin); w.Code != http.StatusCreated {
+			t.Fatalf("admin event %d returned %d: %s", i, w.Code, w.Body)
+		}
+	}
+}
+
+func TestGetEventsByIDsSkipsMissing(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	user := createTestUser(t, db, "user")
+	first := createTestEvent(t, db, 10, 20)
+	second := createTestEvent(t, db, 10, 20)
+	createTestEvent(t, db, 10, 20)
+	missing := second.ID + 1000
+
+	target := fmt.Sprintf("/api/events?ids=%d,%d,%d,%d", second.ID, missing, first.ID, second.ID)
+	w := httptest.NewRecorder()
+	h.GetEvents(w, authedRequest("GET", target, "", user, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bulk fetch returned %d: %s", w.Code, w.Body)
+	}
+	var events []EventResponse
+	if err := json.NewDecoder(w.Body).Decode(&events); err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	// Existing events come back once each, in the requested order
+	if len(events) != 2 || events[0].ID != second.ID || events[1].ID != first.ID {
+		t.Fatalf("bulk fetch returned %+v, want events %d and %d", events, second.ID, first.ID)
+	}
+
+	ids := make([]string, maxBulkEventIDs+1)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i + 1)
+	}
+	w = httptest.NewRecorder()
+	h.GetEvents(w, authedRequest("GET", "/api/events?ids="+strings.Join(ids, ","), "", user, nil))
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeInvalidParameter {
+		t.Fatalf("fetching %d IDs returned %d: %s", len(ids), w.Code, w.Body)
+	}
+}
+
+func TestGetEventLocationsIsDistinctAndTrimmed(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	for location, update := range map[string]map[string]interface{}{
+		"Hall":        {},
+		"  Hall ":     {},
+		"Arena":       {},
+		"   ":         {},
+		"":            {},
+		"Old Theatre": {"date": time.Now().Add(-time.Hour)},
+		"Stadium":     {"status": "cancelled"},
+	} {
+		event := createTestEvent(t, db, 10, 20)
+		update["location"] = location
+		if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumns(update).Error; err != nil {
+			t.Fatalf("set up event at %q: %v", location, err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.GetEventLocations(w, httptest.NewRequest("GET", "/api/events/locations", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("locations returned %d: %s", w.Code, w.Body)
+	}
+	var locations []EventLocation
+	if err := json.NewDecoder(w.Body).Decode(&locations); err != nil {
+		t.Fatalf("decode locations: %v", err)
+	}
+
+	want := []EventLocation{{Location: "Arena", Count: 1}, {Location: "Hall", Count: 2}}
+	if len(locations) != len(want) {
+		t.Fatalf("locations are %+v, want %+v", locations, want)
+	}
+	for i := range want {
+		if locations[i] != want[i] {
+			t.Errorf("location %d is %+v, want %+v", i, locations[i], want[i])
+		}
+	}
+}
+
+// eventSoldOut fetches the event and returns its is_sold_out flag
+func eventSoldOut(t *testing.T, h *EventHandler, event models.Event, user models.User) bool {
+	t.Helper()
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.GetEvent(w, authedRequest("GET", "/api/events/"+vars["id"], "", user, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetEvent returned %d: %s", w.Code, w.Body)
+	}
+	var response struct {
+		IsSoldOut bool `json:"is_sold_out"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	return response.IsSoldOut
+}
+
+func TestUnlimitedEventNeverSellsOut(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	tickets := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	organizer := createTestUser(t, db, "organizer")
+	buyer := createTestUser(t, db, "user")
+
+	// Capacity may be omitted for unlimited events only
+	date := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	body := `{"title": "Picnic", "description": "Picnic", "date": "` + date + `", "location": "Park", "price": 0, "unlimited": true}`
+	w := httptest.NewRecorder()
+	h.CreateEvent(w, authedRequest("POST", "/api/events", body, organizer, nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unlimited event returned %d: %s", w.Code, w.Body)
+	}
+	w = httptest.NewRecorder()
+	h.CreateEvent(w, authedRequest("POST", "/api/events", strings.Replace(body, `"unlimited": true`, `"unlimited": false`, 1), organizer, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("limited event without a capacity returned %d: %s", w.Code, w.Body)
+	}
+
+	var unlimited models.Event
+	db.Where("organizer_id = ?", organizer.ID).First(&unlimited)
+	if !unlimited.Unlimited || eventSoldOut(t, h, unlimited, buyer) {
+		t.Fatalf("new unlimited event is %+v", unlimited)
+	}
+	for i := 0; i < 3; i++ {
+		if code := purchaseOne(tickets, unlimited, buyer, `{"quantity": 5}`); code != http.StatusCreated {
+			t.Fatalf("purchase %d of an unlimited event returned %d", i+1, code)
+		}
+	}
+	if eventSoldOut(t, h, unlimited, buyer) {
+		t.Fatal("unlimited event with 15 tickets sold reports sold out")
+	}
+
+	limited := createTestEvent(t, db, 1, 0)
+	if code := purchaseOne(tickets, limited, buyer, `{"quantity": 1}`); code != http.StatusCreated {
+		t.Fatalf("purchase of a limited event returned %d", code)
+	}
+	if !eventSoldOut(t, h, limited, buyer) {
+		t.Fatal("limited event at capacity does not report sold out")
+	}
+}
+
+func TestGetEventsFiltersAndSorts(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	user := createTestUser(t, db, "user")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// day formats a date the given number of days from now for a query parameter
+	day := func(days int) string {
+		return now.AddDate(0, 0, days).Format(time.RFC3339)
+	}
+
+	events := map[string]models.Event{}
+	for _, e := range []struct {
+		key, title, description, location string
+		days                              int
+		price                             float64
+	}{
+		{"jazz", "Jazz Night", "Live music", "Blue Hall", 2, 30},
+		{"rock", "Rock Festival", "Guitars and jazz fusion", "Open Park", 10, 50},
+		{"talk", "Tech Talk", "Talks", "Blue Hall Annex", 20, 10},
+	} {
+		event := createTestEvent(t, db, 10, e.price)
+		db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumns(map[string]interface{}{
+			"title": e.title, "description": e.description, "location": e.location, "date": now.AddDate(0, 0, e.days),
+		})
+		events[e.key] = event
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "no filters", query: "", want: []string{"jazz", "rock", "talk"}},
+		{name: "empty filters are ignored", query: "?search=&location=&from=&to=", want: []string{"jazz", "rock", "talk"}},
+		{name: "search matches title and description", query: "?search=jazz", want: []string{"jazz", "rock"}},
+		{name: "search ignores case", query: "?search=TECH", want: []string{"talk"}},
+		{name: "location", query: "?location=blue%20hall", want: []string{"jazz", "talk"}},
+		{name: "from", query: "?from=" + day(5), want: []string{"rock", "talk"}},
+		{name: "to", query: "?to=" + day(15), want: []string{"jazz", "rock"}},
+		{name: "date range", query: "?from=" + day(5) + "&to=" + day(15), want: []string{"rock"}},
+		{name: "search and date", query: "?search=jazz&to=" + day(5), want: []string{"jazz"}},
+		{name: "location and date", query: "?location=blue&from=" + day(5), want: []string{"talk"}},
+		{name: "sort by price", query: "?sort=price", want: []string{"talk", "jazz", "rock"}},
+		{name: "sort by price descending", query: "?sort=price&order=desc", want: []string{"rock", "jazz", "talk"}},
+		{name: "sort by title descending", query: "?sort=title&order=desc", want: []string{"talk", "rock", "jazz"}},
+		{name: "filter and sort", query: "?location=blue&sort=date&order=desc", want: []string{"talk", "jazz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.GetEvents(w, authedRequest("GET", "/api/events"+tt.query, "", user, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetEvents returned %d: %s", w.Code, w.Body)
+			}
+			var got []EventResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("decode events: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events, want %v", len(got), tt.want)
+			}
+			for i, key := range tt.want {
+				if got[i].ID != events[key].ID {
+					t.Errorf("event %d is %q, want %s", i, got[i].Title, key)
+				}
+			}
+		})
+	}
+
+	for _, query := range []string{"?from=tomorrow", "?to=2024-13-01", "?sort=popularity", "?sort=price&order=up"} {
+		w := httptest.NewRecorder()
+		h.GetEvents(w, authedRequest("GET", "/api/events"+query, "", user, nil))
+		if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeInvalidParameter {
+			t.Errorf("GetEvents%s returned %d: %s", query, w.Code, w.Body)
+		}
+	}
+}
+
+func TestEventDateMustBeInTheFuture(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("EVENT_DATE_GRACE_SECONDS", "300")
+	h := NewEventHandler(db)
+	admin := createTestUser(t, db, "admin")
+
+	create := func(date time.Time) *httptest.ResponseRecorder {
+		body := `{"title": "Concert", "description": "Concert", "date": "` + date.UTC().Format(time.RFC3339) + `", "location": "Hall", "capacity": 10, "price": 20}`
+		w := httptest.NewRecorder()
+		h.CreateEvent(w, authedRequest("POST", "/api/events", body, admin, nil))
+		return w
+	}
+
+	w := create(time.Now().Add(-time.Hour))
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeValidationFailed {
+		t.Fatalf("event dated an hour ago returned %d: %s", w.Code, w.Body.String())
+	}
+	var count int
+	db.Model(&models.Event{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("rejected event was stored")
+	}
+
+	// A date just behind the server clock is within the grace window
+	if w := create(time.Now().Add(-time.Minute)); w.Code != http.StatusCreated {
+		t.Fatalf("event dated a minute ago returned %d: %s", w.Code, w.Body.String())
+	}
+
+	event := createTestEvent(t, db, 10, 20)
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	body := `{"date": "` + time.Now().Add(-24*time.Hour).UTC().Format(time.RFC3339) + `", "version": 1}`
+	w = httptest.NewRecorder()
+	h.UpdateEvent(w, authedRequest("PUT", "/api/events/"+vars["id"], body, admin, vars))
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeValidationFailed {
+		t.Fatalf("moving the event into the past returned %d: %s", w.Code, w.Body.String())
+	}
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	if stored.Date.Before(time.Now()) {
+		t.Fatalf("rejected update moved the event to %v", stored.Date)
+	}
+}
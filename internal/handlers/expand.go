@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ticketExpansions maps the relations a ticket response may expand to their preload names
+var ticketExpansions = map[string]string{
+	"event":           "Event",
+	"user":            "User",
+	"attendance_logs": "AttendanceLogs",
+}
+
+// eventExpansions maps the relations an event response may expand to their preload names
+var eventExpansions = map[string]string{
+	"tickets":      "Tickets",
+	"ticket_types": "TicketTypes",
+}
+
+// parseExpand parses the comma separated ?expand= parameter against the allowed relations,
+// falling back to defaultValue when the parameter is absent
+func parseExpand(r *http.Request, allowed map[string]string, defaultValue string) (map[string]bool, error) {
+	value := defaultValue
+	if values, ok := r.URL.Query()["expand"]; ok {
+		value = values[0]
+	}
+
+	expand := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := allowed[name]; !ok {
+			return nil, fmt.Errorf("unknown expand field: %s", name)
+		}
+		expand[name] = true
+	}
+	return expand, nil
+}
+
+// preloadExpansions preloads the requested relations on the query
+func preloadExpansions(query *gorm.DB, expand map[string]bool, allowed map[string]string) *gorm.DB {
+	for name := range expand {
+		query = query.Preload(allowed[name])
+	}
+	return query
+}
+
+// TicketResponse is a ticket with its relations included only when expanded. The pointer
+// fields shadow the embedded relations so unexpanded ones are omitted instead of zero-valued.
+type TicketResponse struct {
+	models.Ticket
+	Event          *models.Event          `json:"event,omitempty"`
+	User           *models.User           `json:"user,omitempty"`
+	AttendanceLogs []models.AttendanceLog `json:"attendance_logs,omitempty"`
+}
+
+// newTicketResponse builds the response for a ticket with the expanded relations
+func newTicketResponse(ticket models.Ticket, expand map[string]bool) TicketResponse {
+	response := TicketResponse{Ticket: ticket}
+	if expand["event"] {
+		event := ticket.Event
+		event.Tickets = nil
+		response.Event = &event
+	}
+	if expand["user"] && ticket.UserID != nil {
+		user := ticket.User
+		response.User = &user
+	}
+	if expand["attendance_logs"] {
+		response.AttendanceLogs = ticket.AttendanceLogs
+	}
+	return response
+}
+
+// newTicketResponses builds the responses for a list of tickets
+func newTicketResponses(tickets []models.Ticket, expand map[string]bool) []TicketResponse {
+	responses := make([]TicketResponse, len(tickets))
+	for i, ticket := range tickets {
+		responses[i] = newTicketResponse(ticket, expand)
+	}
+	return responses
+}
+
+// EventResponse is an event with its tickets included only when expanded
+type EventResponse struct {
+	models.Event
+	Tickets []TicketResponse `json:"tickets,omitempty"`
+}
+
+// newEventResponse builds the response for an event with the expanded relations
+func newEventResponse(event models.Event, expand map[string]bool) EventResponse {
+	response := EventResponse{Event: event}
+	if expand["tickets"] {
+		response.Tickets = newTicketResponses(event.Tickets, nil)
+	}
+	return response
+}
+
+// newEventResponses builds the responses for a list of events
+func newEventResponses(events []models.Event, expand map[string]bool) []EventResponse {
+	responses := make([]EventResponse, len(events))
+	for i, event := range events {
+		responses[i] = newEventResponse(event, expand)
+	}
+	return responses
+}
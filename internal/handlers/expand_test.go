@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestGetTicketExpandsRelationsOnRequest(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	holder := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 5, 20)
+	ticket := createTestTicket(t, db, event, holder)
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+
+	tests := []struct {
+		name    string
+		query   string
+		present []string
+		absent  []string
+	}{
+		{name: "absent", query: "", absent: []string{"event", "user", "attendance_logs"}},
+		{name: "event and user", query: "?expand=event,user", present: []string{"event", "user"}, absent: []string{"attendance_logs"}},
+		{name: "event only", query: "?expand=event", present: []string{"event"}, absent: []string{"user"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.GetTicket(w, authedRequest("GET", "/api/tickets/"+vars["id"]+tt.query, "", holder, vars))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetTicket returned %d: %s", w.Code, w.Body.String())
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+				t.Fatalf("decode ticket: %v", err)
+			}
+			if string(fields["event_id"]) != strconv.Itoa(int(event.ID)) {
+				t.Errorf("event_id is %s, want %d", fields["event_id"], event.ID)
+			}
+			for _, name := range tt.present {
+				if _, ok := fields[name]; !ok {
+					t.Errorf("expanded relation %q is missing", name)
+				}
+			}
+			for _, name := range tt.absent {
+				if _, ok := fields[name]; ok {
+					t.Errorf("unexpanded relation %q is included", name)
+				}
+			}
+		})
+	}
+
+	var expanded TicketResponse
+	w := httptest.NewRecorder()
+	h.GetTicket(w, authedRequest("GET", "/api/tickets/"+vars["id"]+"?expand=event,user", "", holder, vars))
+	if err := json.NewDecoder(w.Body).Decode(&expanded); err != nil {
+		t.Fatalf("decode expanded ticket: %v", err)
+	}
+	if expanded.Event == nil || expanded.Event.ID != event.ID || expanded.User == nil || expanded.User.ID != holder.ID {
+		t.Fatalf("expanded relations do not match the ticket's event and holder: %+v", expanded)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetTicket(w, authedRequest("GET", "/api/tickets/"+vars["id"]+"?expand=owner", "", holder, vars))
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeInvalidParameter {
+		t.Fatalf("unknown expand field returned %d: %s", w.Code, w.Body.String())
+	}
+}
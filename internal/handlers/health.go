@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/version"
+
+	"github.com/jinzhu/gorm"
+)
+
+// startedAt is when the server process started, for the uptime in health checks
+var startedAt = time.Now()
+
+// HealthHandler answers liveness and readiness probes
+type HealthHandler struct {
+	db *gorm.DB
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Health reports that the server is up, with its version and uptime. It does not touch the
+// database, so a slow database does not get the process restarted.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := map[string]interface{}{
+		"status":         "ok",
+		"version":        version.Version,
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Ready reports whether the server can take traffic, which requires a reachable database
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
+		return
+	}
+	if err := h.db.DB().Ping(); err != nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database is not reachable")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
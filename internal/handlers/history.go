@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// TimelineEntry is a single event in a ticket's lifecycle
+type TimelineEntry struct {
+	Type    string                 `json:"type"`
+	At      time.Time              `json:"at"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// buildTicketTimeline merges the purchase, transfers, audit entries and check-ins of a ticket
+// into a chronological timeline. Non-admin views omit the identities of other parties.
+func buildTicketTimeline(ticket models.Ticket, transfers []models.TicketTransfer, audits []models.AuditLog, full bool) []TimelineEntry {
+	timeline := []TimelineEntry{{
+		Type: "purchased",
+		At:   ticket.CreatedAt,
+	}}
+
+	for _, transfer := range transfers {
+		details := map[string]interface{}{}
+		if full {
+			details["from_user_id"] = transfer.FromUserID
+			details["to_user_id"] = transfer.ToUserID
+		}
+		timeline = append(timeline, TimelineEntry{Type: "transferred", At: transfer.CreatedAt, Details: details})
+	}
+
+	for _, audit := range audits {
+		details := map[string]interface{}{"action": audit.Action}
+		if audit.Details != "" {
+			details["details"] = audit.Details
+		}
+		if full {
+			details["actor_id"] = audit.ActorID
+		}
+		timeline = append(timeline, TimelineEntry{Type: "audit", At: audit.CreatedAt, Details: details})
+	}
+
+	for _, attendance := range ticket.AttendanceLogs {
+		timeline = append(timeline, TimelineEntry{Type: "checked_in", At: attendance.CheckedInAt})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].At.Before(timeline[j].At)
+	})
+	return timeline
+}
+
+// GetTicketHistory retrieves the chronological lifecycle of a ticket
+func (h *TicketHandler) GetTicketHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	isAdmin := r.Context().Value("user_role") == "admin"
+
+	query := h.db.Preload("AttendanceLogs").Where("id = ?", ticketID)
+	if !isAdmin {
+		// Regular users can only see the history of their own tickets
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var ticket models.Ticket
+	if err := query.First(&ticket).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	var transfers []models.TicketTransfer
+	if err := h.db.Where("ticket_id = ?", ticket.ID).Find(&transfers).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve transfer history")
+		return
+	}
+
+	var audits []models.AuditLog
+	if err := h.db.Where("entity_type = ? AND entity_id = ?", "ticket", ticket.ID).Find(&audits).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve audit log")
+		return
+	}
+
+	response := map[string]interface{}{
+		"ticket_id": ticket.ID,
+		"history":   buildTicketTimeline(ticket, transfers, audits, isAdmin),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
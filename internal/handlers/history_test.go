@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// ticketHistory fetches the ticket's history as the user and returns the response code and
+// timeline
+func ticketHistory(t *testing.T, h *TicketHandler, ticket models.Ticket, user models.User) (int, []TimelineEntry) {
+	t.Helper()
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.GetTicketHistory(w, authedRequest("GET", "/api/tickets/"+vars["id"]+"/history", "", user, vars))
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+	var response struct {
+		History []TimelineEntry `json:"history"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	return w.Code, response.History
+}
+
+func TestGetTicketHistoryOrdersTransferAndCheckIn(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	buyer, recipient := createTestUser(t, db, "user"), createTestUser(t, db, "user")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), buyer)
+
+	if w := transfer(h, ticket, buyer, recipient); w.Code != http.StatusOK {
+		t.Fatalf("transfer returned %d: %s", w.Code, w.Body.String())
+	}
+	if w := scanTicket(h, ticket, admin); w.Code != http.StatusOK {
+		t.Fatalf("check-in returned %d: %s", w.Code, w.Body.String())
+	}
+
+	code, history := ticketHistory(t, h, ticket, recipient)
+	if code != http.StatusOK {
+		t.Fatalf("history as the holder returned %d", code)
+	}
+	position := map[string]int{}
+	for i, entry := range history {
+		if _, seen := position[entry.Type]; !seen {
+			position[entry.Type] = i
+		}
+		if i > 0 && entry.At.Before(history[i-1].At) {
+			t.Errorf("entry %d at %v comes before the previous one at %v", i, entry.At, history[i-1].At)
+		}
+		if entry.Type == "transferred" && len(entry.Details) != 0 {
+			t.Errorf("holder sees transfer details %v", entry.Details)
+		}
+	}
+	purchased, okPurchased := position["purchased"]
+	transferred, okTransferred := position["transferred"]
+	checkedIn, okCheckedIn := position["checked_in"]
+	if !okPurchased || !okTransferred || !okCheckedIn || !(purchased < transferred && transferred < checkedIn) {
+		t.Fatalf("history %+v, want the purchase, then the transfer, then the check-in", history)
+	}
+
+	// The previous holder no longer sees the ticket, admins see who it went to
+	if code, _ := ticketHistory(t, h, ticket, buyer); code != http.StatusNotFound {
+		t.Fatalf("history as the previous holder returned %d, want %d", code, http.StatusNotFound)
+	}
+	_, history = ticketHistory(t, h, ticket, admin)
+	for _, entry := range history {
+		if entry.Type == "transferred" && entry.Details["to_user_id"] != float64(recipient.ID) {
+			t.Fatalf("admin sees transfer details %v, want the recipient %d", entry.Details, recipient.ID)
+		}
+	}
+}
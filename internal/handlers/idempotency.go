@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// idempotencyKeyHeader is the header clients send to make a purchase safe to retry
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength caps the length of an idempotency key
+const maxIdempotencyKeyLength = 255
+
+// idempotencyKeyTTL is how long a purchase is replayed for its idempotency key. Afterwards the
+// key is released and may start a new purchase.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// errIdempotencyKeyReused is returned when a key is sent again for a different purchase
+var errIdempotencyKeyReused = errors.New("Idempotency-Key was already used for a different purchase")
+
+// idempotencyKey returns the Idempotency-Key header of a request, or an empty string when the
+// client did not send one
+func idempotencyKey(r *http.Request) (string, error) {
+	key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+	if len(key) > maxIdempotencyKeyLength {
+		return "", fmt.Errorf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+	}
+	return key, nil
+}
+
+// purchaseFingerprint hashes what a purchase request asks for: the event and the request body
+// without its payment token, which a client may replace when it retries
+func purchaseFingerprint(eventID uint, req PurchaseTicketRequest) string {
+	req.PaymentToken = ""
+	body, _ := json.Marshal(req)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", eventID, body)))
+	return hex.EncodeToString(sum[:])
+}
+
+// findIdempotentOrder returns the order a user placed with an idempotency key within the last
+// idempotencyKeyTTL, with its tickets, or nil when there is none. An order whose key expired
+// gives the key up. errIdempotencyKeyReused is returned when the order was for another
+// purchase: a different request body, or for orders without a fingerprint another event or
+// quantity.
+func findIdempotentOrder(db *gorm.DB, userID uint, key string, eventID uint, req PurchaseTicketRequest, now time.Time) (*models.Order, error) {
+	var order models.Order
+	err := db.Preload("Tickets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id asc")
+	}).Preload("Tickets.Fields").Where("user_id = ? AND idempotency_key = ?", userID, key).First(&order).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if now.Sub(order.CreatedAt) >= idempotencyKeyTTL {
+		if err := db.Model(&models.Order{}).Where("id = ?", order.ID).UpdateColumn("idempotency_key", gorm.Expr("NULL")).Error; err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if order.EventID != eventID || order.Quantity != req.Quantity {
+		return nil, errIdempotencyKeyReused
+	}
+	if order.IdempotencyFingerprint != "" && order.IdempotencyFingerprint != purchaseFingerprint(eventID, req) {
+		return nil, errIdempotencyKeyReused
+	}
+	return &order, nil
+}
+
+// orderQuote rebuilds the price breakdown a purchase was charged from, using the prices, discount
+// and tax rate stored on the order and its tickets rather than the current ones
+func orderQuote(db *gorm.DB, order models.Order) (PriceQuote, error) {
+	quote := PriceQuote{EventID: order.EventID, Quantity: order.Quantity}
+	if len(order.Tickets) > 0 {
+		quote.TicketTypeID = order.Tickets[0].TicketTypeID
+		quote.UnitPrice = order.Tickets[0].PricePaid
+	}
+
+	if order.PromoCodeID != nil {
+		var promo models.PromoCode
+		if err := db.Where("id = ?", *order.PromoCodeID).First(&promo).Error; err != nil {
+			return PriceQuote{}, err
+		}
+		quote.PromoCode = promo.Code
+	}
+
+	subtotal := roundCents(float64(order.Quantity) * quote.UnitPrice)
+	lines := []ReceiptLine{{Quantity: order.Quantity, UnitPrice: quote.UnitPrice, Amount: subtotal}}
+	quote.ReceiptTotals = calculateReceiptTotals(lines, order.Discount, order.TaxRate)
+	return quote, nil
+}
+
+// replayIdempotentPurchase answers a retried purchase with the order placed earlier with the same
+// idempotency key, reporting whether it wrote a response. A key sent for another purchase is
+// rejected with 409.
+func (h *TicketHandler) replayIdempotentPurchase(w http.ResponseWriter, userID uint, key string, eventID uint, req PurchaseTicketRequest) bool {
+	order, err := findIdempotentOrder(h.db, userID, key, eventID, req, time.Now())
+	if err == errIdempotencyKeyReused {
+		respondError(w, http.StatusConflict, apierror.CodeConflict, err.Error())
+		return true
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve order")
+		return true
+	}
+	if order == nil {
+		return false
+	}
+
+	// A pending order is replayed as the hold it was, otherwise the first request is still
+	// charging it
+	if order.Status == orderStatusPending && !req.Hold {
+		respondError(w, http.StatusConflict, apierror.CodeConflict, "A purchase with this Idempotency-Key is in progress, please retry")
+		return true
+	}
+
+	quote, err := orderQuote(h.db, *order)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve order")
+		return true
+	}
+
+	tickets := order.Tickets
+	order.Tickets = nil
+
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(purchaseResponse(*order, tickets, quote))
+	return true
+}
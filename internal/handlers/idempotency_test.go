@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// purchaseWithKey purchases tickets of the event as the user with the Idempotency-Key and
+// returns the recorded response
+func purchaseWithKey(h *TicketHandler, event models.Event, user models.User, key, body string) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	r := authedRequest("POST", "/api/events/"+vars["id"]+"/purchase", body, user, vars)
+	r.Header.Set(idempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	h.PurchaseTicket(w, r)
+	return w
+}
+
+// purchasedOrderID decodes the order ID of a purchase response
+func purchasedOrderID(t *testing.T, w *httptest.ResponseRecorder) uint {
+	t.Helper()
+	var response struct {
+		Order models.Order `json:"order"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode purchase response: %v", err)
+	}
+	return response.Order.ID
+}
+
+func TestIdempotentPurchaseReplaysOriginalOrder(t *testing.T) {
+	db := openTestDB(t)
+	fake := payment.NewFake()
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, fake)
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	body := `{"quantity": 2, "payment_token": "tok_test"}`
+
+	first := purchaseWithKey(h, event, buyer, "retry-1", body)
+	if first.Code != http.StatusCreated || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first purchase returned %d, replayed %q: %s", first.Code, first.Header().Get("Idempotent-Replayed"), first.Body.String())
+	}
+	// A retry may come with a fresh payment token and still replays the purchase
+	retry := purchaseWithKey(h, event, buyer, "retry-1", `{"quantity": 2, "payment_token": "tok_other"}`)
+	if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry returned %d, replayed %q: %s", retry.Code, retry.Header().Get("Idempotent-Replayed"), retry.Body.String())
+	}
+	if purchasedOrderID(t, first) != purchasedOrderID(t, retry) {
+		t.Fatalf("retry returned order %d, want the original %d", purchasedOrderID(t, retry), purchasedOrderID(t, first))
+	}
+
+	var issued int
+	db.Model(&models.Ticket{}).Where("event_id = ?", event.ID).Count(&issued)
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	if issued != 2 || stored.SoldCount != 2 || len(fake.Charges()) != 1 {
+		t.Fatalf("%d tickets issued, sold count %d and %d charges, want one purchase of 2", issued, stored.SoldCount, len(fake.Charges()))
+	}
+}
+
+func TestIdempotencyKeyRejectsDifferentPurchase(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	promo := models.PromoCode{Code: "RETRY", EventID: &event.ID, PercentOff: 50, Active: true}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	if w := purchaseWithKey(h, event, buyer, "retry-2", `{"quantity": 1, "payment_token": "tok_test"}`); w.Code != http.StatusCreated {
+		t.Fatalf("first purchase returned %d: %s", w.Code, w.Body.String())
+	}
+
+	for name, body := range map[string]string{
+		"quantity":   `{"quantity": 2, "payment_token": "tok_test"}`,
+		"promo code": `{"quantity": 1, "promo_code": "RETRY", "payment_token": "tok_test"}`,
+		"hold":       `{"quantity": 1, "hold": true}`,
+	} {
+		if w := purchaseWithKey(h, event, buyer, "retry-2", body); w.Code != http.StatusConflict {
+			t.Errorf("key reused with a different %s returned %d, want %d", name, w.Code, http.StatusConflict)
+		}
+	}
+	if w := purchaseWithKey(h, createTestEvent(t, db, 10, 20), buyer, "retry-2", `{"quantity": 1, "payment_token": "tok_test"}`); w.Code != http.StatusConflict {
+		t.Errorf("key reused for another event returned %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	var orders int
+	db.Model(&models.Order{}).Where("user_id = ?", buyer.ID).Count(&orders)
+	if orders != 1 {
+		t.Fatalf("buyer has %d orders, want 1", orders)
+	}
+}
+
+func TestIdempotencyKeyIsScopedPerUser(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	event := createTestEvent(t, db, 10, 20)
+	body := `{"quantity": 1, "payment_token": "tok_test"}`
+
+	first := purchaseWithKey(h, event, createTestUser(t, db, "user"), "shared-key", body)
+	second := purchaseWithKey(h, event, createTestUser(t, db, "user"), "shared-key", body)
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("purchases with the same key by two users returned %d and %d", first.Code, second.Code)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "" || purchasedOrderID(t, first) == purchasedOrderID(t, second) {
+		t.Fatalf("second user got the first user's order %d back", purchasedOrderID(t, first))
+	}
+}
+
+func TestIdempotencyKeyExpiresAfter24Hours(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	body := `{"quantity": 1, "payment_token": "tok_test"}`
+
+	first := purchaseWithKey(h, event, buyer, "retry-3", body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first purchase returned %d: %s", first.Code, first.Body.String())
+	}
+	original := purchasedOrderID(t, first)
+
+	// Just inside the TTL the purchase is still replayed
+	if err := db.Model(&models.Order{}).Where("id = ?", original).UpdateColumn("created_at", time.Now().Add(-idempotencyKeyTTL+time.Minute)).Error; err != nil {
+		t.Fatalf("backdate order: %v", err)
+	}
+	if w := purchaseWithKey(h, event, buyer, "retry-3", body); w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry within 24 hours returned %d without a replay", w.Code)
+	}
+
+	if err := db.Model(&models.Order{}).Where("id = ?", original).UpdateColumn("created_at", time.Now().Add(-idempotencyKeyTTL-time.Minute)).Error; err != nil {
+		t.Fatalf("backdate order: %v", err)
+	}
+	w := purchaseWithKey(h, event, buyer, "retry-3", body)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" || purchasedOrderID(t, w) == original {
+		t.Fatalf("purchase with an expired key returned %d, replayed %q, want a new order", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+
+	var old models.Order
+	db.Where("id = ?", original).First(&old)
+	if old.IdempotencyKey != nil {
+		t.Fatalf("expired order kept its key %q", *old.IdempotencyKey)
+	}
+}
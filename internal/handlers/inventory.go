@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// lowInventoryWebhookEvent is the webhook event type sent when an event nears sell-out
+const lowInventoryWebhookEvent = "low_inventory"
+
+// LowInventoryAlert is the data of a low_inventory webhook event
+type LowInventoryAlert struct {
+	EventID   uint `json:"event_id"`
+	Remaining int  `json:"remaining"`
+	Threshold int  `json:"threshold"`
+}
+
+// lowInventoryThreshold returns the remaining ticket count at which the low_inventory webhook
+// fires, 0 disables it
+func lowInventoryThreshold() int {
+	return config.GetInt("LOW_INVENTORY_THRESHOLD", 0)
+}
+
+// crossedLowInventory reports whether a purchase of quantity tickets, leaving remaining
+// tickets, took the event from above the threshold to at or below it. Only the purchase that
+// crosses the threshold reports true, later purchases below it do not.
+func crossedLowInventory(remaining, quantity, threshold int) bool {
+	return threshold > 0 && remaining <= threshold && remaining+quantity > threshold
+}
+
+// notifyLowInventory sends the low_inventory webhook for an event in the background and queues
+// it for the webhooks registered for it
+func (h *TicketHandler) notifyLowInventory(eventID uint, remaining, threshold int) {
+	alert := LowInventoryAlert{EventID: eventID, Remaining: remaining, Threshold: threshold}
+	event := webhook.Event{
+		Type:       lowInventoryWebhookEvent,
+		OccurredAt: time.Now().UTC(),
+		Data:       alert,
+	}
+	queueWebhookDeliveries(h.db, lowInventoryWebhookEvent, alert)
+
+	go func() {
+		if err := h.webhooks.Notify(event); err != nil {
+			log.Printf("Failed to deliver %s webhook for event %d: %v", lowInventoryWebhookEvent, eventID, err)
+		}
+	}()
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestCrossedLowInventory(t *testing.T) {
+	tests := []struct {
+		remaining, quantity, threshold int
+		want                           bool
+	}{
+		{remaining: 5, quantity: 5, threshold: 3, want: false},
+		{remaining: 3, quantity: 2, threshold: 3, want: true},
+		{remaining: 0, quantity: 5, threshold: 3, want: true},
+		{remaining: 2, quantity: 1, threshold: 3, want: false},
+		{remaining: 3, quantity: 2, threshold: 0, want: false},
+	}
+	for _, tt := range tests {
+		if got := crossedLowInventory(tt.remaining, tt.quantity, tt.threshold); got != tt.want {
+			t.Errorf("crossedLowInventory(%d, %d, %d) = %t, want %t", tt.remaining, tt.quantity, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestLowInventoryWebhookFiresOncePerCrossing(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("LOW_INVENTORY_THRESHOLD", "3")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	hook := models.Webhook{URL: "https://hooks.example.com/inventory", EventType: lowInventoryWebhookEvent, Secret: "secret", Active: true}
+	if err := db.Create(&hook).Error; err != nil {
+		t.Fatalf("register webhook: %v", err)
+	}
+
+	// Availability goes 10 -> 5 -> 3 -> 2 -> 0, crossing the threshold of 3 once
+	for _, quantity := range []string{"5", "2", "1", "2"} {
+		if code := purchaseOne(h, event, buyer, `{"quantity": `+quantity+`, "payment_token": "tok_test"}`); code != http.StatusCreated {
+			t.Fatalf("purchase of %s tickets returned %d", quantity, code)
+		}
+	}
+
+	var deliveries []models.WebhookDelivery
+	db.Where("webhook_id = ?", hook.ID).Find(&deliveries)
+	if len(deliveries) != 1 {
+		t.Fatalf("queued %d low_inventory webhooks, want 1", len(deliveries))
+	}
+	var payload struct {
+		Type string            `json:"type"`
+		Data LowInventoryAlert `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(deliveries[0].Payload), &payload); err != nil {
+		t.Fatalf("decode webhook payload: %v", err)
+	}
+	if payload.Type != lowInventoryWebhookEvent || payload.Data != (LowInventoryAlert{EventID: event.ID, Remaining: 3, Threshold: 3}) {
+		t.Fatalf("webhook payload is %+v", payload)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/mailer"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// inviteRecipients returns the distinct holders of the source event's tickets who have not
+// unsubscribed from invitations and do not already hold a ticket for the target event
+func inviteRecipients(db *gorm.DB, sourceEventID, targetEventID uint) ([]models.User, error) {
+	var users []models.User
+	err := db.Where("id IN (?)", db.Table("tickets").Select("user_id").
+		Where("event_id = ? AND user_id IS NOT NULL", sourceEventID).SubQuery()).
+		Where("id NOT IN (?)", db.Table("tickets").Select("user_id").
+			Where("event_id = ? AND user_id IS NOT NULL", targetEventID).SubQuery()).
+		Where("invites_opt_out = ?", false).
+		Order("id asc").Find(&users).Error
+	return users, err
+}
+
+// InviteFromEvent emails the attendees of a source event an invitation to the target event in
+// the background. Both events must be managed by the current user.
+func (h *NotificationHandler) InviteFromEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get IDs from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	targetID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+	sourceID, err := strconv.ParseUint(vars["sourceEventId"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid source event ID")
+		return
+	}
+
+	if targetID == sourceID {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Source and target events must differ")
+		return
+	}
+
+	var target, source models.Event
+	for _, lookup := range []struct {
+		id    uint64
+		event *models.Event
+	}{{targetID, &target}, {sourceID, &source}} {
+		if err := h.db.Where("id = ?", lookup.id).First(lookup.event).Error; err != nil {
+			if gorm.IsRecordNotFoundError(err) {
+				respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+			return
+		}
+
+		if !canManageEvent(r, *lookup.event) {
+			respondError(w, http.StatusForbidden, apierror.CodeForbidden, "You do not manage this event")
+			return
+		}
+	}
+
+	if target.Status == "cancelled" || target.Date.Before(time.Now()) {
+		respondError(w, http.StatusBadRequest, apierror.CodeEventPast, "Invitations can only be sent for upcoming events")
+		return
+	}
+
+	recipients, err := inviteRecipients(h.db, source.ID, target.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendees")
+		return
+	}
+
+	job := h.jobs.Create("invite_from_event", r.Context().Value("user_id").(uint), len(recipients))
+
+	go h.sendInvites(job.ID, target, recipients)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// sendInvites delivers the invitation emails at a limited rate and records progress on the job
+func (h *NotificationHandler) sendInvites(jobID string, event models.Event, recipients []models.User) {
+	h.jobs.Start(jobID)
+
+	throttle := time.NewTicker(mailInterval())
+	defer throttle.Stop()
+
+	for _, user := range recipients {
+		<-throttle.C
+
+		err := h.mailer.Send(mailer.Message{
+			To:      user.Email,
+			Subject: fmt.Sprintf("You're invited: %s", event.Title),
+			Body: fmt.Sprintf("Hi %s,\n\nThanks for attending last time! %s takes place on %s at %s and tickets are available now.\n\n"+
+				"To stop receiving invitations, unsubscribe from your account settings.\n",
+				user.Name, event.Title, format.Date(event.Date), event.Location),
+		})
+		if err != nil {
+			log.Printf("Failed to send invitation for event %d to user %d: %v", event.ID, user.ID, err)
+		}
+
+		h.jobs.Progress(jobID, err == nil)
+	}
+
+	h.jobs.Complete(jobID)
+}
+
+// Unsubscribe opts the current user out of event invitations
+func (h *NotificationHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Update("invites_opt_out", true).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to unsubscribe")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "You will no longer receive event invitations"})
+}
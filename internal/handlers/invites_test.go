@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+)
+
+func TestInviteFromEventInvitesDistinctSourceAttendees(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("MAIL_RATE_PER_SECOND", "1000")
+	sender := &recordingSender{}
+	tracker := jobs.NewTracker()
+	h := NewNotificationHandler(db, sender, tracker)
+	organizer := createTestUser(t, db, "organizer")
+
+	source := createTestEvent(t, db, 10, 20)
+	target := createTestEvent(t, db, 10, 20)
+	unrelated := createTestEvent(t, db, 10, 20)
+	db.Model(&models.Event{}).Where("id IN (?)", []uint{source.ID, target.ID}).UpdateColumn("organizer_id", organizer.ID)
+
+	// A returning attendee with two tickets, one who unsubscribed, one who already holds a
+	// target ticket, and an attendee of another event only
+	returning := createTestUser(t, db, "user")
+	unsubscribed := createTestUser(t, db, "user")
+	booked := createTestUser(t, db, "user")
+	outsider := createTestUser(t, db, "user")
+	createTestTicket(t, db, source, returning)
+	createTestTicket(t, db, source, returning)
+	createTestTicket(t, db, source, unsubscribed)
+	createTestTicket(t, db, source, booked)
+	createTestTicket(t, db, target, booked)
+	createTestTicket(t, db, unrelated, outsider)
+
+	w := httptest.NewRecorder()
+	h.Unsubscribe(w, authedRequest("POST", "/api/me/unsubscribe", "", unsubscribed, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unsubscribe returned %d: %s", w.Code, w.Body.String())
+	}
+
+	vars := map[string]string{"id": strconv.Itoa(int(target.ID)), "sourceEventId": strconv.Itoa(int(source.ID))}
+	w = httptest.NewRecorder()
+	h.InviteFromEvent(w, authedRequest("POST", "/api/events/"+vars["id"]+"/invite-from/"+vars["sourceEventId"], "", organizer, vars))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("invite-from returned %d: %s", w.Code, w.Body.String())
+	}
+	var job jobs.Job
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	job = waitForJob(t, tracker, job.ID)
+
+	sent := sender.sent()
+	if job.Total != 1 || len(sent) != 1 || sent[0].To != returning.Email {
+		t.Fatalf("invited %d of %d recipients, want only %s", len(sent), job.Total, returning.Email)
+	}
+
+	// Attendees of events the organizer does not manage cannot be invited
+	vars["sourceEventId"] = strconv.Itoa(int(unrelated.ID))
+	w = httptest.NewRecorder()
+	h.InviteFromEvent(w, authedRequest("POST", "/api/events/"+vars["id"]+"/invite-from/"+vars["sourceEventId"], "", organizer, vars))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("inviting from another organizer's event returned %d: %s", w.Code, w.Body.String())
+	}
+}
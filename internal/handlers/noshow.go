@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+// EventNoShow holds the attendance figures of one past event
+type EventNoShow struct {
+	EventID    uint      `json:"event_id"`
+	Title      string    `json:"title"`
+	Date       time.Time `json:"date"`
+	Sold       int       `json:"sold"`
+	CheckedIn  int       `json:"checked_in"`
+	NoShows    int       `json:"no_shows"`
+	NoShowRate float64   `json:"no_show_rate"`
+}
+
+// NoShowTotals holds the attendance figures summed over every event of the report
+type NoShowTotals struct {
+	Sold       int     `json:"sold"`
+	CheckedIn  int     `json:"checked_in"`
+	NoShows    int     `json:"no_shows"`
+	NoShowRate float64 `json:"no_show_rate"`
+}
+
+// NoShowReport is the no-show report of an organizer's past events in a date range
+type NoShowReport struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Events  []EventNoShow `json:"events"`
+	Overall NoShowTotals  `json:"overall"`
+}
+
+// noShowRate returns the percentage of sold tickets that were never checked in
+func noShowRate(sold, checkedIn int) float64 {
+	if sold == 0 {
+		return 0
+	}
+	return roundCents(float64(sold-checkedIn) * 100 / float64(sold))
+}
+
+// buildNoShowReport fills in the per event no-shows and rates and sums the overall totals
+func buildNoShowReport(from, to time.Time, events []EventNoShow) NoShowReport {
+	report := NoShowReport{From: from, To: to, Events: events}
+	for i := range report.Events {
+		event := &report.Events[i]
+		event.NoShows = event.Sold - event.CheckedIn
+		event.NoShowRate = noShowRate(event.Sold, event.CheckedIn)
+
+		report.Overall.Sold += event.Sold
+		report.Overall.CheckedIn += event.CheckedIn
+	}
+	report.Overall.NoShows = report.Overall.Sold - report.Overall.CheckedIn
+	report.Overall.NoShowRate = noShowRate(report.Overall.Sold, report.Overall.CheckedIn)
+	return report
+}
+
+// GetNoShowRate reports, per event and overall, the share of sold tickets that were never
+// checked in for the current organizer's past events dated in a range (default last 30 days).
+// Cancelled events and cancelled tickets are left out. Admins see every event.
+func (h *EventHandler) GetNoShowRate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	params := r.URL.Query()
+	now := time.Now().UTC()
+
+	to := now
+	if value := params.Get("to"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid to date")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if value := params.Get("from"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid from date")
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "From date must be before to date")
+		return
+	}
+
+	// A ticket counts as attended once it has any attendance log, however often it was scanned
+	query := h.db.Table("events").
+		Select("events.id AS event_id, events.title, events.date, COUNT(tickets.id) AS sold, COUNT(checkins.ticket_id) AS checked_in").
+		Joins("JOIN tickets ON tickets.event_id = events.id AND tickets.user_id IS NOT NULL AND tickets.status <> 'cancelled'").
+		Joins("LEFT JOIN (SELECT DISTINCT ticket_id FROM attendance_logs) checkins ON checkins.ticket_id = tickets.id").
+		Where("events.deleted_at IS NULL").
+		Where("events.status <> ? AND events.date >= ? AND events.date < ? AND events.date < ?", "cancelled", from, to, now)
+
+	if r.Context().Value("user_role") != "admin" {
+		query = query.Where("events.organizer_id = ?", userID)
+	}
+
+	events := []EventNoShow{}
+	if err := query.Group("events.id, events.title, events.date").Order("events.date asc, events.id asc").
+		Scan(&events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute no-show rate")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(buildNoShowReport(from, to, events))
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// NotificationHandler handles bulk email requests to attendees
+type NotificationHandler struct {
+	db     *gorm.DB
+	mailer mailer.Sender
+	jobs   *jobs.Tracker
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(db *gorm.DB, sender mailer.Sender, tracker *jobs.Tracker) *NotificationHandler {
+	return &NotificationHandler{db: db, mailer: sender, jobs: tracker}
+}
+
+// holderTickets groups the valid tickets of one attendee
+type holderTickets struct {
+	User    models.User
+	Tickets []models.Ticket
+}
+
+// groupTicketsByHolder groups tickets per holder so each attendee is emailed exactly once
+func groupTicketsByHolder(tickets []models.Ticket) []holderTickets {
+	holders := []holderTickets{}
+	index := map[uint]int{}
+
+	for _, ticket := range tickets {
+		// Reserved tickets without a holder have nobody to email
+		if ticket.UserID == nil {
+			continue
+		}
+
+		i, ok := index[*ticket.UserID]
+		if !ok {
+			i = len(holders)
+			index[*ticket.UserID] = i
+			holders = append(holders, holderTickets{User: ticket.User})
+		}
+		holders[i].Tickets = append(holders[i].Tickets, ticket)
+	}
+	return holders
+}
+
+// mailInterval returns the delay between emails, derived from MAIL_RATE_PER_SECOND
+func mailInterval() time.Duration {
+	rate := config.GetInt("MAIL_RATE_PER_SECOND", 5)
+	if rate < 1 {
+		rate = 1
+	}
+	return time.Second / time.Duration(rate)
+}
+
+// SendQRToAll emails every holder of a valid ticket their QR codes in the background
+// and returns a job ID that can be polled for progress (organizer or admin)
+func (h *NotificationHandler) SendQRToAll(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if !canManageEvent(r, event) {
+		respondError(w, http.StatusForbidden, apierror.CodeForbidden, "You do not manage this event")
+		return
+	}
+
+	var tickets []models.Ticket
+	if err := h.db.Preload("User").Where("event_id = ? AND status = ?", event.ID, "valid").Order("id asc").Find(&tickets).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	holders := groupTicketsByHolder(tickets)
+	job := h.jobs.Create("send_qr_all", r.Context().Value("user_id").(uint), len(holders))
+
+	go h.sendQREmails(job.ID, event, holders)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// sendQREmails delivers the QR emails at a limited rate and records progress on the job
+func (h *NotificationHandler) sendQREmails(jobID string, event models.Event, holders []holderTickets) {
+	h.jobs.Start(jobID)
+
+	throttle := time.NewTicker(mailInterval())
+	defer throttle.Stop()
+
+	for _, holder := range holders {
+		<-throttle.C
+
+		msg := mailer.Message{
+			To:      holder.User.Email,
+			Subject: fmt.Sprintf("Your tickets for %s", event.Title),
+			Body: fmt.Sprintf("Hi %s,\n\nYour QR codes for %s on %s at %s are attached. Please have them ready at the entrance.\n",
+				holder.User.Name, event.Title, format.Date(event.Date), event.Location),
+		}
+
+		succeeded := true
+		for _, ticket := range holder.Tickets {
+			png, err := utils.RenderQRCodePNG(ticket.QRCode, 256)
+			if err != nil {
+				log.Printf("Failed to render QR code for ticket %d: %v", ticket.ID, err)
+				succeeded = false
+				continue
+			}
+			msg.Attachments = append(msg.Attachments, mailer.Attachment{
+				Filename:    fmt.Sprintf("ticket_%d.png", ticket.ID),
+				ContentType: "image/png",
+				Data:        png,
+			})
+		}
+
+		if len(msg.Attachments) > 0 {
+			if err := h.mailer.Send(msg); err != nil {
+				log.Printf("Failed to send QR codes to user %d: %v", holder.User.ID, err)
+				succeeded = false
+			}
+		}
+
+		h.jobs.Progress(jobID, succeeded)
+	}
+
+	h.jobs.Complete(jobID)
+}
+
+// GetJob retrieves the progress of a background job started by the current user
+func (h *NotificationHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	job, ok := h.jobs.Get(vars["id"])
+	if !ok || (r.Context().Value("user_role") != "admin" && r.Context().Value("user_id") != job.OwnerID) {
+		respondError(w, http.StatusNotFound, apierror.CodeJobNotFound, "Job not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+)
+
+// waitForJob waits for the background job to complete and returns its final state
+func waitForJob(t *testing.T, tracker *jobs.Tracker, id string) jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, ok := tracker.Get(id)
+		if !ok {
+			t.Fatalf("job %s is not tracked", id)
+		}
+		if job.Status == "completed" {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %q after 5s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSendQRToAllEmailsEachHolderOnce(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("MAIL_RATE_PER_SECOND", "1000")
+	sender := &recordingSender{}
+	tracker := jobs.NewTracker()
+	h := NewNotificationHandler(db, sender, tracker)
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+
+	// One holder with two tickets, one with a single ticket, and one whose only ticket
+	// was cancelled
+	multiple := createTestUser(t, db, "user")
+	single := createTestUser(t, db, "user")
+	cancelled := createTestUser(t, db, "user")
+	createTestTicket(t, db, event, multiple)
+	createTestTicket(t, db, event, single)
+	createTestTicket(t, db, event, multiple)
+	ticket := createTestTicket(t, db, event, cancelled)
+	db.Model(&models.Ticket{}).Where("id = ?", ticket.ID).UpdateColumn("status", "cancelled")
+
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.SendQRToAll(w, authedRequest("POST", "/api/events/"+vars["id"]+"/send-qr-all", "", admin, vars))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("send-qr-all returned %d: %s", w.Code, w.Body.String())
+	}
+	var job jobs.Job
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	if job.Total != 2 {
+		t.Fatalf("job targets %d holders, want 2", job.Total)
+	}
+
+	job = waitForJob(t, tracker, job.ID)
+	if job.Processed != 2 || job.Failed != 0 {
+		t.Fatalf("job processed %d holders with %d failures", job.Processed, job.Failed)
+	}
+
+	attachments := map[string]int{}
+	for _, msg := range sender.sent() {
+		if _, ok := attachments[msg.To]; ok {
+			t.Errorf("%s was emailed more than once", msg.To)
+		}
+		attachments[msg.To] = len(msg.Attachments)
+	}
+	want := map[string]int{multiple.Email: 2, single.Email: 1}
+	if len(attachments) != len(want) {
+		t.Fatalf("emailed %v, want %v", attachments, want)
+	}
+	for email, count := range want {
+		if attachments[email] != count {
+			t.Errorf("%s got %d QR codes, want %d", email, attachments[email], count)
+		}
+	}
+}
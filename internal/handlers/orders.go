@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/logging"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// Payment states of an order. Orders left pending past their expiry are released by
+// jobs.ExpirePendingOrders.
+const (
+	orderStatusPending = "pending"
+	orderStatusPaid    = "paid"
+)
+
+// orderStatusFor is the status a new order starts in: free orders are paid, the others wait for
+// a payment step
+func orderStatusFor(total float64) string {
+	if total <= 0 {
+		return orderStatusPaid
+	}
+	return orderStatusPending
+}
+
+// reservationTTL is how long an unpaid order holds its tickets: the event's own TTL, or
+// PENDING_ORDER_TTL_MINUTES
+func reservationTTL(event models.Event) time.Duration {
+	minutes := event.ReservationTTLMinutes
+	if minutes <= 0 {
+		minutes = config.GetInt("PENDING_ORDER_TTL_MINUTES", 15)
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// paymentCurrency is the ISO currency code orders are charged in
+func paymentCurrency() string {
+	return config.GetEnv("PAYMENT_CURRENCY", "usd")
+}
+
+// errOrderNotPending is returned when an order was paid or released before its charge could be
+// recorded
+var errOrderNotPending = errors.New("order is no longer pending")
+
+// chargeOrder charges a pending order whose reservation is committed and confirms it, reporting
+// whether it succeeded. On failure it writes the response, and releases the reservation when
+// release is set; a charge that went through but could not be confirmed is refunded.
+func (h *TicketHandler) chargeOrder(w http.ResponseWriter, r *http.Request, order *models.Order, tickets []models.Ticket, token string, release bool) bool {
+	logger := logging.FromContext(r.Context())
+
+	chargeID, err := h.payments.Charge(order.Total, paymentCurrency(), token)
+	if err != nil {
+		if release {
+			h.releaseOrder(r, *order, jobs.OrderStatusFailed)
+		}
+		recordPurchaseFailure(h.db, order.EventID, order.UserID, order.Quantity, purchaseFailurePaymentFailed)
+		if errors.Is(err, payment.ErrDeclined) {
+			respondError(w, http.StatusPaymentRequired, apierror.CodePaymentDeclined, "Payment was declined")
+			return false
+		}
+		logger.Error("Failed to charge order", "order_id", order.ID, "error", err)
+		respondError(w, http.StatusBadGateway, apierror.CodePaymentFailed, "Failed to process payment")
+		return false
+	}
+
+	if err := confirmOrderPayment(h.db, order, tickets, chargeID); err != nil {
+		logger.Error("Failed to confirm paid order, refunding its charge", "order_id", order.ID, "charge_id", chargeID, "error", err)
+		if refundErr := h.payments.Refund(chargeID); refundErr != nil {
+			logger.Error("Failed to refund charge, refund it manually", "order_id", order.ID, "charge_id", chargeID, "error", refundErr)
+		}
+		if release {
+			h.releaseOrder(r, *order, jobs.OrderStatusFailed)
+		}
+		recordPurchaseFailure(h.db, order.EventID, order.UserID, order.Quantity, purchaseFailureError)
+		if err == errOrderNotPending {
+			respondError(w, http.StatusConflict, apierror.CodeOrderNotPending, "The order was released before its payment completed, the charge was refunded")
+			return false
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to record payment, the charge was refunded")
+		return false
+	}
+	return true
+}
+
+// confirmOrderPayment records the charge of a pending order and issues its held tickets. It
+// returns errOrderNotPending when the order was released or paid in the meantime.
+func confirmOrderPayment(db *gorm.DB, order *models.Order, tickets []models.Ticket, chargeID string) error {
+	tx := db.Begin()
+
+	// Only a still pending order is confirmed, so a release committed first wins
+	result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, orderStatusPending).
+		UpdateColumns(map[string]interface{}{"charge_id": chargeID, "status": orderStatusPaid})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		return errOrderNotPending
+	}
+
+	if err := tx.Model(&models.Ticket{}).Where("order_id = ? AND status = ?", order.ID, "pending").
+		UpdateColumn("status", "valid").Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	order.ChargeID, order.Status = chargeID, orderStatusPaid
+	for i := range tickets {
+		tickets[i].Status = "valid"
+	}
+	return nil
+}
+
+// PayOrderRequest represents the payment of a held order
+type PayOrderRequest struct {
+	PaymentToken string `json:"payment_token" binding:"required"`
+}
+
+// PayOrder charges an order held with a purchase made with hold set, issuing its tickets. The
+// hold is kept when the payment fails, so the buyer may retry until it expires (buyer only).
+func (h *TicketHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	orderID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid order ID")
+		return
+	}
+
+	userID, ok := r.Context().Value("user_id").(uint)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var req PayOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Preload("Tickets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id asc")
+	}).Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeOrderNotFound, "Order not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve order")
+		return
+	}
+
+	if order.Status != orderStatusPending {
+		respondError(w, http.StatusConflict, apierror.CodeOrderNotPending, "Order is "+order.Status)
+		return
+	}
+	if order.ExpiresAt == nil || !time.Now().Before(*order.ExpiresAt) {
+		respondError(w, http.StatusConflict, apierror.CodeOrderNotPending, "The order's reservation has expired")
+		return
+	}
+
+	tickets := order.Tickets
+	order.Tickets = nil
+	if !h.chargeOrder(w, r, &order, tickets, req.PaymentToken, false) {
+		return
+	}
+
+	quote, err := orderQuote(h.db, order)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve order")
+		return
+	}
+	h.queueTicketsPurchased(order, tickets)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(purchaseResponse(order, tickets, quote))
+}
+
+// releaseOrder gives back the reservation of an order whose payment failed. When the release
+// itself fails the order stays pending until the pending order sweeper expires it.
+func (h *TicketHandler) releaseOrder(r *http.Request, order models.Order, status string) {
+	if _, _, err := jobs.ReleaseOrder(h.db, order.ID, status, time.Now()); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to release order", "order_id", order.ID, "error", err)
+	}
+}
+
+// Per-ticket outcomes of a group check-in
+const (
+	checkInResultCheckedIn   = "checked_in"
+	checkInResultAlreadyUsed = "already_used"
+	checkInResultExpired     = "expired"
+	checkInResultCancelled   = "cancelled"
+	checkInResultUnpaid      = "unpaid"
+)
+
+// CheckInResult is the outcome of checking in one ticket of a group
+type CheckInResult struct {
+	TicketID uint   `json:"ticket_id"`
+	Result   string `json:"result"`
+}
+
+// CheckInOrder checks in every valid ticket of an order in one transaction, skipping tickets
+// that were already used or have expired (admin only)
+func (h *TicketHandler) CheckInOrder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	orderID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid order ID")
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ?", orderID).First(&order).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeOrderNotFound, "Order not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve order")
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the order's tickets so a concurrent single-ticket check-in cannot interleave
+	var tickets []models.Ticket
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("order_id = ?", order.ID).Order("id asc").Find(&tickets).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	now := time.Now()
+	results := make([]CheckInResult, 0, len(tickets))
+	var checkedIn []uint
+	var validated []TicketValidatedData
+	for _, ticket := range tickets {
+		switch ticket.Status {
+		case "used":
+			results = append(results, CheckInResult{TicketID: ticket.ID, Result: checkInResultAlreadyUsed})
+			continue
+		case "expired":
+			results = append(results, CheckInResult{TicketID: ticket.ID, Result: checkInResultExpired})
+			continue
+		case "cancelled":
+			results = append(results, CheckInResult{TicketID: ticket.ID, Result: checkInResultCancelled})
+			continue
+		case "pending":
+			results = append(results, CheckInResult{TicketID: ticket.ID, Result: checkInResultUnpaid})
+			continue
+		}
+
+		if err := tx.Model(&models.Ticket{}).Where("id = ?", ticket.ID).Update("status", "used").Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate tickets")
+			return
+		}
+
+		attendanceLog := models.AttendanceLog{
+			TicketID:    ticket.ID,
+			CheckedInAt: now,
+			CheckedInBy: checkedInBy(r),
+		}
+		if err := tx.Create(&attendanceLog).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create attendance log")
+			return
+		}
+
+		checkedIn = append(checkedIn, ticket.ID)
+		validated = append(validated, TicketValidatedData{TicketID: ticket.ID, EventID: ticket.EventID, UserID: ticket.UserID, CheckedInAt: now})
+		results = append(results, CheckInResult{TicketID: ticket.ID, Result: checkInResultCheckedIn})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check in order")
+		return
+	}
+
+	for _, ticketID := range checkedIn {
+		recordAudit(h.db, r, "ticket.status_changed", "ticket", ticketID, "valid -> used")
+	}
+	for _, data := range validated {
+		queueWebhookDeliveries(h.db, ticketValidatedWebhookEvent, data)
+	}
+
+	response := map[string]interface{}{
+		"message":    "Order checked in",
+		"order_id":   order.ID,
+		"checked_in": len(checkedIn),
+		"results":    results,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
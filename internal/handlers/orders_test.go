@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// holdTickets holds tickets of the event for the buyer and returns the pending order
+func holdTickets(t *testing.T, h *TicketHandler, event models.Event, buyer models.User, body string) models.Order {
+	t.Helper()
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.PurchaseTicket(w, authedRequest("POST", "/api/events/"+vars["id"]+"/purchase", body, buyer, vars))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("hold returned %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Order models.Order `json:"order"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode hold response: %v", err)
+	}
+	if response.Order.Status != orderStatusPending || response.Order.ExpiresAt == nil {
+		t.Fatalf("hold placed a %q order expiring at %v, want a pending order with an expiry", response.Order.Status, response.Order.ExpiresAt)
+	}
+	return response.Order
+}
+
+// payOrder pays the order as the user and returns the response code
+func payOrder(h *TicketHandler, order models.Order, user models.User, token string) int {
+	vars := map[string]string{"id": strconv.Itoa(int(order.ID))}
+	w := httptest.NewRecorder()
+	h.PayOrder(w, authedRequest("POST", "/api/orders/"+vars["id"]+"/pay", `{"payment_token": "`+token+`"}`, user, vars))
+	return w.Code
+}
+
+func TestExpirePendingOrdersReleasesHeldTickets(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 1, 20)
+	promo := models.PromoCode{Code: "HOLD", EventID: &event.ID, PercentOff: 10, MaxUses: 1, Active: true}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	held := holdTickets(t, h, event, buyer, `{"quantity": 1, "hold": true, "promo_code": "HOLD"}`)
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	db.Where("id = ?", promo.ID).First(&promo)
+	if stored.SoldCount != 1 || promo.UsedCount != 1 {
+		t.Fatalf("hold left sold count %d and promo uses %d, want both claimed", stored.SoldCount, promo.UsedCount)
+	}
+
+	// A pending order placed before reservations expired has no expiry and is never released
+	legacy := createTestEvent(t, db, 1, 20)
+	legacyOrder := models.Order{UserID: buyer.ID, EventID: legacy.ID, Quantity: 1, Total: legacy.Price, Status: orderStatusPending}
+	if err := db.Create(&legacyOrder).Error; err != nil {
+		t.Fatalf("create legacy order: %v", err)
+	}
+	legacyTicket := createTestTicket(t, db, legacy, buyer)
+	if err := db.Model(&models.Ticket{}).Where("id = ?", legacyTicket.ID).UpdateColumn("order_id", legacyOrder.ID).Error; err != nil {
+		t.Fatalf("attach ticket to legacy order: %v", err)
+	}
+
+	if expired, err := jobs.ExpirePendingOrders(db, held.ExpiresAt.Add(-time.Minute), nil); err != nil || expired != 0 {
+		t.Fatalf("sweep before the expiry released %d orders, err %v", expired, err)
+	}
+
+	var notified []uint
+	expired, err := jobs.ExpirePendingOrders(db, held.ExpiresAt.Add(time.Minute), func(order models.Order) {
+		notified = append(notified, order.ID)
+	})
+	if err != nil {
+		t.Fatalf("ExpirePendingOrders: %v", err)
+	}
+	if expired != 1 || len(notified) != 1 || notified[0] != held.ID {
+		t.Fatalf("expired %d orders and notified %v, want only order %d", expired, notified, held.ID)
+	}
+
+	var order models.Order
+	db.Where("id = ?", held.ID).First(&order)
+	var ticket models.Ticket
+	db.Where("order_id = ?", held.ID).First(&ticket)
+	db.Where("id = ?", event.ID).First(&stored)
+	db.Where("id = ?", promo.ID).First(&promo)
+	if order.Status != jobs.OrderStatusExpired || ticket.Status != "cancelled" || stored.SoldCount != 0 || promo.UsedCount != 0 {
+		t.Fatalf("expired order is %q with ticket %q, sold count %d and promo uses %d, want expired, cancelled, 0 and 0",
+			order.Status, ticket.Status, stored.SoldCount, promo.UsedCount)
+	}
+
+	db.Where("id = ?", legacyOrder.ID).First(&legacyOrder)
+	db.Where("id = ?", legacyTicket.ID).First(&legacyTicket)
+	if legacyOrder.Status != orderStatusPending || legacyTicket.Status != "valid" {
+		t.Fatalf("legacy order is %q with its ticket %q, want it left alone", legacyOrder.Status, legacyTicket.Status)
+	}
+
+	// The expired hold cannot be paid, and its seat and promo code use go to the next buyer
+	if code := payOrder(h, held, buyer, "tok_test"); code != http.StatusConflict {
+		t.Fatalf("paying an expired hold returned %d, want %d", code, http.StatusConflict)
+	}
+	if code := purchaseOne(h, event, createTestUser(t, db, "user"), `{"quantity": 1, "promo_code": "HOLD", "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase of the released seat returned %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestHoldExpiresAfterTheEventReservationTTL(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	t.Setenv("PENDING_ORDER_TTL_MINUTES", "15")
+
+	event := createTestEvent(t, db, 5, 20)
+	if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumn("reservation_ttl_minutes", 60).Error; err != nil {
+		t.Fatalf("set reservation TTL: %v", err)
+	}
+
+	before := time.Now()
+	held := holdTickets(t, h, event, createTestUser(t, db, "user"), `{"quantity": 1, "hold": true}`)
+	if held.ExpiresAt.Before(before.Add(59*time.Minute)) || held.ExpiresAt.After(time.Now().Add(61*time.Minute)) {
+		t.Fatalf("hold expires at %v, want about an hour after %v", held.ExpiresAt, before)
+	}
+}
+
+func TestPayOrderIssuesHeldTickets(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 2, 20)
+	held := holdTickets(t, h, event, buyer, `{"quantity": 2, "hold": true}`)
+
+	if code := payOrder(h, held, createTestUser(t, db, "user"), "tok_test"); code != http.StatusNotFound {
+		t.Fatalf("paying another user's order returned %d, want %d", code, http.StatusNotFound)
+	}
+
+	// A declined payment keeps the hold so the buyer can retry
+	if code := payOrder(h, held, buyer, payment.FakeDeclineToken); code != http.StatusPaymentRequired {
+		t.Fatalf("declined payment returned %d, want %d", code, http.StatusPaymentRequired)
+	}
+	var order models.Order
+	db.Where("id = ?", held.ID).First(&order)
+	if order.Status != orderStatusPending {
+		t.Fatalf("order is %q after a declined payment, want it still held", order.Status)
+	}
+
+	if code := payOrder(h, held, buyer, "tok_test"); code != http.StatusOK {
+		t.Fatalf("payment returned %d, want %d", code, http.StatusOK)
+	}
+	db.Where("id = ?", held.ID).First(&order)
+	var valid int
+	db.Model(&models.Ticket{}).Where("order_id = ? AND status = ?", held.ID, "valid").Count(&valid)
+	if order.Status != orderStatusPaid || order.ChargeID == "" || valid != 2 {
+		t.Fatalf("paid order is %q with charge %q and %d valid tickets, want paid with a charge and 2 tickets", order.Status, order.ChargeID, valid)
+	}
+
+	if code := payOrder(h, held, buyer, "tok_test"); code != http.StatusConflict {
+		t.Fatalf("paying a paid order returned %d, want %d", code, http.StatusConflict)
+	}
+}
+
+func TestCheckInOrderSkipsUnusableTickets(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 20)
+	if code := purchaseOne(h, event, buyer, `{"quantity": 4, "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase returned %d", code)
+	}
+
+	var tickets []models.Ticket
+	db.Where("event_id = ?", event.ID).Order("id asc").Find(&tickets)
+	if len(tickets) != 4 || tickets[0].OrderID == nil {
+		t.Fatalf("purchase created %d tickets, want 4 in one order", len(tickets))
+	}
+	db.Model(&models.Ticket{}).Where("id = ?", tickets[1].ID).UpdateColumn("status", "used")
+	db.Model(&models.Ticket{}).Where("id = ?", tickets[2].ID).UpdateColumn("status", "cancelled")
+
+	vars := map[string]string{"id": strconv.Itoa(int(*tickets[0].OrderID))}
+	w := httptest.NewRecorder()
+	h.CheckInOrder(w, authedRequest("POST", "/api/orders/"+vars["id"]+"/checkin", "", admin, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("order check-in returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		CheckedIn int             `json:"checked_in"`
+		Results   []CheckInResult `json:"results"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode check-in response: %v", err)
+	}
+
+	want := []string{checkInResultCheckedIn, checkInResultAlreadyUsed, checkInResultCancelled, checkInResultCheckedIn}
+	if response.CheckedIn != 2 || len(response.Results) != len(want) {
+		t.Fatalf("order check-in is %+v, want 2 of 4 tickets checked in", response)
+	}
+	for i, result := range response.Results {
+		if result.TicketID != tickets[i].ID || result.Result != want[i] {
+			t.Errorf("result %d is %+v, want %q for ticket %d", i, result, want[i], tickets[i].ID)
+		}
+		var logs int
+		db.Model(&models.AttendanceLog{}).Where("ticket_id = ?", tickets[i].ID).Count(&logs)
+		if (logs == 1) != (want[i] == checkInResultCheckedIn) {
+			t.Errorf("ticket %d has %d attendance logs", tickets[i].ID, logs)
+		}
+	}
+
+	var statuses []string
+	db.Model(&models.Ticket{}).Where("event_id = ?", event.ID).Order("id asc").Pluck("status", &statuses)
+	if len(statuses) != 4 || statuses[0] != "used" || statuses[2] != "cancelled" || statuses[3] != "used" {
+		t.Fatalf("ticket statuses after order check-in are %v", statuses)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// organizerEventsExportHeader is the header row of the organizer events summary export
+var organizerEventsExportHeader = []string{"Event ID", "Title", "Date", "Capacity", "Sold", "Checked In", "Revenue"}
+
+// organizerEventsExportRow assembles the summary export row for an event
+func organizerEventsExportRow(event models.Event, sales EventSales) []string {
+	capacity := fmt.Sprintf("%d", event.Capacity)
+	if event.Unlimited {
+		capacity = "Unlimited"
+	}
+
+	return []string{
+		fmt.Sprintf("%d", event.ID),
+		sanitizeExportCell(event.Title),
+		event.Date.Format("2006-01-02 15:04:05"),
+		capacity,
+		fmt.Sprintf("%d", sales.Sold),
+		fmt.Sprintf("%d", sales.CheckedIn),
+		fmt.Sprintf("%.2f", sales.Revenue),
+	}
+}
+
+// GetOrganizerTickets retrieves tickets across the current organizer's events, filtered by
+// status, event and last update time, paginated. Admins see tickets for all events.
+func (h *TicketHandler) GetOrganizerTickets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	// Attendee and event details are included unless the caller narrows the expansion
+	expand, err := parseExpand(r, ticketExpansions, "event,user")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	params := r.URL.Query()
+	query := h.db.Model(&models.Ticket{}).Joins("JOIN events ON events.id = tickets.event_id")
+
+	if r.Context().Value("user_role") != "admin" {
+		// Organizers only see tickets for their own events
+		query = query.Where("events.organizer_id = ?", userID)
+	}
+
+	if status := params.Get("status"); status != "" {
+		query = query.Where("tickets.status = ?", status)
+	}
+
+	if value := params.Get("event_id"); value != "" {
+		eventID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+			return
+		}
+		query = query.Where("tickets.event_id = ?", eventID)
+	}
+
+	if value := params.Get("from"); value != "" {
+		from, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid from date")
+			return
+		}
+		query = query.Where("tickets.updated_at >= ?", from)
+	}
+
+	if value := params.Get("to"); value != "" {
+		to, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid to date")
+			return
+		}
+		query = query.Where("tickets.updated_at < ?", to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	var tickets []models.Ticket
+	if err := preloadExpansions(query.Select("tickets.*"), expand, ticketExpansions).
+		Order(stableOrder("tickets.updated_at desc", "tickets.id")).Offset(page.Offset()).Limit(page.PerPage).
+		Find(&tickets).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	response := PaginatedResponse{
+		Data:    newTicketResponses(tickets, expand),
+		Page:    page.Page,
+		PerPage: page.PerPage,
+		Total:   total,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ExportOrganizerEvents exports a one row per event sales summary of the current organizer's
+// events as CSV or XLSX. Admins export every event.
+func (h *EventHandler) ExportOrganizerEvents(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	exportFormat := r.URL.Query().Get("format")
+	if exportFormat == "" {
+		exportFormat = "csv"
+	}
+	if exportFormat != "csv" && exportFormat != "xlsx" {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Format must be csv or xlsx")
+		return
+	}
+
+	query := h.db.Order("date asc, id asc")
+	if r.Context().Value("user_role") != "admin" {
+		query = query.Where("organizer_id = ?", userID)
+	}
+
+	var events []models.Event
+	if err := query.Find(&events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve events")
+		return
+	}
+
+	eventIDs := make([]uint, len(events))
+	for i, event := range events {
+		eventIDs[i] = event.ID
+	}
+	sales, err := eventSales(h.db, eventIDs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute event sales")
+		return
+	}
+
+	rows := [][]string{organizerEventsExportHeader}
+	for _, event := range events {
+		rows = append(rows, organizerEventsExportRow(event, sales[event.ID]))
+	}
+
+	if exportFormat == "xlsx" {
+		file := excelize.NewFile()
+		defer file.Close()
+
+		sheet := file.GetSheetName(0)
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+			}
+			if err := file.SetSheetRow(sheet, cell, &values); err != nil {
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to build export")
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", "attachment;filename=events_summary.xlsx")
+		file.Write(w)
+		return
+	}
+
+	// Set CSV headers
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment;filename=events_summary.csv")
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+	writer.WriteAll(rows)
+}
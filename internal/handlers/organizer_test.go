@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// organizerTickets lists the tickets visible to the user at target
+func organizerTickets(t *testing.T, h *TicketHandler, user models.User, target string) []TicketResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.GetOrganizerTickets(w, authedRequest("GET", target, "", user, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s returned %d: %s", target, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data  []TicketResponse `json:"data"`
+		Total int64            `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode tickets: %v", err)
+	}
+	if int(response.Total) != len(response.Data) {
+		t.Fatalf("total %d does not match the %d tickets returned", response.Total, len(response.Data))
+	}
+	return response.Data
+}
+
+func TestGetOrganizerTicketsOnlyListsOwnEvents(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	organizer := createTestUser(t, db, "organizer")
+	other := createTestUser(t, db, "organizer")
+	attendee := createTestUser(t, db, "user")
+
+	own := createTestEvent(t, db, 10, 20)
+	foreign := createTestEvent(t, db, 10, 20)
+	db.Model(&models.Event{}).Where("id = ?", own.ID).UpdateColumn("organizer_id", organizer.ID)
+	db.Model(&models.Event{}).Where("id = ?", foreign.ID).UpdateColumn("organizer_id", other.ID)
+
+	createTestTicket(t, db, own, attendee)
+	used := createTestTicket(t, db, own, attendee)
+	db.Model(&models.Ticket{}).Where("id = ?", used.ID).UpdateColumn("status", "used")
+	createTestTicket(t, db, foreign, attendee)
+
+	tickets := organizerTickets(t, h, organizer, "/api/organizer/tickets")
+	if len(tickets) != 2 {
+		t.Fatalf("organizer sees %d tickets, want the 2 of their event", len(tickets))
+	}
+	for _, ticket := range tickets {
+		if ticket.EventID != own.ID {
+			t.Errorf("organizer sees ticket %d of event %d", ticket.ID, ticket.EventID)
+		}
+		if ticket.Event == nil || ticket.User == nil || ticket.User.ID != attendee.ID {
+			t.Errorf("ticket %d is missing its event or attendee details", ticket.ID)
+		}
+	}
+
+	// Filtering on another organizer's event does not widen the scope
+	if tickets := organizerTickets(t, h, organizer, "/api/organizer/tickets?event_id="+strconv.Itoa(int(foreign.ID))); len(tickets) != 0 {
+		t.Fatalf("organizer sees %d tickets of another organizer's event", len(tickets))
+	}
+	if tickets := organizerTickets(t, h, organizer, "/api/organizer/tickets?status=used"); len(tickets) != 1 || tickets[0].ID != used.ID {
+		t.Fatalf("status filter returned %d tickets, want the used one", len(tickets))
+	}
+
+	if tickets := organizerTickets(t, h, admin, "/api/organizer/tickets"); len(tickets) != 3 {
+		t.Fatalf("admin sees %d tickets, want all 3", len(tickets))
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// pagination holds the page requested through ?page= and ?per_page=
+type pagination struct {
+	Page    int
+	PerPage int
+}
+
+// Offset returns the number of rows to skip for the page
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// PaginatedResponse wraps a page of results with its position in the full result set
+type PaginatedResponse struct {
+	Data    interface{} `json:"data"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Total   int64       `json:"total"`
+}
+
+// parsePagination reads ?page= and ?per_page=, defaulting to the first page and capping the page size
+func parsePagination(r *http.Request) (pagination, error) {
+	p := pagination{Page: 1, PerPage: defaultPerPage}
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return p, errors.New("invalid page")
+		}
+		p.Page = page
+	}
+
+	if value := r.URL.Query().Get("per_page"); value != "" {
+		perPage, err := strconv.Atoi(value)
+		if err != nil || perPage < 1 {
+			return p, errors.New("invalid per_page")
+		}
+		if perPage > maxPerPage {
+			perPage = maxPerPage
+		}
+		p.PerPage = perPage
+	}
+
+	return p, nil
+}
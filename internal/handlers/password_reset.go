@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/logging"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/mailer"
+
+	"github.com/jinzhu/gorm"
+)
+
+// passwordResetTokenTTL is how long password reset links stay valid
+const passwordResetTokenTTL = time.Hour
+
+// minPasswordLength matches the minimum enforced on registration
+const minPasswordLength = 6
+
+// ForgotPasswordRequest represents the forgot password request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the reset password request payload
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// issuePasswordResetToken invalidates any outstanding reset tokens for the user, stores a new
+// one and returns the raw token to be emailed
+func issuePasswordResetToken(db *gorm.DB, user models.User, now time.Time) (string, error) {
+	token, err := auth.GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx := db.Begin()
+	if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	record := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: now.Add(passwordResetTokenTTL),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendPasswordResetEmail issues a new reset token and emails it to the user
+func (h *AuthHandler) sendPasswordResetEmail(user models.User) error {
+	now := time.Now()
+	token, err := issuePasswordResetToken(h.db, user, now)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.GetEnv("APP_BASE_URL", "http://localhost:8000"), token)
+	return h.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nA password reset was requested for your account. Open the link below to choose a new password:\n\n%s\n\nYour reset token is %s. It can be used once and expires on %s.\n\nIf you did not request a reset, you can ignore this email.\n",
+			user.Name, link, token, format.Date(now.Add(passwordResetTokenTTL))),
+	})
+}
+
+// ForgotPassword emails a password reset token to the owner of an account. The response is
+// the same whether or not the email belongs to an account to avoid user enumeration.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
+		return
+	}
+
+	var req ForgotPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email != "" && h.resetLimiter.allow(email, verificationResendInterval(), time.Now()) {
+		var user models.User
+		if err := h.db.Where("LOWER(email) = ?", email).First(&user).Error; err == nil {
+			if err := h.sendPasswordResetEmail(user); err != nil {
+				logging.FromContext(r.Context()).Error("Failed to send password reset email", "user_id", user.ID, "error", err)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the email belongs to an account, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password for the user owning a valid reset token. The token is
+// consumed, and the user's refresh tokens are revoked so other sessions cannot be renewed.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Reset token required")
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+		return
+	}
+
+	var record models.PasswordResetToken
+	if err := h.db.Where("token_hash = ? AND used_at IS NULL", auth.HashToken(req.Token)).First(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidToken, "Invalid or expired reset token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reset password")
+		return
+	}
+
+	now := time.Now()
+	if now.After(record.ExpiresAt) {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidToken, "Invalid or expired reset token")
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to hash password")
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Consume the token conditionally so two concurrent resets cannot both use it
+	consume := tx.Model(&models.PasswordResetToken{}).Where("id = ? AND used_at IS NULL", record.ID).Update("used_at", now)
+	if consume.Error != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reset password")
+		return
+	}
+	if consume.RowsAffected == 0 {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidToken, "Invalid or expired reset token")
+		return
+	}
+
+	// UpdateColumns skips the BeforeUpdate hook, which would hash the hashed password again
+	if err := tx.Model(&models.User{}).Where("id = ?", record.UserID).
+		UpdateColumns(map[string]interface{}{"password": hashedPassword, "updated_at": now}).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reset password")
+		return
+	}
+	if err := auth.RevokeUserRefreshTokens(tx, record.UserID); err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reset password")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reset password")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password reset successfully"})
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"event-ticketing-system/internal/models"
+)
+
+// canManageEvent reports whether the current user is an admin or the event's organizer
+func canManageEvent(r *http.Request, event models.Event) bool {
+	if r.Context().Value("user_role") == "admin" {
+		return true
+	}
+
+	userID, ok := r.Context().Value("user_id").(uint)
+	return ok && event.OrganizerID != 0 && event.OrganizerID == userID
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// maxTicketsPerPurchase caps the number of tickets bought in one purchase
+const maxTicketsPerPurchase = 10
+
+// errInvalidPromoCode is returned for promo codes that cannot be applied to a purchase
+var errInvalidPromoCode = errors.New("invalid promo code")
+
+// QuoteRequest represents the price quote request payload
+type QuoteRequest struct {
+	Quantity     int    `json:"quantity" binding:"required,min=1,max=10"`
+	TicketTypeID *uint  `json:"ticket_type_id"`
+	PromoCode    string `json:"promo_code"`
+}
+
+// PriceQuote is the price breakdown of a purchase
+type PriceQuote struct {
+	EventID      uint    `json:"event_id"`
+	TicketTypeID *uint   `json:"ticket_type_id,omitempty"`
+	Quantity     int     `json:"quantity"`
+	UnitPrice    float64 `json:"unit_price"`
+	PromoCode    string  `json:"promo_code,omitempty"`
+	ReceiptTotals
+}
+
+// findPromoCode looks up a promo code for a purchase of the event, matching the code
+// case-insensitively. It returns nil for an empty code and errInvalidPromoCode for codes that do
+// not exist, belong to another event, are inactive, expired or used up. The usage cap is
+// checked again when the purchase claims a use.
+func findPromoCode(db *gorm.DB, eventID uint, code string, now time.Time) (*models.PromoCode, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, nil
+	}
+
+	var promo models.PromoCode
+	if err := db.Where("code = ?", code).First(&promo).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, errInvalidPromoCode
+		}
+		return nil, err
+	}
+
+	if !promo.Active || (promo.EventID != nil && *promo.EventID != eventID) ||
+		(promo.ExpiresAt != nil && !now.Before(*promo.ExpiresAt)) ||
+		(promo.MaxUses > 0 && promo.UsedCount >= promo.MaxUses) {
+		return nil, errInvalidPromoCode
+	}
+	return &promo, nil
+}
+
+// claimPromoCodeUse atomically counts one use of a promo code if it is still under its usage
+// cap, reporting whether the use was claimed. Call it inside the purchase transaction.
+func claimPromoCodeUse(tx *gorm.DB, promoID uint) (bool, error) {
+	result := tx.Model(&models.PromoCode{}).
+		Where("id = ? AND active AND (max_uses = 0 OR used_count < max_uses)", promoID).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// promoDiscount is the discount a promo code gives on a subtotal: a percentage of it or a
+// fixed amount per order, never more than the subtotal
+func promoDiscount(promo *models.PromoCode, subtotal float64) float64 {
+	if promo == nil {
+		return 0
+	}
+	discount := promo.AmountOff
+	if promo.PercentOff > 0 {
+		discount = subtotal * promo.PercentOff / 100
+	}
+	return roundCents(math.Min(discount, subtotal))
+}
+
+// quotePurchase prices a purchase of quantity tickets of an event, at the tier price when a
+// ticket type is given and the event price otherwise. Purchases are charged from this quote so
+// the preview and the realized price always agree.
+func quotePurchase(event models.Event, ticketType *models.TicketType, quantity int, promo *models.PromoCode, rate float64) (PriceQuote, error) {
+	if quantity < 1 || quantity > maxTicketsPerPurchase {
+		return PriceQuote{}, fmt.Errorf("quantity must be between 1 and %d", maxTicketsPerPurchase)
+	}
+
+	quote := PriceQuote{
+		EventID:   event.ID,
+		Quantity:  quantity,
+		UnitPrice: event.Price,
+	}
+	if ticketType != nil {
+		quote.TicketTypeID = &ticketType.ID
+		quote.UnitPrice = ticketType.Price
+	}
+
+	if promo != nil {
+		quote.PromoCode = promo.Code
+	}
+
+	subtotal := roundCents(float64(quantity) * quote.UnitPrice)
+	lines := []ReceiptLine{{Quantity: quantity, UnitPrice: quote.UnitPrice, Amount: subtotal}}
+	quote.ReceiptTotals = calculateReceiptTotals(lines, promoDiscount(promo, subtotal), rate)
+	return quote, nil
+}
+
+// QuotePurchase previews the price of a purchase, including the tier price, discount and tax,
+// without creating tickets or reserving capacity
+func (h *TicketHandler) QuotePurchase(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var req QuoteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if event.Status == "cancelled" {
+		respondError(w, http.StatusBadRequest, apierror.CodeEventCancelled, "Cannot purchase tickets for cancelled events")
+		return
+	}
+	if event.Date.Before(time.Now()) {
+		respondError(w, http.StatusBadRequest, apierror.CodeEventPast, "Cannot purchase tickets for past events")
+		return
+	}
+
+	ticketType, err := resolveTicketType(h.db, event.ID, req.TicketTypeID)
+	if err != nil {
+		if err == errTicketTypeRequired {
+			respondError(w, http.StatusBadRequest, apierror.CodeTicketTypeRequired, err.Error())
+			return
+		}
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketTypeNotFound, "Ticket type not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket type")
+		return
+	}
+
+	promo, err := findPromoCode(h.db, event.ID, req.PromoCode, time.Now())
+	if err != nil {
+		if err == errInvalidPromoCode {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidPromoCode, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve promo code")
+		return
+	}
+
+	quote, err := quotePurchase(event, ticketType, req.Quantity, promo, taxRate())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
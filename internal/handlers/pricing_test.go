@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestQuotePurchase(t *testing.T) {
+	event := models.Event{ID: 3, Price: 20}
+	vip := &models.TicketType{ID: 9, Price: 45.5}
+
+	tests := []struct {
+		name       string
+		ticketType *models.TicketType
+		quantity   int
+		promo      *models.PromoCode
+		rate       float64
+		wantUnit   float64
+		want       ReceiptTotals
+		wantErr    bool
+	}{
+		{name: "event price", quantity: 2, wantUnit: 20, want: ReceiptTotals{Subtotal: 40, Total: 40}},
+		{name: "ticket type price", ticketType: vip, quantity: 3, wantUnit: 45.5, want: ReceiptTotals{Subtotal: 136.5, Total: 136.5}},
+		{
+			name:     "percentage promo",
+			quantity: 4,
+			promo:    &models.PromoCode{Code: "TEN", PercentOff: 10},
+			wantUnit: 20,
+			want:     ReceiptTotals{Subtotal: 80, Discount: 8, Total: 72},
+		},
+		{
+			name:     "fixed promo is capped at the subtotal",
+			quantity: 1,
+			promo:    &models.PromoCode{Code: "BIG", AmountOff: 50},
+			wantUnit: 20,
+			want:     ReceiptTotals{Subtotal: 20, Discount: 20, Total: 0},
+		},
+		{
+			name:     "tax applies after the discount",
+			quantity: 3,
+			promo:    &models.PromoCode{Code: "FIVE", AmountOff: 5},
+			rate:     8.25,
+			wantUnit: 20,
+			want:     ReceiptTotals{Subtotal: 60, Discount: 5, TaxRate: 8.25, Tax: 4.54, Total: 59.54},
+		},
+		{name: "no tickets", quantity: 0, wantErr: true},
+		{name: "too many tickets", quantity: maxTicketsPerPurchase + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := quotePurchase(event, tt.ticketType, tt.quantity, tt.promo, tt.rate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", quote)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if quote.UnitPrice != tt.wantUnit || quote.Quantity != tt.quantity {
+				t.Errorf("quote is %d at %v, want %d at %v", quote.Quantity, quote.UnitPrice, tt.quantity, tt.wantUnit)
+			}
+			if quote.ReceiptTotals != tt.want {
+				t.Errorf("totals = %+v, want %+v", quote.ReceiptTotals, tt.want)
+			}
+			if tt.ticketType != nil && (quote.TicketTypeID == nil || *quote.TicketTypeID != tt.ticketType.ID) {
+				t.Errorf("quote ticket type = %v, want %d", quote.TicketTypeID, tt.ticketType.ID)
+			}
+			if tt.promo != nil && quote.PromoCode != tt.promo.Code {
+				t.Errorf("quote promo code = %q, want %q", quote.PromoCode, tt.promo.Code)
+			}
+		})
+	}
+}
+
+func TestQuoteMatchesRealizedPurchase(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	t.Setenv("TAX_RATE", "7.5")
+	event := createTestEvent(t, db, 20, 10)
+	vip := models.TicketType{EventID: event.ID, Name: "VIP", Price: 33.33, Capacity: 10}
+	if err := db.Create(&vip).Error; err != nil {
+		t.Fatalf("create ticket type: %v", err)
+	}
+	promo := models.PromoCode{Code: "QUOTE", EventID: &event.ID, PercentOff: 15, Active: true}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	body := fmt.Sprintf(`{"quantity": 3, "ticket_type_id": %d, "promo_code": "quote"`, vip.ID)
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+
+	w := httptest.NewRecorder()
+	h.QuotePurchase(w, authedRequest("POST", "/api/events/"+vars["id"]+"/quote", body+"}", createTestUser(t, db, "user"), vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("quote returned %d: %s", w.Code, w.Body.String())
+	}
+	var quote PriceQuote
+	if err := json.NewDecoder(w.Body).Decode(&quote); err != nil {
+		t.Fatalf("decode quote: %v", err)
+	}
+
+	// Quoting reserves nothing
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	db.Where("id = ?", promo.ID).First(&promo)
+	if stored.SoldCount != 0 || promo.UsedCount != 0 {
+		t.Fatalf("quote left sold count %d and promo uses %d, want neither claimed", stored.SoldCount, promo.UsedCount)
+	}
+
+	w = httptest.NewRecorder()
+	h.PurchaseTicket(w, authedRequest("POST", "/api/events/"+vars["id"]+"/purchase", body+`, "payment_token": "tok_test"}`, createTestUser(t, db, "user"), vars))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("purchase returned %d: %s", w.Code, w.Body.String())
+	}
+	var purchase struct {
+		Order   models.Order    `json:"order"`
+		Tickets []models.Ticket `json:"tickets"`
+		Pricing PriceQuote      `json:"pricing"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&purchase); err != nil {
+		t.Fatalf("decode purchase: %v", err)
+	}
+
+	priced := purchase.Pricing
+	if priced.ReceiptTotals != quote.ReceiptTotals || priced.UnitPrice != quote.UnitPrice || priced.Quantity != quote.Quantity || priced.PromoCode != quote.PromoCode {
+		t.Fatalf("purchase priced %+v, quoted %+v", purchase.Pricing, quote)
+	}
+	if purchase.Order.Total != quote.Total {
+		t.Fatalf("order total %v, quoted %v", purchase.Order.Total, quote.Total)
+	}
+	var charged float64
+	for _, ticket := range purchase.Tickets {
+		charged += ticket.AmountCharged
+	}
+	if roundCents(charged) != quote.Total {
+		t.Fatalf("tickets were charged %v in all, quoted %v", roundCents(charged), quote.Total)
+	}
+}
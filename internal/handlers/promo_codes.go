@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// promoCodePattern limits promo codes to letters, digits, dashes and underscores
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// CreatePromoCodeRequest represents the create promo code request payload. Exactly one of
+// percent_off and amount_off is set; codes without an event_id apply to every event.
+type CreatePromoCodeRequest struct {
+	Code       string     `json:"code" binding:"required"`
+	EventID    *uint      `json:"event_id"`
+	PercentOff float64    `json:"percent_off" binding:"min=0,max=100"`
+	AmountOff  float64    `json:"amount_off" binding:"min=0"`
+	MaxUses    int        `json:"max_uses" binding:"min=0"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// CreatePromoCode creates a promo code for one of the caller's events. Only admins can create
+// codes that apply to every event.
+func (h *TicketHandler) CreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreatePromoCodeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !promoCodePattern.MatchString(code) {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Code must be 3 to 32 letters, digits, dashes or underscores")
+		return
+	}
+	if (req.PercentOff > 0) == (req.AmountOff > 0) {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Exactly one of percent_off and amount_off must be set")
+		return
+	}
+	if req.PercentOff < 0 || req.PercentOff > 100 || req.AmountOff < 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "percent_off must be between 0 and 100 and amount_off at least 0")
+		return
+	}
+	if req.MaxUses < 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "max_uses must be at least 0")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "expires_at must be in the future")
+		return
+	}
+
+	if req.EventID == nil {
+		if r.Context().Value("user_role") != "admin" {
+			respondError(w, http.StatusForbidden, apierror.CodeForbidden, "Only admins can create promo codes for every event")
+			return
+		}
+	} else {
+		var event models.Event
+		if err := h.db.Where("id = ?", *req.EventID).First(&event).Error; err != nil {
+			if gorm.IsRecordNotFoundError(err) {
+				respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+			return
+		}
+		if !canManageEvent(r, event) {
+			respondError(w, http.StatusForbidden, apierror.CodeForbidden, "You can only create promo codes for your own events")
+			return
+		}
+	}
+
+	var existing int64
+	if err := h.db.Model(&models.PromoCode{}).Where("code = ?", code).Count(&existing).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create promo code")
+		return
+	}
+	if existing > 0 {
+		respondError(w, http.StatusConflict, apierror.CodeAlreadyExists, "Promo code already exists")
+		return
+	}
+
+	userID, _ := r.Context().Value("user_id").(uint)
+	promo := models.PromoCode{
+		Code:       code,
+		EventID:    req.EventID,
+		PercentOff: req.PercentOff,
+		AmountOff:  roundCents(req.AmountOff),
+		MaxUses:    req.MaxUses,
+		Active:     true,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  userID,
+	}
+	if err := h.db.Create(&promo).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create promo code")
+		return
+	}
+
+	recordAudit(h.db, r, "promo_code.created", "promo_code", promo.ID, promo.Code)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(promo)
+}
+
+// GetPromoCodes lists promo codes, newest first: every code for admins, and the codes of
+// their own events for organizers
+func (h *TicketHandler) GetPromoCodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	page, err := parsePagination(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	query := h.db.Model(&models.PromoCode{})
+	if r.Context().Value("user_role") != "admin" {
+		userID, _ := r.Context().Value("user_id").(uint)
+		query = query.Where("event_id IN (SELECT id FROM events WHERE organizer_id = ?)", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve promo codes")
+		return
+	}
+
+	promos := []models.PromoCode{}
+	if err := query.Order(stableOrder("created_at desc", "id")).Offset(page.Offset()).Limit(page.PerPage).
+		Find(&promos).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve promo codes")
+		return
+	}
+
+	response := PaginatedResponse{
+		Data:    promos,
+		Page:    page.Page,
+		PerPage: page.PerPage,
+		Total:   total,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// Reasons recorded for failed purchase attempts
+const (
+	purchaseFailureSoldOut        = "sold_out"
+	purchaseFailurePastEvent      = "past_event"
+	purchaseFailureEventCancelled = "event_cancelled"
+	purchaseFailurePaymentFailed  = "payment_failed"
+	purchaseFailureUserLimit      = "user_limit"
+	purchaseFailureError          = "error"
+)
+
+// PurchaseFailureSummary summarizes the failed purchase attempts for an event
+type PurchaseFailureSummary struct {
+	EventID      uint             `json:"event_id"`
+	Total        int64            `json:"total"`
+	ByReason     map[string]int64 `json:"by_reason"`
+	LastFailedAt *time.Time       `json:"last_failed_at"`
+}
+
+// recordPurchaseFailure stores a failed purchase attempt and logs an alert when an event's
+// failures within the alert window reach the configured threshold. Failures are logged
+// rather than returned so recording never changes the purchase response.
+func recordPurchaseFailure(db *gorm.DB, eventID, userID uint, quantity int, reason string) {
+	if config.GetEnv("PURCHASE_FAILURE_LOGGING", "true") == "false" {
+		return
+	}
+
+	failure := models.PurchaseFailure{
+		EventID:  eventID,
+		UserID:   userID,
+		Quantity: quantity,
+		Reason:   reason,
+	}
+	if err := db.Create(&failure).Error; err != nil {
+		log.Printf("Failed to record purchase failure for event %d: %v", eventID, err)
+		return
+	}
+
+	threshold := config.GetInt("PURCHASE_FAILURE_ALERT_THRESHOLD", 0)
+	if threshold <= 0 {
+		return
+	}
+
+	window := time.Duration(config.GetInt("PURCHASE_FAILURE_ALERT_WINDOW_MINUTES", 60)) * time.Minute
+	var recent int64
+	if err := db.Model(&models.PurchaseFailure{}).
+		Where("event_id = ? AND created_at >= ?", eventID, time.Now().Add(-window)).
+		Count(&recent).Error; err != nil {
+		log.Printf("Failed to count purchase failures for event %d: %v", eventID, err)
+		return
+	}
+
+	// Alert once, when the threshold is crossed, rather than on every later failure
+	if recent == int64(threshold) {
+		log.Printf("ALERT: event %d has %d failed purchase attempts in the last %s (latest reason: %s)", eventID, recent, window, reason)
+	}
+}
+
+// GetPurchaseFailures summarizes the failed purchase attempts for an event (organizer of the event or admin)
+func (h *TicketHandler) GetPurchaseFailures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if !canManageEvent(r, event) {
+		respondError(w, http.StatusForbidden, apierror.CodeForbidden, "You do not manage this event")
+		return
+	}
+
+	var rows []struct {
+		Reason string
+		Count  int64
+		Last   time.Time
+	}
+	if err := h.db.Model(&models.PurchaseFailure{}).
+		Select("reason, COUNT(*) AS count, MAX(created_at) AS last").
+		Where("event_id = ?", event.ID).
+		Group("reason").Scan(&rows).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve purchase failures")
+		return
+	}
+
+	summary := PurchaseFailureSummary{EventID: event.ID, ByReason: map[string]int64{}}
+	for _, row := range rows {
+		summary.Total += row.Count
+		summary.ByReason[row.Reason] = row.Count
+		if last := row.Last; summary.LastFailedAt == nil || last.After(*summary.LastFailedAt) {
+			summary.LastFailedAt = &last
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
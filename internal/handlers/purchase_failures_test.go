@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestSoldOutPurchaseIsRecorded(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 1, 20)
+	createTestTicket(t, db, event, createTestUser(t, db, "user"))
+
+	if code := purchaseOne(h, event, buyer, `{"quantity": 2, "payment_token": "tok_test"}`); code == http.StatusCreated {
+		t.Fatal("purchase of a sold out event succeeded")
+	}
+
+	var failures []models.PurchaseFailure
+	db.Where("event_id = ?", event.ID).Find(&failures)
+	if len(failures) != 1 {
+		t.Fatalf("recorded %d purchase failures, want 1", len(failures))
+	}
+	if f := failures[0]; f.Reason != purchaseFailureSoldOut || f.UserID != buyer.ID || f.Quantity != 2 {
+		t.Fatalf("recorded failure %+v, want a sold out attempt for 2 tickets by user %d", f, buyer.ID)
+	}
+
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.GetPurchaseFailures(w, authedRequest("GET", "/api/events/"+vars["id"]+"/purchase-failures", "", admin, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("purchase failure summary returned %d: %s", w.Code, w.Body.String())
+	}
+	var summary PurchaseFailureSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if summary.Total != 1 || summary.ByReason[purchaseFailureSoldOut] != 1 || summary.LastFailedAt == nil {
+		t.Fatalf("summary is %+v, want one sold out failure", summary)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// purchaseBucketIdle is how long an event's bucket may go unused before it is dropped; a
+// bucket idle for longer than a second is full again anyway
+const purchaseBucketIdle = time.Minute
+
+// purchaseBucket holds the purchase tokens left for one event
+type purchaseBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// purchaseRateLimiter is a token bucket per event that admits at most the event's
+// purchase_rate_limit purchases per second across all users, with bursts of up to one
+// second's worth
+type purchaseRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[uint]*purchaseBucket
+}
+
+func newPurchaseRateLimiter() *purchaseRateLimiter {
+	return &purchaseRateLimiter{buckets: make(map[uint]*purchaseBucket)}
+}
+
+// allow reports whether a purchase of the event may go ahead now given its rate in purchases
+// per second. When it may not, the returned duration is how long until one would be admitted.
+func (l *purchaseRateLimiter) allow(eventID uint, rate int, now time.Time) (bool, time.Duration) {
+	if rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop idle buckets so the map does not grow without bound
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= purchaseBucketIdle {
+			delete(l.buckets, key)
+		}
+	}
+
+	bucket, ok := l.buckets[eventID]
+	if !ok {
+		bucket = &purchaseBucket{tokens: float64(rate), last: now}
+		l.buckets[eventID] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(rate), bucket.tokens+now.Sub(bucket.last).Seconds()*float64(rate))
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / float64(rate) * float64(time.Second))
+}
+
+// retryAfterSeconds rounds a wait up to the whole seconds of a Retry-After header
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
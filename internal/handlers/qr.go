@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/database"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// qrImageSize is the width and height in pixels of served QR images
+const qrImageSize = 256
+
+// Width and height in pixels of served barcode images
+const (
+	barcodeImageWidth  = 400
+	barcodeImageHeight = 120
+)
+
+// barcodesEnabled reports whether new tickets carry a 1D barcode alongside the QR code
+func barcodesEnabled() bool {
+	return config.GetEnv("TICKET_BARCODES", "false") == "true"
+}
+
+// generateQRPayload is the payload generator used for new tickets, replaceable in tests.
+// Payloads are signed with QR_SIGNING_SECRET.
+var generateQRPayload = func(eventID uint, userID uint, ticketID uint) string {
+	return utils.GenerateQRCode(eventID, userID, ticketID, qrSigningSecret())
+}
+
+// verifyQRPayload rejects a scanned payload whose signature does not check out under
+// QR_SIGNING_SECRET, and returns the ticket it was issued for
+func verifyQRPayload(payload string) (utils.QRClaims, error) {
+	return utils.ValidateQRCode(payload, qrSigningSecret())
+}
+
+// generateBarcodeValue is the barcode generator used for new tickets, replaceable in tests
+var generateBarcodeValue = utils.GenerateBarcodeValue
+
+var errQRPayloadExhausted = errors.New("could not generate a unique QR payload")
+
+// ticketHolderID returns the ID of the ticket's holder, 0 for unassigned tickets
+func ticketHolderID(ticket models.Ticket) uint {
+	if ticket.UserID == nil {
+		return 0
+	}
+	return *ticket.UserID
+}
+
+// createTicketWithUniqueQR assigns a fresh QR payload, and barcode when enabled, to the ticket
+// and inserts it, retrying with new codes when the insert hits a unique constraint
+func createTicketWithUniqueQR(db *gorm.DB, ticket *models.Ticket) error {
+	return insertTicketWithUniqueQR(db, ticket, false)
+}
+
+// createTicketWithUniqueQRInTx is createTicketWithUniqueQR for use inside a transaction. Each
+// attempt runs in a savepoint so a collision does not abort the surrounding transaction.
+func createTicketWithUniqueQRInTx(tx *gorm.DB, ticket *models.Ticket) error {
+	return insertTicketWithUniqueQR(tx, ticket, true)
+}
+
+func insertTicketWithUniqueQR(db *gorm.DB, ticket *models.Ticket, savepoint bool) error {
+	attempts := config.GetInt("QR_PAYLOAD_MAX_ATTEMPTS", 5)
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// The payload names the ticket, so its ID is taken from the sequence before the insert
+	if ticket.ID == 0 {
+		id, err := nextTicketID(db)
+		if err != nil {
+			return err
+		}
+		ticket.ID = id
+	}
+
+	for i := 0; i < attempts; i++ {
+		ticket.QRCode = generateQRPayload(ticket.EventID, ticketHolderID(*ticket), ticket.ID)
+		if barcodesEnabled() {
+			value, err := generateBarcodeValue()
+			if err != nil {
+				return err
+			}
+			ticket.Barcode = &value
+		}
+
+		if savepoint {
+			if err := db.Exec("SAVEPOINT ticket_qr").Error; err != nil {
+				return err
+			}
+		}
+
+		err := db.Create(ticket).Error
+		if err == nil {
+			if savepoint {
+				return db.Exec("RELEASE SAVEPOINT ticket_qr").Error
+			}
+			return nil
+		}
+
+		if savepoint {
+			if rollbackErr := db.Exec("ROLLBACK TO SAVEPOINT ticket_qr").Error; rollbackErr != nil {
+				return rollbackErr
+			}
+		}
+		if !database.IsUniqueViolation(err) {
+			return err
+		}
+	}
+
+	return errQRPayloadExhausted
+}
+
+// nextTicketID reserves the next ID of the tickets table
+func nextTicketID(db *gorm.DB) (uint, error) {
+	var next struct {
+		ID uint
+	}
+	if err := db.Raw("SELECT nextval(pg_get_serial_sequence('tickets', 'id')) AS id").Scan(&next).Error; err != nil {
+		return 0, err
+	}
+	return next.ID, nil
+}
+
+// regenerateTicketQR replaces the ticket's QR payload, and its barcode if it has one, with
+// fresh ones, retrying when a new code hits a unique constraint
+func regenerateTicketQR(db *gorm.DB, ticket *models.Ticket) error {
+	return updateTicketQR(db, ticket, false)
+}
+
+// regenerateTicketQRInTx is regenerateTicketQR for use inside a transaction. Each attempt runs
+// in a savepoint so a collision does not abort the surrounding transaction.
+func regenerateTicketQRInTx(tx *gorm.DB, ticket *models.Ticket) error {
+	return updateTicketQR(tx, ticket, true)
+}
+
+func updateTicketQR(db *gorm.DB, ticket *models.Ticket, savepoint bool) error {
+	attempts := config.GetInt("QR_PAYLOAD_MAX_ATTEMPTS", 5)
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for i := 0; i < attempts; i++ {
+		updates := map[string]interface{}{"qr_code": generateQRPayload(ticket.EventID, ticketHolderID(*ticket), ticket.ID)}
+		if ticket.Barcode != nil {
+			value, err := generateBarcodeValue()
+			if err != nil {
+				return err
+			}
+			updates["barcode"] = value
+		}
+
+		if savepoint {
+			if err := db.Exec("SAVEPOINT ticket_qr").Error; err != nil {
+				return err
+			}
+		}
+
+		err := db.Model(&models.Ticket{}).Where("id = ?", ticket.ID).Updates(updates).Error
+		if err == nil {
+			ticket.QRCode = updates["qr_code"].(string)
+			if value, ok := updates["barcode"].(string); ok {
+				ticket.Barcode = &value
+			}
+			if savepoint {
+				return db.Exec("RELEASE SAVEPOINT ticket_qr").Error
+			}
+			return nil
+		}
+
+		if savepoint {
+			if rollbackErr := db.Exec("ROLLBACK TO SAVEPOINT ticket_qr").Error; rollbackErr != nil {
+				return rollbackErr
+			}
+		}
+		if !database.IsUniqueViolation(err) {
+			return err
+		}
+	}
+
+	return errQRPayloadExhausted
+}
+
+// qrETag returns the entity tag of a QR image, derived from its payload so it changes
+// whenever the QR code is regenerated
+func qrETag(payload string) string {
+	sum := sha256.Sum256([]byte(payload))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches the entity tag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// findAccessibleTicket loads a ticket that the current user owns, or any ticket for admins
+func (h *TicketHandler) findAccessibleTicket(r *http.Request, ticketID uint64) (models.Ticket, error) {
+	var ticket models.Ticket
+	query := h.db.Where("id = ?", ticketID)
+	if r.Context().Value("user_role") != "admin" {
+		query = query.Where("user_id = ?", r.Context().Value("user_id"))
+	}
+	err := query.First(&ticket).Error
+	return ticket, err
+}
+
+// GetTicketQRImage serves the ticket's QR code as a PNG image, rendered from the stored
+// payload. Responses carry an ETag so clients can revalidate instead of re-downloading.
+func (h *TicketHandler) GetTicketQRImage(w http.ResponseWriter, r *http.Request) {
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	if r.Context().Value("user_id") == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	ticket, err := h.findAccessibleTicket(r, ticketID)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	etag := qrETag(ticket.QRCode)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", config.GetInt("QR_IMAGE_CACHE_MAX_AGE_SECONDS", 3600)))
+
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	png, err := utils.RenderQRCodePNG(ticket.QRCode, qrImageSize)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to render QR code")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// GetTicketBarcodeImage serves the ticket's barcode as a Code128 PNG image, for venues that
+// scan 1D barcodes. Tickets issued while barcodes were disabled have none.
+func (h *TicketHandler) GetTicketBarcodeImage(w http.ResponseWriter, r *http.Request) {
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	if r.Context().Value("user_id") == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	ticket, err := h.findAccessibleTicket(r, ticketID)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	if ticket.Barcode == nil {
+		respondError(w, http.StatusNotFound, apierror.CodeNotFound, "Ticket has no barcode")
+		return
+	}
+
+	etag := qrETag(*ticket.Barcode)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", config.GetInt("QR_IMAGE_CACHE_MAX_AGE_SECONDS", 3600)))
+
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	png, err := utils.RenderBarcodePNG(*ticket.Barcode, barcodeImageWidth, barcodeImageHeight)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to render barcode")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// RegenerateTicketQR issues a new QR payload for a valid ticket, invalidating the old code
+func (h *TicketHandler) RegenerateTicketQR(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	if r.Context().Value("user_id") == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	ticket, err := h.findAccessibleTicket(r, ticketID)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	if ticket.Status != "valid" {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, "Only valid tickets can have their QR code regenerated")
+		return
+	}
+
+	if err := regenerateTicketQR(h.db, &ticket); err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to regenerate QR code")
+		return
+	}
+
+	recordAudit(h.db, r, "ticket.qr_regenerated", "ticket", ticket.ID, "")
+
+	response := map[string]interface{}{
+		"message": "QR code regenerated successfully",
+		"ticket":  ticket,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
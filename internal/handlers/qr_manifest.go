@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// QRManifestEntry is a scannable ticket in an event's QR manifest
+type QRManifestEntry struct {
+	TicketID  uint    `json:"ticket_id"`
+	QRCode    string  `json:"qr_code"`
+	Barcode   *string `json:"barcode,omitempty"`
+	Signature string  `json:"signature,omitempty"`
+}
+
+// QRManifest lists the QR payloads of an event's valid tickets for seeding an offline
+// validation cache
+type QRManifest struct {
+	EventID     uint              `json:"event_id"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Signed      bool              `json:"signed"`
+	Count       int               `json:"count"`
+	Tickets     []QRManifestEntry `json:"tickets"`
+}
+
+// minQRSecretLength is the shortest QR_SIGNING_SECRET accepted for signing ticket payloads
+const minQRSecretLength = 32
+
+// qrSecret is the ticket payload signing key, set once at startup by LoadQRSigningSecret
+var qrSecret []byte
+
+// LoadQRSigningSecret reads the ticket payload signing key from QR_SIGNING_SECRET. It must be
+// called before any ticket is issued or scanned, and fails when the secret is missing or too
+// short.
+func LoadQRSigningSecret() error {
+	secret := config.GetEnv("QR_SIGNING_SECRET", "")
+	if secret == "" {
+		return errors.New("QR_SIGNING_SECRET environment variable is required but not set")
+	}
+	if len(secret) < minQRSecretLength {
+		return fmt.Errorf("QR_SIGNING_SECRET must be at least %d bytes long", minQRSecretLength)
+	}
+
+	qrSecret = []byte(secret)
+	return nil
+}
+
+// qrSigningSecret returns the secret ticket payloads and manifest entries are signed with
+func qrSigningSecret() []byte {
+	return qrSecret
+}
+
+// buildQRManifest assembles the manifest of valid tickets, signing each payload when a
+// secret is given. Used and cancelled tickets are left out so a scanner rejects them.
+func buildQRManifest(eventID uint, tickets []models.Ticket, secret []byte, now time.Time) QRManifest {
+	manifest := QRManifest{
+		EventID:     eventID,
+		GeneratedAt: now,
+		Signed:      len(secret) > 0,
+		Tickets:     []QRManifestEntry{},
+	}
+
+	for _, ticket := range tickets {
+		if ticket.Status != "valid" {
+			continue
+		}
+
+		entry := QRManifestEntry{TicketID: ticket.ID, QRCode: ticket.QRCode, Barcode: ticket.Barcode}
+		if manifest.Signed {
+			entry.Signature = utils.SignQRPayload(ticket.QRCode, secret)
+		}
+		manifest.Tickets = append(manifest.Tickets, entry)
+	}
+	manifest.Count = len(manifest.Tickets)
+	return manifest
+}
+
+// GetQRManifest returns the QR payloads of an event's valid tickets, signed with
+// QR_SIGNING_SECRET, for offline scanners to validate against (admin only). With
+// ?download=true the manifest is served as a file.
+func (h *TicketHandler) GetQRManifest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	var tickets []models.Ticket
+	if err := h.db.Where("event_id = ? AND status = ?", event.ID, "valid").Order("id asc").Find(&tickets).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	manifest := buildQRManifest(event.ID, tickets, qrSigningSecret(), time.Now())
+
+	if r.URL.Query().Get("download") == "true" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=qr_manifest_event_%d.json", event.ID))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(manifest)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/utils"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestGetQRManifestListsOnlyValidSignedPayloads(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+
+	valid := []models.Ticket{
+		createTestTicket(t, db, event, createTestUser(t, db, "user")),
+		createTestTicket(t, db, event, createTestUser(t, db, "user")),
+	}
+	for _, status := range []string{"used", "cancelled"} {
+		ticket := createTestTicket(t, db, event, createTestUser(t, db, "user"))
+		db.Model(&models.Ticket{}).Where("id = ?", ticket.ID).UpdateColumn("status", status)
+	}
+	createTestTicket(t, db, createTestEvent(t, db, 10, 20), createTestUser(t, db, "user"))
+
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.GetQRManifest(w, authedRequest("GET", "/api/events/"+vars["id"]+"/qr-manifest?download=true", "", admin, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("QR manifest returned %d: %s", w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment;filename=qr_manifest_event_"+vars["id"]+".json" {
+		t.Errorf("Content-Disposition is %q", disposition)
+	}
+	var manifest QRManifest
+	if err := json.NewDecoder(w.Body).Decode(&manifest); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+
+	if manifest.EventID != event.ID || !manifest.Signed || manifest.Count != len(valid) || len(manifest.Tickets) != len(valid) {
+		t.Fatalf("manifest of event %d lists %d of %d tickets, want the %d valid ones", manifest.EventID, len(manifest.Tickets), manifest.Count, len(valid))
+	}
+	for i, entry := range manifest.Tickets {
+		if entry.TicketID != valid[i].ID || entry.QRCode != valid[i].QRCode {
+			t.Errorf("entry %d is ticket %d, want %d", i, entry.TicketID, valid[i].ID)
+		}
+		if entry.Signature != utils.SignQRPayload(entry.QRCode, []byte(testQRSecret)) {
+			t.Errorf("ticket %d has signature %q, which does not check out", entry.TicketID, entry.Signature)
+		}
+		if claims, err := utils.ValidateQRCode(entry.QRCode, []byte(testQRSecret)); err != nil || claims.EventID != event.ID {
+			t.Errorf("ticket %d payload does not verify for the event: %+v, %v", entry.TicketID, claims, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// collidingQRPayloads makes the next collisions generated payloads repeat payload, then
+// generates unique ones, and returns a pointer to the number of payloads generated
+func collidingQRPayloads(t *testing.T, payload string, collisions int) *int {
+	t.Helper()
+	original := generateQRPayload
+	t.Cleanup(func() { generateQRPayload = original })
+
+	calls := 0
+	generateQRPayload = func(eventID uint, userID uint, ticketID uint) string {
+		calls++
+		if calls <= collisions {
+			return payload
+		}
+		return fmt.Sprintf("unique-%d-%d", ticketID, calls)
+	}
+	return &calls
+}
+
+func TestCreateTicketRetriesQRCollision(t *testing.T) {
+	db := openTestDB(t)
+	holder := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 5, 20)
+	existing := createTestTicket(t, db, event, holder)
+
+	calls := collidingQRPayloads(t, existing.QRCode, 2)
+	ticket := models.Ticket{EventID: event.ID, UserID: &holder.ID, Status: "valid"}
+	if err := createTicketWithUniqueQR(db, &ticket); err != nil {
+		t.Fatalf("create ticket after collisions: %v", err)
+	}
+	if *calls != 3 {
+		t.Fatalf("generated %d payloads, want 3", *calls)
+	}
+	if ticket.QRCode == existing.QRCode {
+		t.Fatal("ticket was stored with the colliding payload")
+	}
+
+	var stored models.Ticket
+	if err := db.Where("id = ?", ticket.ID).First(&stored).Error; err != nil {
+		t.Fatalf("reload ticket: %v", err)
+	}
+	if stored.QRCode != ticket.QRCode {
+		t.Fatalf("stored payload %q, want %q", stored.QRCode, ticket.QRCode)
+	}
+}
+
+func TestCreateTicketInTxRetriesQRCollision(t *testing.T) {
+	db := openTestDB(t)
+	holder := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 5, 20)
+	existing := createTestTicket(t, db, event, holder)
+
+	collidingQRPayloads(t, existing.QRCode, 1)
+	tx := db.Begin()
+	ticket := models.Ticket{EventID: event.ID, UserID: &holder.ID, Status: "valid"}
+	if err := createTicketWithUniqueQRInTx(tx, &ticket); err != nil {
+		tx.Rollback()
+		t.Fatalf("create ticket in transaction after a collision: %v", err)
+	}
+	// The collision was rolled back to its savepoint, so the transaction still commits
+	if err := tx.Commit().Error; err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	var count int
+	db.Model(&models.Ticket{}).Where("event_id = ?", event.ID).Count(&count)
+	if count != 2 {
+		t.Fatalf("event has %d tickets, want 2", count)
+	}
+}
+
+func TestCreateTicketGivesUpAfterMaxQRAttempts(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("QR_PAYLOAD_MAX_ATTEMPTS", "3")
+	holder := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 5, 20)
+	existing := createTestTicket(t, db, event, holder)
+
+	calls := collidingQRPayloads(t, existing.QRCode, 3)
+	ticket := models.Ticket{EventID: event.ID, UserID: &holder.ID, Status: "valid"}
+	if err := createTicketWithUniqueQR(db, &ticket); err != errQRPayloadExhausted {
+		t.Fatalf("create ticket returned %v, want errQRPayloadExhausted", err)
+	}
+	if *calls != 3 {
+		t.Fatalf("generated %d payloads, want 3", *calls)
+	}
+}
+
+// getQRImage fetches the ticket's QR image as the user, revalidating with ifNoneMatch when set
+func getQRImage(h *TicketHandler, ticket models.Ticket, user models.User, ifNoneMatch string) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	r := authedRequest("GET", "/api/tickets/"+vars["id"]+"/qr.png", "", user, vars)
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	h.GetTicketQRImage(w, r)
+	return w
+}
+
+func TestGetTicketQRImageRevalidatesWithETag(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	holder := createTestUser(t, db, "user")
+	ticket := createTestTicket(t, db, createTestEvent(t, db, 5, 20), holder)
+
+	w := getQRImage(h, ticket, holder, "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first fetch returned %d with ETag %q", w.Code, etag)
+	}
+	if w.Header().Get("Content-Type") != "image/png" || w.Body.Len() == 0 {
+		t.Fatalf("first fetch served %q with %d bytes", w.Header().Get("Content-Type"), w.Body.Len())
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		t.Fatal("QR image has no Cache-Control header")
+	}
+
+	w = getQRImage(h, ticket, holder, etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("revalidation returned %d with %d bytes, want an empty 304", w.Code, w.Body.Len())
+	}
+
+	// Regenerating the QR code changes the ETag, so the cached image is no longer current
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	rw := httptest.NewRecorder()
+	h.RegenerateTicketQR(rw, authedRequest("POST", "/api/tickets/"+vars["id"]+"/qr/regenerate", "", holder, vars))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("regenerate returned %d: %s", rw.Code, rw.Body.String())
+	}
+
+	w = getQRImage(h, ticket, holder, etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("fetch with the old ETag after regeneration returned %d, want 200", w.Code)
+	}
+	if fresh := w.Header().Get("ETag"); fresh == "" || fresh == etag {
+		t.Fatalf("ETag after regeneration is %q, was %q", fresh, etag)
+	}
+}
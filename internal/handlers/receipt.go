@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/pdf"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// ReceiptLine is one line item of a receipt, grouping the order's tickets of the same type and price
+type ReceiptLine struct {
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// ReceiptTotals holds the amounts charged for an order
+type ReceiptTotals struct {
+	Subtotal float64 `json:"subtotal"`
+	Discount float64 `json:"discount"`
+	TaxRate  float64 `json:"tax_rate"`
+	Tax      float64 `json:"tax"`
+	Total    float64 `json:"total"`
+}
+
+// Receipt is the purchase receipt of an order
+type Receipt struct {
+	OrderID     uint          `json:"order_id"`
+	EventID     uint          `json:"event_id"`
+	EventTitle  string        `json:"event_title"`
+	EventDate   time.Time     `json:"event_date"`
+	Buyer       string        `json:"buyer"`
+	PurchasedAt time.Time     `json:"purchased_at"`
+	Lines       []ReceiptLine `json:"lines"`
+	ReceiptTotals
+}
+
+// taxRate returns the tax percentage applied to new orders
+func taxRate() float64 {
+	return config.GetFloat("TAX_RATE", 0)
+}
+
+// roundCents rounds an amount to two decimal places
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// splitOrderTotal divides the total charged for an order over its tickets in proportion to their
+// prices, so the discount and tax are shared the same way. Shares are rounded to cents and the
+// last ticket takes the rounding remainder, so they add up to the total.
+func splitOrderTotal(total float64, prices []float64) []float64 {
+	shares := make([]float64, len(prices))
+	if len(prices) == 0 {
+		return shares
+	}
+
+	var subtotal float64
+	for _, price := range prices {
+		subtotal += price
+	}
+
+	var allocated float64
+	for i, price := range prices[:len(prices)-1] {
+		if subtotal > 0 {
+			shares[i] = roundCents(total * price / subtotal)
+		} else {
+			shares[i] = roundCents(total / float64(len(prices)))
+		}
+		allocated += shares[i]
+	}
+	shares[len(prices)-1] = roundCents(total - allocated)
+	return shares
+}
+
+// calculateReceiptTotals sums the line items, applies the order discount (never more than the
+// subtotal) and then the tax percentage on the discounted amount
+func calculateReceiptTotals(lines []ReceiptLine, discount, rate float64) ReceiptTotals {
+	var subtotal float64
+	for _, line := range lines {
+		subtotal += line.Amount
+	}
+	subtotal = roundCents(subtotal)
+
+	discount = roundCents(math.Min(math.Max(discount, 0), subtotal))
+	tax := roundCents((subtotal - discount) * rate / 100)
+
+	return ReceiptTotals{
+		Subtotal: subtotal,
+		Discount: discount,
+		TaxRate:  rate,
+		Tax:      tax,
+		Total:    roundCents(subtotal - discount + tax),
+	}
+}
+
+// buildReceiptLines groups tickets into line items by ticket type and price paid, in the
+// order the tickets were issued
+func buildReceiptLines(tickets []models.Ticket, typeNames map[uint]string) []ReceiptLine {
+	lines := []ReceiptLine{}
+	index := map[string]int{}
+	for _, ticket := range tickets {
+		description := "Admission"
+		if ticket.TicketTypeID != nil {
+			if name, ok := typeNames[*ticket.TicketTypeID]; ok {
+				description = name
+			}
+		}
+
+		key := fmt.Sprintf("%s|%.2f", description, ticket.PricePaid)
+		i, ok := index[key]
+		if !ok {
+			i = len(lines)
+			index[key] = i
+			lines = append(lines, ReceiptLine{Description: description, UnitPrice: ticket.PricePaid})
+		}
+		lines[i].Quantity++
+		lines[i].Amount = roundCents(float64(lines[i].Quantity) * lines[i].UnitPrice)
+	}
+	return lines
+}
+
+// renderReceiptPDF renders a receipt as a one page PDF
+func renderReceiptPDF(receipt Receipt) ([]byte, error) {
+	doc := pdf.New()
+	doc.Heading("Receipt")
+	doc.Text(fmt.Sprintf("Order #%d", receipt.OrderID))
+	doc.Text(fmt.Sprintf("Purchased: %s", format.Date(receipt.PurchasedAt)))
+	doc.Text(fmt.Sprintf("Buyer: %s", receipt.Buyer))
+	doc.Space(4)
+	doc.Text(fmt.Sprintf("%s - %s", receipt.EventTitle, format.Date(receipt.EventDate)))
+	doc.Space(4)
+
+	rows := make([][]string, 0, len(receipt.Lines))
+	for _, line := range receipt.Lines {
+		rows = append(rows, []string{line.Description, strconv.Itoa(line.Quantity), format.Price(line.UnitPrice), format.Price(line.Amount)})
+	}
+	doc.Table([]string{"Item", "Qty", "Unit price", "Amount"}, []float64{80, 20, 35, 35}, rows)
+	doc.Space(2)
+
+	doc.Summary(135, 35, [][2]string{
+		{"Subtotal", format.Price(receipt.Subtotal)},
+		{"Discount", "-" + format.Price(receipt.Discount)},
+		{fmt.Sprintf("Tax (%s%%)", strconv.FormatFloat(receipt.TaxRate, 'f', -1, 64)), format.Price(receipt.Tax)},
+		{"Total", format.Price(receipt.Total)},
+	})
+
+	return doc.Bytes()
+}
+
+// GetOrderReceipt returns the receipt of an order as JSON or PDF. Only the buyer or an admin
+// may access it.
+func (h *TicketHandler) GetOrderReceipt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	orderID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid order ID")
+		return
+	}
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	receiptFormat := r.URL.Query().Get("format")
+	if receiptFormat == "" {
+		receiptFormat = "json"
+	}
+	if receiptFormat != "json" && receiptFormat != "pdf" {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Format must be json or pdf")
+		return
+	}
+
+	var order models.Order
+	query := h.db.Where("id = ?", orderID)
+	if r.Context().Value("user_role") != "admin" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Preload("Tickets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id asc")
+	}).First(&order).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeOrderNotFound, "Order not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve order")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", order.EventID).First(&event).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	var buyer models.User
+	if err := h.db.Where("id = ?", order.UserID).First(&buyer).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve buyer")
+		return
+	}
+
+	var types []models.TicketType
+	if err := h.db.Where("event_id = ?", event.ID).Find(&types).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket types")
+		return
+	}
+	typeNames := map[uint]string{}
+	for _, ticketType := range types {
+		typeNames[ticketType.ID] = ticketType.Name
+	}
+
+	lines := buildReceiptLines(order.Tickets, typeNames)
+	receipt := Receipt{
+		OrderID:       order.ID,
+		EventID:       event.ID,
+		EventTitle:    event.Title,
+		EventDate:     event.Date,
+		Buyer:         buyer.Name,
+		PurchasedAt:   order.CreatedAt,
+		Lines:         lines,
+		ReceiptTotals: calculateReceiptTotals(lines, order.Discount, order.TaxRate),
+	}
+
+	if receiptFormat == "pdf" {
+		body, err := renderReceiptPDF(receipt)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to render receipt")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=receipt_order_%d.pdf", order.ID))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(receipt)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestSplitOrderTotal(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  float64
+		prices []float64
+		want   []float64
+	}{
+		{name: "no tickets", total: 10, prices: nil, want: []float64{}},
+		{name: "single ticket takes the total", total: 21.65, prices: []float64{20}, want: []float64{21.65}},
+		{name: "equal prices", total: 90, prices: []float64{50, 50}, want: []float64{45, 45}},
+		{name: "last ticket takes the rounding remainder", total: 100, prices: []float64{10, 10, 10}, want: []float64{33.33, 33.33, 33.34}},
+		{name: "shares follow the prices", total: 108, prices: []float64{25, 75}, want: []float64{27, 81}},
+		{name: "free tickets split the total equally", total: 3, prices: []float64{0, 0}, want: []float64{1.5, 1.5}},
+		{name: "free order", total: 0, prices: []float64{20, 20}, want: []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitOrderTotal(tt.total, tt.prices)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitOrderTotal(%v, %v) = %v, want %v", tt.total, tt.prices, got, tt.want)
+			}
+
+			var sum float64
+			for _, share := range got {
+				sum += share
+			}
+			if len(got) > 0 && roundCents(sum) != tt.total {
+				t.Errorf("shares add up to %v, want %v", roundCents(sum), tt.total)
+			}
+		})
+	}
+}
+
+func TestCalculateReceiptTotals(t *testing.T) {
+	lines := []ReceiptLine{{Quantity: 2, UnitPrice: 25, Amount: 50}, {Quantity: 1, UnitPrice: 19.99, Amount: 19.99}}
+
+	tests := []struct {
+		name     string
+		discount float64
+		rate     float64
+		want     ReceiptTotals
+	}{
+		{name: "no discount or tax", want: ReceiptTotals{Subtotal: 69.99, Total: 69.99}},
+		{name: "discounted order", discount: 7, want: ReceiptTotals{Subtotal: 69.99, Discount: 7, Total: 62.99}},
+		{name: "tax on the discounted amount", discount: 7, rate: 10, want: ReceiptTotals{Subtotal: 69.99, Discount: 7, TaxRate: 10, Tax: 6.3, Total: 69.29}},
+		{name: "discount capped at the subtotal", discount: 100, rate: 10, want: ReceiptTotals{Subtotal: 69.99, Discount: 69.99, TaxRate: 10}},
+		{name: "negative discount ignored", discount: -5, want: ReceiptTotals{Subtotal: 69.99, Total: 69.99}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateReceiptTotals(lines, tt.discount, tt.rate); got != tt.want {
+				t.Fatalf("calculateReceiptTotals() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// getReceipt fetches the order's receipt as the user and returns the recorded response
+func getReceipt(h *TicketHandler, orderID uint, user models.User, format string) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(orderID))}
+	w := httptest.NewRecorder()
+	h.GetOrderReceipt(w, authedRequest("GET", "/api/orders/"+vars["id"]+"/receipt?format="+format, "", user, vars))
+	return w
+}
+
+func TestGetOrderReceiptOfDiscountedOrder(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	t.Setenv("TAX_RATE", "8")
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 25)
+	promo := models.PromoCode{Code: "RECEIPT", EventID: &event.ID, AmountOff: 10, Active: true}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	if code := purchaseOne(h, event, buyer, `{"quantity": 3, "promo_code": "RECEIPT", "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase returned %d, want %d", code, http.StatusCreated)
+	}
+	var order models.Order
+	db.Where("event_id = ?", event.ID).First(&order)
+
+	w := getReceipt(h, order.ID, buyer, "json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("receipt returned %d: %s", w.Code, w.Body.String())
+	}
+	var receipt Receipt
+	if err := json.NewDecoder(w.Body).Decode(&receipt); err != nil {
+		t.Fatalf("decode receipt: %v", err)
+	}
+	want := ReceiptTotals{Subtotal: 75, Discount: 10, TaxRate: 8, Tax: 5.2, Total: 70.2}
+	if receipt.ReceiptTotals != want || receipt.Total != order.Total {
+		t.Fatalf("receipt totals = %+v for an order of %v, want %+v", receipt.ReceiptTotals, order.Total, want)
+	}
+	if len(receipt.Lines) != 1 || receipt.Lines[0].Quantity != 3 || receipt.Lines[0].Amount != 75 {
+		t.Fatalf("receipt lines = %+v, want 3 admissions for 75", receipt.Lines)
+	}
+
+	if w := getReceipt(h, order.ID, createTestUser(t, db, "user"), "json"); w.Code != http.StatusNotFound {
+		t.Fatalf("another user's receipt request returned %d, want %d", w.Code, http.StatusNotFound)
+	}
+	w = getReceipt(h, order.ID, createTestUser(t, db, "admin"), "pdf")
+	if w.Code != http.StatusOK || !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")) {
+		t.Fatalf("admin PDF receipt returned %d with %d bytes, want a PDF", w.Code, w.Body.Len())
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+// reconciliationExportHeader is the header row of the reconciliation CSV export
+var reconciliationExportHeader = []string{"Ticket ID", "Event ID", "Event", "Purchased At", "Order ID", "Charge ID", "Price Paid", "Amount Charged", "Door Payment", "Status", "Checked In", "Checked In At"}
+
+// ReconciliationRow ties a sold ticket to its payment and its first check-in. AmountCharged is
+// the ticket's share of its order total after discounts and tax, which is what the payment
+// provider collected; PricePaid is the list price before them.
+type ReconciliationRow struct {
+	TicketID          uint       `json:"ticket_id"`
+	EventID           uint       `json:"event_id"`
+	EventTitle        string     `json:"event_title"`
+	PurchasedAt       time.Time  `json:"purchased_at"`
+	OrderID           *uint      `json:"order_id"`
+	ChargeID          string     `json:"charge_id"`
+	PricePaid         float64    `json:"price_paid"`
+	AmountCharged     float64    `json:"amount_charged"`
+	DoorPaymentAmount *float64   `json:"door_payment_amount"`
+	Status            string     `json:"status"`
+	CheckedIn         bool       `json:"checked_in"`
+	CheckedInAt       *time.Time `json:"checked_in_at"`
+}
+
+// reconciliationExportRow assembles the CSV export row for a reconciliation row
+func reconciliationExportRow(row ReconciliationRow) []string {
+	doorPayment := ""
+	if row.DoorPaymentAmount != nil {
+		doorPayment = fmt.Sprintf("%.2f", *row.DoorPaymentAmount)
+	}
+	checkedInAt := ""
+	if row.CheckedInAt != nil {
+		checkedInAt = row.CheckedInAt.Format("2006-01-02 15:04:05")
+	}
+	orderID := ""
+	if row.OrderID != nil {
+		orderID = fmt.Sprintf("%d", *row.OrderID)
+	}
+
+	return []string{
+		fmt.Sprintf("%d", row.TicketID),
+		fmt.Sprintf("%d", row.EventID),
+		row.EventTitle,
+		row.PurchasedAt.Format("2006-01-02 15:04:05"),
+		orderID,
+		row.ChargeID,
+		fmt.Sprintf("%.2f", row.PricePaid),
+		fmt.Sprintf("%.2f", row.AmountCharged),
+		doorPayment,
+		row.Status,
+		fmt.Sprintf("%t", row.CheckedIn),
+		checkedInAt,
+	}
+}
+
+// GetReconciliation exports every ticket sold in a date range with its event, order and charge,
+// the amount charged for it, door payment and first check-in, as CSV or JSON (admin only). Rows are streamed from the
+// database so large ranges do not have to fit in memory.
+func (h *AdminHandler) GetReconciliation(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	exportFormat := query.Get("format")
+	if exportFormat == "" {
+		exportFormat = "csv"
+	}
+	if exportFormat != "csv" && exportFormat != "json" {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Format must be csv or json")
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid to date")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if value := query.Get("from"); value != "" {
+		parsed, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid from date")
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "From date must be before to date")
+		return
+	}
+
+	// Only the first check-in of each ticket is reported, so re-scans do not duplicate rows
+	rows, err := h.db.Table("tickets").
+		Select("tickets.id AS ticket_id, tickets.event_id, events.title AS event_title, "+
+			"tickets.created_at AS purchased_at, tickets.order_id, COALESCE(orders.charge_id, '') AS charge_id, "+
+			"tickets.price_paid, tickets.amount_charged, tickets.door_payment_amount, tickets.status, "+
+			"checkins.checked_in_at IS NOT NULL AS checked_in, checkins.checked_in_at").
+		Joins("JOIN events ON events.id = tickets.event_id").
+		Joins("LEFT JOIN orders ON orders.id = tickets.order_id").
+		Joins("LEFT JOIN (SELECT ticket_id, MIN(checked_in_at) AS checked_in_at FROM attendance_logs GROUP BY ticket_id) checkins ON checkins.ticket_id = tickets.id").
+		Where("tickets.user_id IS NOT NULL AND tickets.created_at >= ? AND tickets.created_at < ?", from, to).
+		Order("tickets.created_at asc, tickets.id asc").
+		Rows()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve reconciliation data")
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("reconciliation_%s_%s", from.Format("20060102"), to.Format("20060102"))
+
+	if exportFormat == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=%s.json", filename))
+
+		// Write the array element by element instead of encoding a slice of every row
+		encoder := json.NewEncoder(w)
+		w.Write([]byte("["))
+		first := true
+		for rows.Next() {
+			var row ReconciliationRow
+			if err := h.db.ScanRows(rows, &row); err != nil {
+				break
+			}
+			if !first {
+				w.Write([]byte(","))
+			}
+			first = false
+			encoder.Encode(row)
+		}
+		w.Write([]byte("]\n"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=%s.csv", filename))
+
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	writer.Write(reconciliationExportHeader)
+	for rows.Next() {
+		var row ReconciliationRow
+		if err := h.db.ScanRows(rows, &row); err != nil {
+			break
+		}
+		writer.Write(reconciliationExportRow(row))
+	}
+}
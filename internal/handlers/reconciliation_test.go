@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestGetReconciliationJoinsTicketsPaymentsAndCheckIns(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAdminHandler(db)
+	tickets := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 10, 25)
+
+	if code := purchaseOne(tickets, event, buyer, `{"quantity": 2, "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase returned %d", code)
+	}
+	var sold []models.Ticket
+	db.Where("event_id = ?", event.ID).Order("id asc").Find(&sold)
+	var order models.Order
+	db.Where("id = ?", *sold[0].OrderID).First(&order)
+
+	// The first ticket is scanned twice, the second never
+	firstScan := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, at := range []time.Time{firstScan, firstScan.Add(30 * time.Minute)} {
+		db.Create(&models.AttendanceLog{TicketID: sold[0].ID, CheckedInAt: at})
+	}
+	db.Model(&models.Ticket{}).Where("id = ?", sold[0].ID).UpdateColumn("status", "used")
+
+	// Reserved tickets without a holder were never sold
+	reserved := createTestTicket(t, db, event, buyer)
+	db.Model(&models.Ticket{}).Where("id = ?", reserved.ID).UpdateColumn("user_id", nil)
+
+	w := httptest.NewRecorder()
+	h.GetReconciliation(w, authedRequest("GET", "/api/admin/reconciliation?format=json", "", admin, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reconciliation returned %d: %s", w.Code, w.Body.String())
+	}
+	var rows []ReconciliationRow
+	if err := json.NewDecoder(w.Body).Decode(&rows); err != nil {
+		t.Fatalf("decode reconciliation: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want the 2 sold tickets", len(rows))
+	}
+
+	for i, row := range rows {
+		if row.TicketID != sold[i].ID || row.EventID != event.ID || row.EventTitle != event.Title {
+			t.Errorf("row %d is for ticket %d of event %d %q", i, row.TicketID, row.EventID, row.EventTitle)
+		}
+		if row.OrderID == nil || *row.OrderID != order.ID || row.ChargeID != order.ChargeID || row.ChargeID == "" {
+			t.Errorf("row %d has order %v and charge %q, want order %d and charge %q", i, row.OrderID, row.ChargeID, order.ID, order.ChargeID)
+		}
+		if row.PricePaid != 25 || row.AmountCharged != sold[i].AmountCharged {
+			t.Errorf("row %d paid %.2f charged %.2f, want 25.00 and %.2f", i, row.PricePaid, row.AmountCharged, sold[i].AmountCharged)
+		}
+	}
+	if !rows[0].CheckedIn || rows[0].CheckedInAt == nil || !rows[0].CheckedInAt.Equal(firstScan) || rows[0].Status != "used" {
+		t.Errorf("scanned ticket row is checked in %t at %v with status %q, want the first scan at %v", rows[0].CheckedIn, rows[0].CheckedInAt, rows[0].Status, firstScan)
+	}
+	if rows[1].CheckedIn || rows[1].CheckedInAt != nil || rows[1].Status != "valid" {
+		t.Errorf("unscanned ticket row is checked in %t at %v with status %q", rows[1].CheckedIn, rows[1].CheckedInAt, rows[1].Status)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetReconciliation(w, authedRequest("GET", "/api/admin/reconciliation", "", admin, nil))
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != reconciliationExportHeader[0] || records[1][10] != "true" || records[2][10] != "false" {
+		t.Fatalf("CSV export is %v", records)
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// requestValidator checks request payloads against their binding tags, naming fields by their
+// JSON keys so errors match what the client sent
+var requestValidator = newRequestValidator()
+
+func newRequestValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// validationMessage describes a failed rule in words
+func validationMessage(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	unit := ""
+	switch fieldError.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fieldError.Tag() {
+	case "required", "required_unless":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", param, unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", param, unit)
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	}
+	return fmt.Sprintf("failed the %s check", fieldError.Tag())
+}
+
+// requestFieldErrors validates a decoded request, returning one entry per failed field
+func requestFieldErrors(req interface{}) []apierror.FieldError {
+	err := requestValidator.Struct(req)
+	var failed validator.ValidationErrors
+	if !errors.As(err, &failed) {
+		return nil
+	}
+
+	fields := make([]apierror.FieldError, 0, len(failed))
+	for _, fieldError := range failed {
+		// The namespace starts with the struct name, e.g. CreateSeatsRequest.seats[0].row
+		field := fieldError.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		fields = append(fields, apierror.FieldError{Field: field, Message: validationMessage(fieldError)})
+	}
+	return fields
+}
+
+// validateRequest checks a decoded request against its binding tags, answering 422 with the
+// failed fields when it does not pass
+func validateRequest(w http.ResponseWriter, req interface{}) bool {
+	fields := requestFieldErrors(req)
+	if len(fields) == 0 {
+		return true
+	}
+	apierror.RespondFields(w, http.StatusUnprocessableEntity, apierror.CodeValidationFailed, "Request validation failed", fields)
+	return false
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/mailer"
+)
+
+func TestRequestFieldErrors(t *testing.T) {
+	validEvent := `{"title": "Concert", "description": "Live", "date": "2030-01-01T20:00:00Z", "location": "Hall", "capacity": 100, "price": 20}`
+
+	tests := []struct {
+		name string
+		req  interface{}
+		body string
+		want []apierror.FieldError
+	}{
+		{name: "valid registration", req: &RegisterRequest{}, body: `{"name": "Ada", "email": "ada@example.com", "password": "secret1"}`},
+		{name: "empty registration", req: &RegisterRequest{}, body: `{}`, want: []apierror.FieldError{
+			{Field: "name", Message: "is required"},
+			{Field: "email", Message: "is required"},
+			{Field: "password", Message: "is required"},
+		}},
+		{name: "malformed email and short password", req: &RegisterRequest{}, body: `{"name": "Ada", "email": "ada.example.com", "password": "abc"}`, want: []apierror.FieldError{
+			{Field: "email", Message: "must be a valid email address"},
+			{Field: "password", Message: "must be at least 6 characters"},
+		}},
+		{name: "valid event", req: &CreateEventRequest{}, body: validEvent},
+		{name: "event without a title", req: &CreateEventRequest{}, body: `{"description": "Live", "date": "2030-01-01T20:00:00Z", "location": "Hall", "capacity": 100}`, want: []apierror.FieldError{
+			{Field: "title", Message: "is required"},
+		}},
+		{name: "negative price and capacity", req: &CreateEventRequest{}, body: `{"title": "Concert", "description": "Live", "date": "2030-01-01T20:00:00Z", "location": "Hall", "capacity": -1, "price": -5}`, want: []apierror.FieldError{
+			{Field: "capacity", Message: "must be at least 1"},
+			{Field: "price", Message: "must be at least 0"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.body), tt.req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			if got := requestFieldErrors(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestFieldErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidPayloadsAreRejectedBeforeTheDatabase(t *testing.T) {
+	db := openTestDB(t)
+	auth := NewAuthHandler(db, mailer.LogSender{})
+	events := NewEventHandler(db)
+	admin := createTestUser(t, db, "admin")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+		body    string
+		field   string
+	}{
+		{name: "register with a malformed email", handler: auth.Register, target: "/api/auth/register",
+			body: `{"name": "Ada", "email": "not-an-email", "password": "secret1"}`, field: "email"},
+		{name: "event without a title", handler: events.CreateEvent, target: "/api/events",
+			body: `{"description": "Live", "date": "2030-01-01T20:00:00Z", "location": "Hall", "capacity": 100, "price": 20}`, field: "title"},
+		{name: "event with a negative price", handler: events.CreateEvent, target: "/api/events",
+			body: `{"title": "Concert", "description": "Live", "date": "2030-01-01T20:00:00Z", "location": "Hall", "capacity": 100, "price": -1}`, field: "price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, authedRequest("POST", tt.target, tt.body, admin, nil))
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("returned %d, want 422: %s", w.Code, w.Body.String())
+			}
+			var envelope apierror.Envelope
+			if err := json.NewDecoder(w.Body).Decode(&envelope); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if envelope.Error.Code != apierror.CodeValidationFailed || len(envelope.Error.Fields) != 1 || envelope.Error.Fields[0].Field != tt.field {
+				t.Fatalf("error is %+v, want only %s to fail", envelope.Error, tt.field)
+			}
+		})
+	}
+
+	var users, created int
+	db.Model(&models.User{}).Count(&users)
+	db.Model(&models.Event{}).Count(&created)
+	if users != 1 || created != 0 {
+		t.Fatalf("rejected payloads left %d users and %d events, want only the admin", users, created)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// maxReservedRange caps the number of tickets reserved in one request
+const maxReservedRange = 1000
+
+// ReserveRangeRequest represents the reserve ticket range request payload
+type ReserveRangeRequest struct {
+	Count int `json:"count" binding:"required,min=1,max=1000"`
+}
+
+// ReservedTicket is the printable identity of a reserved ticket
+type ReservedTicket struct {
+	ID           uint   `json:"id"`
+	SerialNumber int    `json:"serial_number"`
+	QRCode       string `json:"qr_code"`
+}
+
+// ReserveRange pre-creates a block of unassigned valid tickets with sequential serial numbers
+// for pre-printing (admin only). The block is created atomically and counts against capacity.
+func (h *TicketHandler) ReserveRange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var req ReserveRangeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if req.Count < 1 || req.Count > maxReservedRange {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Count must be between 1 and 1000")
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the event so concurrent reservations and capacity checks are serialized
+	var event models.Event
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", eventID).First(&event).Error; err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if event.Status == "cancelled" {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeEventCancelled, "Cannot reserve tickets for cancelled events")
+		return
+	}
+
+	claimed, err := claimSoldCount(tx, event.ID, req.Count)
+	if err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check capacity")
+		return
+	}
+
+	if !claimed {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeCapacityExceeded, "Not enough tickets available")
+		return
+	}
+
+	// Continue numbering after the last reserved ticket of the event
+	var last struct{ Max int }
+	if err := tx.Model(&models.Ticket{}).Select("COALESCE(MAX(serial_number), 0) AS max").
+		Where("event_id = ?", event.ID).Scan(&last).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+		return
+	}
+
+	reserved := make([]ReservedTicket, 0, req.Count)
+	for i := 1; i <= req.Count; i++ {
+		serial := last.Max + i
+		ticket := models.Ticket{
+			EventID:      event.ID,
+			SerialNumber: &serial,
+			Status:       "valid",
+		}
+
+		if err := createTicketWithUniqueQRInTx(tx, &ticket); err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+			return
+		}
+
+		reserved = append(reserved, ReservedTicket{ID: ticket.ID, SerialNumber: serial, QRCode: ticket.QRCode})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+		return
+	}
+
+	response := map[string]interface{}{
+		"message":      "Tickets reserved successfully",
+		"event_id":     event.ID,
+		"first_serial": reserved[0].SerialNumber,
+		"last_serial":  reserved[len(reserved)-1].SerialNumber,
+		"tickets":      reserved,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
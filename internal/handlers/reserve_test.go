@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// reserveRange reserves count tickets of the event as the admin and returns the recorded response
+func reserveRange(h *TicketHandler, event models.Event, admin models.User, count int) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.ReserveRange(w, authedRequest("POST", "/api/events/"+vars["id"]+"/reserve-range", `{"count": `+strconv.Itoa(count)+`}`, admin, vars))
+	return w
+}
+
+// reservedTickets counts the unassigned tickets of the event
+func reservedTickets(t *testing.T, h *TicketHandler, event models.Event) int {
+	t.Helper()
+	var count int
+	if err := h.db.Model(&models.Ticket{}).Where("event_id = ? AND user_id IS NULL", event.ID).Count(&count).Error; err != nil {
+		t.Fatalf("count reserved tickets: %v", err)
+	}
+	return count
+}
+
+func TestReserveRangeRespectsCapacity(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 5, 20)
+	createTestTicket(t, db, event, createTestUser(t, db, "user"))
+
+	w := reserveRange(h, event, admin, 3)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("reserve-range returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Tickets []ReservedTicket `json:"tickets"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode reserved block: %v", err)
+	}
+	if len(response.Tickets) != 3 {
+		t.Fatalf("reserved %d tickets, want 3", len(response.Tickets))
+	}
+	for i, ticket := range response.Tickets {
+		if ticket.SerialNumber != i+1 || ticket.QRCode == "" {
+			t.Errorf("reserved ticket %d is %+v, want serial number %d with a QR code", i, ticket, i+1)
+		}
+	}
+
+	// One sold and three reserved leave room for one more, not three
+	w = reserveRange(h, event, admin, 3)
+	if w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeCapacityExceeded {
+		t.Fatalf("reservation over capacity returned %d: %s", w.Code, w.Body.String())
+	}
+	if got := reservedTickets(t, h, event); got != 3 {
+		t.Fatalf("event has %d reserved tickets after a rejected block, want 3", got)
+	}
+	if got := soldCount(t, h, event); got != 4 {
+		t.Fatalf("sold count is %d, want 4", got)
+	}
+}
+
+func TestReserveRangeIsAtomic(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("QR_PAYLOAD_MAX_ATTEMPTS", "2")
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+
+	// Every payload collides with the first ticket of the block, so the second insert fails
+	collidingQRPayloads(t, "reserved-block-payload", 100)
+	if w := reserveRange(h, event, admin, 3); w.Code != http.StatusInternalServerError {
+		t.Fatalf("reserve-range with a failing insert returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if got := reservedTickets(t, h, event); got != 0 {
+		t.Fatalf("failed block left %d reserved tickets", got)
+	}
+	if got := soldCount(t, h, event); got != 0 {
+		t.Fatalf("failed block left a sold count of %d", got)
+	}
+}
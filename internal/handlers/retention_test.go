@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// retentionFixture holds events on both sides of a 30 day retention window
+type retentionFixture struct {
+	attended, unattended, empty, recent models.Event
+}
+
+// createRetentionFixture creates three events dated 60 days ago, one with a checked in
+// ticket, one with a ticket nobody used and one without tickets, and a checked in event
+// dated 10 days ago
+func createRetentionFixture(t *testing.T, db *gorm.DB, now time.Time) retentionFixture {
+	t.Helper()
+	event := func(age time.Duration) models.Event {
+		created := createTestEvent(t, db, 10, 20)
+		db.Model(&models.Event{}).Where("id = ?", created.ID).UpdateColumn("date", now.Add(-age))
+		return created
+	}
+	checkIn := func(ticket models.Ticket) {
+		if err := db.Create(&models.AttendanceLog{TicketID: ticket.ID, CheckedInAt: now.Add(-time.Hour)}).Error; err != nil {
+			t.Fatalf("record attendance: %v", err)
+		}
+	}
+
+	fixture := retentionFixture{
+		attended:   event(60 * 24 * time.Hour),
+		unattended: event(60 * 24 * time.Hour),
+		empty:      event(60 * 24 * time.Hour),
+		recent:     event(10 * 24 * time.Hour),
+	}
+	checkIn(createTestTicket(t, db, fixture.attended, createTestUser(t, db, "user")))
+	createTestTicket(t, db, fixture.attended, createTestUser(t, db, "user"))
+	createTestTicket(t, db, fixture.unattended, createTestUser(t, db, "user"))
+	checkIn(createTestTicket(t, db, fixture.recent, createTestUser(t, db, "user")))
+	return fixture
+}
+
+func TestPurgeExpiredEventDataDryRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		purgeTickets bool
+		want         jobs.RetentionReport
+	}{
+		{name: "attendance logs only", purgeTickets: false, want: jobs.RetentionReport{Events: 1, AttendanceLogs: 1}},
+		{name: "with tickets", purgeTickets: true, want: jobs.RetentionReport{Events: 2, AttendanceLogs: 1, Tickets: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			now := time.Now()
+			createRetentionFixture(t, db, now)
+
+			policy := jobs.RetentionPolicy{Window: 30 * 24 * time.Hour, PurgeTickets: tt.purgeTickets, DryRun: true}
+			report, err := jobs.PurgeExpiredEventData(db, policy, now)
+			if err != nil {
+				t.Fatalf("dry run: %v", err)
+			}
+			if report != tt.want {
+				t.Fatalf("dry run reported %+v, want %+v", report, tt.want)
+			}
+
+			var logs, tickets, snapshots int
+			db.Model(&models.AttendanceLog{}).Count(&logs)
+			db.Model(&models.Ticket{}).Count(&tickets)
+			db.Model(&models.EventSnapshot{}).Count(&snapshots)
+			if logs != 2 || tickets != 4 || snapshots != 0 {
+				t.Fatalf("dry run left %d attendance logs, %d tickets and %d snapshots, want 2, 4 and 0", logs, tickets, snapshots)
+			}
+		})
+	}
+}
+
+func TestPurgeExpiredEventDataKeepsSnapshots(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+	fixture := createRetentionFixture(t, db, now)
+
+	policy := jobs.RetentionPolicy{Window: 30 * 24 * time.Hour, PurgeTickets: true}
+	report, err := jobs.PurgeExpiredEventData(db, policy, now)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if want := (jobs.RetentionReport{Events: 2, AttendanceLogs: 1, Tickets: 3}); report != want {
+		t.Fatalf("purge reported %+v, want %+v", report, want)
+	}
+
+	var remaining []models.Ticket
+	db.Find(&remaining)
+	if len(remaining) != 1 || remaining[0].EventID != fixture.recent.ID {
+		t.Fatalf("%d tickets survived, want only the recent event's", len(remaining))
+	}
+	var logs int
+	db.Model(&models.AttendanceLog{}).Count(&logs)
+	if logs != 1 {
+		t.Fatalf("%d attendance logs survived, want the recent event's 1", logs)
+	}
+
+	var snapshots []models.EventSnapshot
+	db.Order("event_id asc").Find(&snapshots)
+	if len(snapshots) != 2 {
+		t.Fatalf("took %d snapshots, want 2", len(snapshots))
+	}
+	attended, unattended := snapshots[0], snapshots[1]
+	if attended.EventID != fixture.attended.ID || attended.Sold != 2 || attended.CheckedIn != 1 || attended.Revenue != 40 || !attended.TicketsPurged {
+		t.Errorf("snapshot of the attended event is %+v", attended)
+	}
+	if unattended.EventID != fixture.unattended.ID || unattended.Sold != 1 || unattended.CheckedIn != 0 || unattended.Revenue != 20 {
+		t.Errorf("snapshot of the unattended event is %+v", unattended)
+	}
+
+	// A second run finds nothing left to purge
+	if report, err := jobs.PurgeExpiredEventData(db, policy, now); err != nil || report.Events != 0 {
+		t.Fatalf("second purge reported %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// EventSales holds the ticket sales aggregate for one event
+type EventSales struct {
+	EventID   uint    `json:"event_id"`
+	Sold      int64   `json:"sold"`
+	CheckedIn int64   `json:"checked_in"`
+	Revenue   float64 `json:"revenue"`
+}
+
+// eventSales aggregates ticket sales for the given events, keyed by event ID. Reserved
+// tickets without a holder, held tickets not paid yet and cancelled tickets are not counted,
+// and events without sales are absent.
+func eventSales(db *gorm.DB, eventIDs []uint) (map[uint]EventSales, error) {
+	sales := map[uint]EventSales{}
+	if len(eventIDs) == 0 {
+		return sales, nil
+	}
+
+	var rows []EventSales
+	if err := db.Table("tickets").
+		Select("tickets.event_id, COUNT(*) AS sold, "+
+			"SUM(CASE WHEN tickets.status = 'used' THEN 1 ELSE 0 END) AS checked_in, "+
+			"COALESCE(SUM(events.price), 0) AS revenue").
+		Joins("JOIN events ON events.id = tickets.event_id").
+		Where("tickets.event_id IN (?) AND tickets.user_id IS NOT NULL AND tickets.status NOT IN ('cancelled', 'pending')", eventIDs).
+		Group("tickets.event_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		sales[row.EventID] = row
+	}
+	return sales, nil
+}
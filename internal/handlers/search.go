@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+)
+
+const (
+	defaultSearchLimit = 5
+	maxSearchLimit     = 20
+)
+
+// SearchResults holds the matches of an admin search, grouped by category
+type SearchResults struct {
+	Query   string           `json:"query"`
+	Events  []models.Event   `json:"events"`
+	Users   []models.User    `json:"users"`
+	Tickets []TicketResponse `json:"tickets"`
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied search term
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// Search looks up events by title, users by name or email and tickets by ID or QR code
+// prefix, returning at most ?limit= matches per category (admin only)
+func (h *AdminHandler) Search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Search query required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	contains := "%" + escapeLike(q) + "%"
+	results := SearchResults{
+		Query:  q,
+		Events: []models.Event{},
+		Users:  []models.User{},
+	}
+
+	if err := h.db.Where("title ILIKE ?", contains).Order(stableOrder("date desc", "id")).Limit(limit).Find(&results.Events).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search events")
+		return
+	}
+
+	if err := h.db.Where("name ILIKE ? OR email ILIKE ?", contains, contains).Order("id asc").Limit(limit).Find(&results.Users).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search users")
+		return
+	}
+
+	ticketQuery := h.db.Where("qr_code LIKE ?", escapeLike(q)+"%")
+	if id, err := strconv.ParseUint(q, 10, 32); err == nil {
+		ticketQuery = h.db.Where("id = ? OR qr_code LIKE ?", id, escapeLike(q)+"%")
+	}
+	var tickets []models.Ticket
+	if err := ticketQuery.Order("id asc").Limit(limit).Find(&tickets).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search tickets")
+		return
+	}
+	results.Tickets = newTicketResponses(tickets, nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestSearchCategorizesMatches(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAdminHandler(db)
+	admin := createTestUser(t, db, "admin")
+
+	user := createTestUser(t, db, "user")
+	db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("name", "Marigold Jones")
+	event := createTestEvent(t, db, 10, 20)
+	db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumn("title", "Marigold Festival")
+	createTestEvent(t, db, 10, 20)
+
+	w := httptest.NewRecorder()
+	h.Search(w, authedRequest("GET", "/api/admin/search?q=marigold", "", admin, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("search returned %d: %s", w.Code, w.Body.String())
+	}
+	var results SearchResults
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("decode results: %v", err)
+	}
+
+	if len(results.Events) != 1 || results.Events[0].ID != event.ID {
+		t.Errorf("events are %+v, want only event %d", results.Events, event.ID)
+	}
+	if len(results.Users) != 1 || results.Users[0].ID != user.ID {
+		t.Errorf("users are %+v, want only user %d", results.Users, user.ID)
+	}
+	if len(results.Tickets) != 0 {
+		t.Errorf("tickets are %+v, want none", results.Tickets)
+	}
+}
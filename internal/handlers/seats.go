@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// Statuses of a seat in a seat map
+const (
+	seatStatusAvailable = "available"
+	seatStatusReserved  = "reserved"
+)
+
+// maxSeatsPerRequest caps the number of seats that can be added to a seat map at once
+const maxSeatsPerRequest = 2000
+
+// SeatRequest is one seat added to an event's seat map
+type SeatRequest struct {
+	Section string `json:"section"`
+	Row     string `json:"row" binding:"required"`
+	Number  string `json:"number" binding:"required"`
+}
+
+// CreateSeatsRequest represents the add seats request payload
+type CreateSeatsRequest struct {
+	Seats []SeatRequest `json:"seats" binding:"required,min=1,dive"`
+}
+
+// hasSeatMap reports whether an event sells reserved seats, in which case purchases pick their
+// seats instead of being counted against capacity alone
+func hasSeatMap(db *gorm.DB, eventID uint) (bool, error) {
+	var count int
+	err := db.Model(&models.Seat{}).Where("event_id = ?", eventID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// parseSeatIDs removes duplicate seat IDs, keeping the order they were picked in
+func parseSeatIDs(ids []uint) []uint {
+	unique := make([]uint, 0, len(ids))
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// reserveSeats marks the seats reserved if all of them belong to the event and are still
+// available, reporting whether they were. The update is guarded on the status, so two
+// purchases cannot book the same seat.
+func reserveSeats(tx *gorm.DB, eventID uint, seatIDs []uint) (bool, error) {
+	result := tx.Model(&models.Seat{}).
+		Where("id IN (?) AND event_id = ? AND status = ?", seatIDs, eventID, seatStatusAvailable).
+		UpdateColumns(map[string]interface{}{"status": seatStatusReserved, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == int64(len(seatIDs)), nil
+}
+
+// assignSeat records the ticket holding a reserved seat
+func assignSeat(tx *gorm.DB, seatID, ticketID uint) error {
+	return tx.Model(&models.Seat{}).Where("id = ?", seatID).
+		UpdateColumns(map[string]interface{}{"ticket_id": ticketID, "updated_at": time.Now()}).Error
+}
+
+// releaseSeat makes the seat of a cancelled ticket available again
+func releaseSeat(tx *gorm.DB, seatID uint) error {
+	return tx.Model(&models.Seat{}).Where("id = ?", seatID).
+		UpdateColumns(map[string]interface{}{"status": seatStatusAvailable, "ticket_id": gorm.Expr("NULL"), "updated_at": time.Now()}).Error
+}
+
+// validateSeatRequests trims the seats of a request and rejects blank or repeated positions
+func validateSeatRequests(seats []SeatRequest) ([]SeatRequest, error) {
+	cleaned := make([]SeatRequest, 0, len(seats))
+	seen := map[SeatRequest]bool{}
+	for _, seat := range seats {
+		seat = SeatRequest{Section: strings.TrimSpace(seat.Section), Row: strings.TrimSpace(seat.Row), Number: strings.TrimSpace(seat.Number)}
+		if seat.Row == "" || seat.Number == "" {
+			return nil, errors.New("every seat needs a row and a number")
+		}
+		if seen[seat] {
+			return nil, fmt.Errorf("seat %s is listed twice", seatLabel(seat.Section, seat.Row, seat.Number))
+		}
+		seen[seat] = true
+		cleaned = append(cleaned, seat)
+	}
+	return cleaned, nil
+}
+
+// seatLabel names a seat for messages, for example "Balcony row C seat 12"
+func seatLabel(section, row, number string) string {
+	label := "row " + row + " seat " + number
+	if section != "" {
+		label = section + " " + label
+	}
+	return label
+}
+
+// GetSeats lists the seat map of an event with the availability of each seat
+func (h *EventHandler) GetSeats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	query := h.db.Where("event_id = ?", event.ID)
+	if status := r.URL.Query().Get("status"); status != "" {
+		if status != seatStatusAvailable && status != seatStatusReserved {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Status must be available or reserved")
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	seats := []models.Seat{}
+	if err := query.Order("section asc, row asc, number asc, id asc").Find(&seats).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve seats")
+		return
+	}
+
+	// Holders are private, only the availability of a seat is shown
+	available := 0
+	for i := range seats {
+		seats[i].TicketID = nil
+		if seats[i].Status == seatStatusAvailable {
+			available++
+		}
+	}
+
+	response := map[string]interface{}{
+		"event_id":  event.ID,
+		"available": available,
+		"reserved":  len(seats) - available,
+		"seats":     seats,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateSeats adds seats to the seat map of an event (admin only). Once an event has a seat
+// map, purchases must pick their seats. Limited events cannot have more seats than capacity.
+func (h *EventHandler) CreateSeats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var req CreateSeatsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if len(req.Seats) == 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "At least one seat is required")
+		return
+	}
+	if len(req.Seats) > maxSeatsPerRequest {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("At most %d seats can be added at once", maxSeatsPerRequest))
+		return
+	}
+	requested, err := validateSeatRequests(req.Seats)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the event so concurrent additions cannot together exceed its capacity
+	var event models.Event
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", eventID).First(&event).Error; err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	var existing []models.Seat
+	if err := tx.Where("event_id = ?", event.ID).Find(&existing).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve seats")
+		return
+	}
+	if !event.Unlimited && len(existing)+len(requested) > event.Capacity {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, fmt.Sprintf("The seat map cannot exceed the event capacity of %d", event.Capacity))
+		return
+	}
+
+	positions := map[SeatRequest]bool{}
+	for _, seat := range existing {
+		positions[SeatRequest{Section: seat.Section, Row: seat.Row, Number: seat.Number}] = true
+	}
+
+	seats := make([]models.Seat, 0, len(requested))
+	for _, position := range requested {
+		if positions[position] {
+			tx.Rollback()
+			respondError(w, http.StatusConflict, apierror.CodeAlreadyExists, fmt.Sprintf("Seat %s already exists", seatLabel(position.Section, position.Row, position.Number)))
+			return
+		}
+
+		seat := models.Seat{EventID: event.ID, Section: position.Section, Row: position.Row, Number: position.Number, Status: seatStatusAvailable}
+		if err := tx.Create(&seat).Error; err != nil {
+			tx.Rollback()
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create seats")
+			return
+		}
+		seats = append(seats, seat)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create seats")
+		return
+	}
+
+	recordAudit(h.db, r, "seats.created", "event", event.ID, fmt.Sprintf("%d seats", len(seats)))
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(seats)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// claimSoldCount atomically adds quantity to the event's sold count if it stays within
+// capacity (or the event is unlimited), reporting whether the seats were claimed. Call it inside the transaction
+// that creates the tickets so a failed purchase releases the seats on rollback.
+func claimSoldCount(tx *gorm.DB, eventID uint, quantity int) (bool, error) {
+	result := tx.Model(&models.Event{}).
+		Where("id = ? AND (unlimited OR sold_count + ? <= capacity)", eventID, quantity).
+		UpdateColumn("sold_count", gorm.Expr("sold_count + ?", quantity))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// releaseSoldCount subtracts quantity from the event's sold count when tickets are cancelled
+func releaseSoldCount(tx *gorm.DB, eventID uint, quantity int) error {
+	return tx.Model(&models.Event{}).Where("id = ?", eventID).
+		UpdateColumn("sold_count", gorm.Expr("GREATEST(sold_count - ?, 0)", quantity)).Error
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// soldCount returns the maintained sold count of the event
+func soldCount(t *testing.T, h *TicketHandler, event models.Event) int {
+	t.Helper()
+	var stored models.Event
+	if err := h.db.Where("id = ?", event.ID).First(&stored).Error; err != nil {
+		t.Fatalf("reload event: %v", err)
+	}
+	return stored.SoldCount
+}
+
+func TestSoldCountFollowsPurchaseAndCancel(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 3, 20)
+
+	if code := purchaseOne(h, event, buyer, `{"quantity": 2, "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase returned %d", code)
+	}
+	if got := soldCount(t, h, event); got != 2 {
+		t.Fatalf("sold count after buying 2 tickets is %d", got)
+	}
+
+	// A declined purchase claims no seats
+	if code := purchaseOne(h, event, buyer, `{"quantity": 1, "payment_token": "`+payment.FakeDeclineToken+`"}`); code != http.StatusPaymentRequired {
+		t.Fatalf("declined purchase returned %d", code)
+	}
+	if got := soldCount(t, h, event); got != 2 {
+		t.Fatalf("sold count after a declined purchase is %d, want 2", got)
+	}
+
+	var ticket models.Ticket
+	db.Where("event_id = ? AND status = ?", event.ID, "valid").First(&ticket)
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.CancelTicket(w, authedRequest("POST", "/api/tickets/"+vars["id"]+"/cancel", "", buyer, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancel returned %d: %s", w.Code, w.Body)
+	}
+	if got := soldCount(t, h, event); got != 1 {
+		t.Fatalf("sold count after cancelling a ticket is %d, want 1", got)
+	}
+
+	// The released seat can be bought again, up to the capacity
+	if code := purchaseOne(h, event, buyer, `{"quantity": 2, "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase of the released seat returned %d", code)
+	}
+	if code := purchaseOne(h, event, buyer, `{"quantity": 1, "payment_token": "tok_test"}`); code == http.StatusCreated {
+		t.Fatal("purchase over the capacity succeeded")
+	}
+	if got := soldCount(t, h, event); got != 3 {
+		t.Fatalf("sold count of a sold out event is %d, want 3", got)
+	}
+}
+
+func TestReconcileSoldCountsCorrectsDrift(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	holder := createTestUser(t, db, "user")
+	drifted := createTestEvent(t, db, 10, 20)
+	accurate := createTestEvent(t, db, 10, 20)
+	for i := 0; i < 2; i++ {
+		createTestTicket(t, db, drifted, holder)
+		createTestTicket(t, db, accurate, holder)
+	}
+	cancelled := createTestTicket(t, db, drifted, holder)
+	db.Model(&models.Ticket{}).Where("id = ?", cancelled.ID).UpdateColumn("status", "cancelled")
+	db.Model(&models.Event{}).Where("id = ?", drifted.ID).UpdateColumn("sold_count", 7)
+	db.Model(&models.Event{}).Where("id = ?", accurate.ID).UpdateColumn("sold_count", 2)
+
+	if _, err := jobs.ReconcileSoldCounts(db); err != nil {
+		t.Fatalf("ReconcileSoldCounts: %v", err)
+	}
+	if got := soldCount(t, h, drifted); got != 2 {
+		t.Fatalf("reconciled sold count is %d, want the 2 uncancelled tickets", got)
+	}
+	if got := soldCount(t, h, accurate); got != 2 {
+		t.Fatalf("accurate sold count changed to %d", got)
+	}
+
+	if corrected, err := jobs.ReconcileSoldCounts(db); err != nil || corrected != 0 {
+		t.Fatalf("second reconciliation corrected %d events, err %v, want none", corrected, err)
+	}
+}
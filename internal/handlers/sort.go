@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// eventSortKeys maps the ?sort= values accepted by event lists to their columns
+var eventSortKeys = map[string]string{
+	"date":       "date",
+	"price":      "price",
+	"title":      "title",
+	"created_at": "created_at",
+}
+
+// ticketSortKeys maps the ?sort= values accepted by ticket lists to their columns
+var ticketSortKeys = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"status":     "status",
+}
+
+// userSortKeys maps the ?sort= values accepted by the user list to their columns
+var userSortKeys = map[string]string{
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+}
+
+// stableOrder appends the primary key to an ORDER BY clause so rows that tie on the sort
+// columns come back in the same order on every page
+func stableOrder(clause, idColumn string) string {
+	if clause == "" {
+		return idColumn + " asc"
+	}
+	return clause + ", " + idColumn + " asc"
+}
+
+// parseSort reads ?sort= and ?order= (asc or desc, default asc) and returns a stable ORDER BY
+// clause. Without ?sort= the rows are ordered by defaultKey, or by ID alone when it is empty.
+func parseSort(r *http.Request, keys map[string]string, defaultKey, idColumn string) (string, error) {
+	key := r.URL.Query().Get("sort")
+	if key == "" {
+		key = defaultKey
+	}
+
+	direction := strings.ToLower(r.URL.Query().Get("order"))
+	if direction == "" {
+		direction = "asc"
+	}
+	if direction != "asc" && direction != "desc" {
+		return "", errors.New("order must be asc or desc")
+	}
+
+	if key == "" {
+		return stableOrder("", idColumn), nil
+	}
+
+	column, ok := keys[key]
+	if !ok {
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("invalid sort %q, expected one of %s", key, strings.Join(names, ", "))
+	}
+
+	return stableOrder(column+" "+direction, idColumn), nil
+}
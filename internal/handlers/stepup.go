@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/models"
+)
+
+// ReauthRequest represents the step-up re-authentication request payload
+type ReauthRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// stepUpSatisfied reports whether the request may perform a destructive action, which with
+// REQUIRE_STEP_UP enabled needs an elevated token
+func stepUpSatisfied(r *http.Request) bool {
+	return !auth.StepUpRequired() || r.Context().Value("elevated") == true
+}
+
+// Reauth verifies the current admin's password again and issues a short-lived elevated token
+// for destructive actions
+func (h *AuthHandler) Reauth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, ok := r.Context().Value("user").(models.User)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var req ReauthRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if !auth.CheckPassword(req.Password, user.Password) {
+		recordAudit(h.db, r, "admin.reauth_failed", "user", user.ID, "")
+		respondError(w, http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Invalid password")
+		return
+	}
+
+	token, err := auth.GenerateElevatedToken(user)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	recordAudit(h.db, r, "admin.reauth", "user", user.ID, "")
+
+	response := map[string]interface{}{
+		"token":      token,
+		"expires_at": time.Now().Add(auth.StepUpTokenTTL()),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/database"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+)
+
+// testQRSecret signs the QR payloads of tickets created by tests
+const testQRSecret = "test-qr-signing-secret-of-32-bytes!"
+
+// testJWTSecret signs the tokens issued in tests
+const testJWTSecret = "test-jwt-signing-secret-of-32-bytes"
+
+// openTestDB connects to the PostgreSQL database named by TEST_DATABASE_URL and migrates a
+// schema of its own, dropped when the test ends. Tests needing a database are skipped when
+// TEST_DATABASE_URL is not set.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := testDatabaseURL(t)
+	admin, err := gorm.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+		t.Fatalf("connect to test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	t.Setenv("QR_SIGNING_SECRET", testQRSecret)
+	if err := LoadQRSigningSecret(); err != nil {
+		t.Fatalf("load QR signing secret: %v", err)
+	}
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	if err := auth.LoadSecret(); err != nil {
+		t.Fatalf("load JWT secret: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test schema: %v", err)
+	}
+	return db
+}
+
+// testDatabaseURL returns TEST_DATABASE_URL, skipping the test when it is not set
+func testDatabaseURL(t *testing.T) string {
+	t.Helper()
+	dsn := strings.TrimSpace(os.Getenv("TEST_DATABASE_URL"))
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	return dsn
+}
+
+// withSearchPath points a connection string, in URL or key=value form, at a schema
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+// createTestUser inserts a user with the given role
+func createTestUser(t *testing.T, db *gorm.DB, role string) models.User {
+	t.Helper()
+	user := models.User{
+		Name:     role,
+		Email:    fmt.Sprintf("%s-%d@example.com", role, time.Now().UnixNano()),
+		Password: "not-a-real-hash",
+		Role:     role,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// createTestEvent inserts an active event a week away with the given capacity and no ticket
+// types
+func createTestEvent(t *testing.T, db *gorm.DB, capacity int, price float64) models.Event {
+	t.Helper()
+	event := models.Event{
+		Title:       "Test event",
+		Description: "Test event",
+		Date:        time.Now().Add(7 * 24 * time.Hour),
+		Location:    "Test venue",
+		Capacity:    capacity,
+		Price:       price,
+		Status:      "active",
+	}
+	if err := db.Create(&event).Error; err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	return event
+}
+
+// createTestTicket inserts a valid ticket held by the user and counts it as sold
+func createTestTicket(t *testing.T, db *gorm.DB, event models.Event, user models.User) models.Ticket {
+	t.Helper()
+	ticket := models.Ticket{EventID: event.ID, UserID: &user.ID, Status: "valid", PricePaid: event.Price, AmountCharged: event.Price}
+	if err := createTicketWithUniqueQR(db, &ticket); err != nil {
+		t.Fatalf("create ticket: %v", err)
+	}
+	if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumn("sold_count", gorm.Expr("sold_count + 1")).Error; err != nil {
+		t.Fatalf("count ticket as sold: %v", err)
+	}
+	return ticket
+}
+
+// authedRequest builds a request as the authentication middleware leaves it for the user,
+// with the given route variables
+func authedRequest(method, target, body string, user models.User, vars map[string]string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	ctx := context.WithValue(r.Context(), "user_id", user.ID)
+	ctx = context.WithValue(ctx, "user_role", user.Role)
+	ctx = context.WithValue(ctx, "user", user)
+	r = r.WithContext(ctx)
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	return r
+}
+
+// responseErrorCode returns the code of the error envelope in a recorded response
+func responseErrorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var envelope apierror.Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode error response %q: %v", w.Body.String(), err)
+	}
+	return envelope.Error.Code
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/pdf"
+	"event-ticketing-system/pkg/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// ticketPDFQRSize is the width and height in millimetres of the QR code on printed tickets
+const ticketPDFQRSize = 70
+
+// PrintableTicket holds what is printed on a ticket
+type PrintableTicket struct {
+	TicketID   uint
+	EventTitle string
+	EventDate  time.Time
+	Location   string
+	Attendee   string
+	Seat       string // empty for events without a seat map
+	QRPayload  string
+}
+
+// TicketRenderer renders a printable ticket as a document
+type TicketRenderer interface {
+	RenderTicket(ticket PrintableTicket) ([]byte, error)
+}
+
+// pdfTicketRenderer renders tickets as one page PDFs with the QR code below the details
+type pdfTicketRenderer struct{}
+
+// RenderTicket renders the ticket as a one page PDF
+func (pdfTicketRenderer) RenderTicket(ticket PrintableTicket) ([]byte, error) {
+	qr, err := utils.RenderQRCodePNG(ticket.QRPayload, qrImageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+	doc.Heading(ticket.EventTitle)
+	doc.Text(fmt.Sprintf("Date: %s", format.Date(ticket.EventDate)))
+	doc.Text(fmt.Sprintf("Location: %s", ticket.Location))
+	doc.Space(4)
+	doc.Text(fmt.Sprintf("Attendee: %s", ticket.Attendee))
+	if ticket.Seat != "" {
+		doc.Text(fmt.Sprintf("Seat: %s", ticket.Seat))
+	}
+	doc.Text(fmt.Sprintf("Ticket #%d", ticket.TicketID))
+	doc.Space(8)
+
+	if err := doc.Image("qr", qr, ticketPDFQRSize); err != nil {
+		return nil, err
+	}
+
+	return doc.Bytes()
+}
+
+// ticketRenderer is the renderer used for ticket downloads, replaceable in tests
+var ticketRenderer TicketRenderer = pdfTicketRenderer{}
+
+// GetTicketPDF serves a printable PDF of the ticket with its QR code. Only the holder or an
+// admin may download it; other callers get a 404.
+func (h *TicketHandler) GetTicketPDF(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	if r.Context().Value("user_id") == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	ticket, err := h.findAccessibleTicket(r, ticketID)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", ticket.EventID).First(&event).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	printable := PrintableTicket{
+		TicketID:   ticket.ID,
+		EventTitle: event.Title,
+		EventDate:  event.Date,
+		Location:   event.Location,
+		QRPayload:  ticket.QRCode,
+	}
+
+	if ticket.UserID != nil {
+		var holder models.User
+		if err := h.db.Where("id = ?", *ticket.UserID).First(&holder).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket holder")
+			return
+		}
+		printable.Attendee = holder.Name
+	}
+
+	if ticket.SeatID != nil {
+		var seat models.Seat
+		if err := h.db.Where("id = ?", *ticket.SeatID).First(&seat).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve seat")
+			return
+		}
+		printable.Seat = seatLabel(seat.Section, seat.Row, seat.Number)
+	}
+
+	body, err := ticketRenderer.RenderTicket(printable)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to render ticket")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=ticket_%d.pdf", ticket.ID))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
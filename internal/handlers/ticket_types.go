@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// generalTicketTypeName names the ticket type created from an event's legacy price and capacity
+const generalTicketTypeName = "General"
+
+// errTicketTypeRequired is returned when an event has several ticket types and none was chosen
+var errTicketTypeRequired = errors.New("ticket_type_id is required for events with several ticket types")
+
+// TicketTypeRequest represents a ticket type in the create and update event payloads. On
+// update, types with an ID change the existing type and types without one are added.
+type TicketTypeRequest struct {
+	ID       *uint   `json:"id"`
+	Name     string  `json:"name" binding:"required"`
+	Price    float64 `json:"price" binding:"min=0"`
+	Capacity int     `json:"capacity" binding:"required,min=1"`
+}
+
+// validateTicketTypes checks the ticket types of a request, requiring unique names, a price of
+// at least 0 and a capacity of at least 1
+func validateTicketTypes(types []TicketTypeRequest) error {
+	names := map[string]bool{}
+	for _, ticketType := range types {
+		name := strings.TrimSpace(ticketType.Name)
+		if name == "" {
+			return errors.New("ticket type name is required")
+		}
+		if names[strings.ToLower(name)] {
+			return fmt.Errorf("duplicate ticket type name: %s", name)
+		}
+		names[strings.ToLower(name)] = true
+
+		if ticketType.Price < 0 {
+			return fmt.Errorf("ticket type %s must have a price of at least 0", name)
+		}
+		if ticketType.Capacity < 1 {
+			return fmt.Errorf("ticket type %s must have a capacity of at least 1", name)
+		}
+	}
+	return nil
+}
+
+// ticketTypeTotals returns the combined capacity and the lowest price of ticket types, which
+// become the event's capacity and its advertised price
+func ticketTypeTotals(types []models.TicketType) (int, float64) {
+	capacity := 0
+	price := 0.0
+	for i, ticketType := range types {
+		capacity += ticketType.Capacity
+		if i == 0 || ticketType.Price < price {
+			price = ticketType.Price
+		}
+	}
+	return capacity, price
+}
+
+// planTicketTypes applies the requested ticket types to an event's existing ones. Listed types
+// with an ID are updated, the others are added, and existing types left out are removed. A
+// type cannot drop below the tickets it has sold, and a type with sold tickets cannot be
+// removed.
+func planTicketTypes(existing []models.TicketType, sold map[uint]int, requested []TicketTypeRequest) ([]models.TicketType, []uint, error) {
+	byID := map[uint]models.TicketType{}
+	for _, ticketType := range existing {
+		byID[ticketType.ID] = ticketType
+	}
+
+	kept := map[uint]bool{}
+	types := make([]models.TicketType, 0, len(requested))
+	for _, req := range requested {
+		ticketType := models.TicketType{}
+		if req.ID != nil {
+			current, ok := byID[*req.ID]
+			if !ok {
+				return nil, nil, fmt.Errorf("ticket type %d does not belong to this event", *req.ID)
+			}
+			if req.Capacity < sold[current.ID] {
+				return nil, nil, fmt.Errorf("ticket type %s cannot have a capacity lower than its %d tickets sold", current.Name, sold[current.ID])
+			}
+			ticketType = current
+			kept[current.ID] = true
+		}
+
+		ticketType.Name = strings.TrimSpace(req.Name)
+		ticketType.Price = req.Price
+		ticketType.Capacity = req.Capacity
+		types = append(types, ticketType)
+	}
+
+	var removed []uint
+	for _, ticketType := range existing {
+		if kept[ticketType.ID] {
+			continue
+		}
+		if sold[ticketType.ID] > 0 {
+			return nil, nil, fmt.Errorf("ticket type %s has tickets sold and cannot be removed", ticketType.Name)
+		}
+		removed = append(removed, ticketType.ID)
+	}
+	return types, removed, nil
+}
+
+// planEventTicketType carries a price or capacity change made on the event itself over to its
+// single ticket type, which purchases are priced and capped by. An event made unlimited loses
+// that type, as a type cannot express unlimited capacity, and a limited event without a type gets
+// the General one CreateEvent would have created. Events with several types must change them
+// through ticket_types instead.
+func planEventTicketType(existing []models.TicketType, sold map[uint]int, event models.Event, priceOrCapacity bool) ([]models.TicketType, []uint, error) {
+	if len(existing) > 1 {
+		if priceOrCapacity {
+			return nil, nil, errors.New("event has several ticket types, change their price and capacity with ticket_types")
+		}
+		return nil, nil, nil
+	}
+
+	if event.Unlimited {
+		if len(existing) == 0 {
+			return nil, nil, nil
+		}
+		if sold[existing[0].ID] > 0 {
+			return nil, nil, fmt.Errorf("ticket type %s has tickets sold, the event cannot be made unlimited", existing[0].Name)
+		}
+		return nil, []uint{existing[0].ID}, nil
+	}
+
+	if len(existing) == 0 {
+		return []models.TicketType{{EventID: event.ID, Name: generalTicketTypeName, Price: event.Price, Capacity: event.Capacity}}, nil, nil
+	}
+
+	ticketType := existing[0]
+	if event.Capacity < sold[ticketType.ID] {
+		return nil, nil, fmt.Errorf("ticket type %s cannot have a capacity lower than its %d tickets sold", ticketType.Name, sold[ticketType.ID])
+	}
+	ticketType.Price = event.Price
+	ticketType.Capacity = event.Capacity
+	return []models.TicketType{ticketType}, nil, nil
+}
+
+// ticketTypeSoldCounts counts the uncancelled tickets of each ticket type of an event
+func ticketTypeSoldCounts(db *gorm.DB, eventID uint) (map[uint]int, error) {
+	var rows []struct {
+		TicketTypeID uint
+		Sold         int
+	}
+	if err := db.Table("tickets").Select("ticket_type_id, COUNT(*) AS sold").
+		Where("event_id = ? AND ticket_type_id IS NOT NULL AND status <> ?", eventID, "cancelled").
+		Group("ticket_type_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	sold := map[uint]int{}
+	for _, row := range rows {
+		sold[row.TicketTypeID] = row.Sold
+	}
+	return sold, nil
+}
+
+// findTicketType loads a ticket type of the event, or returns nil when id is nil
+func findTicketType(db *gorm.DB, eventID uint, id *uint) (*models.TicketType, error) {
+	if id == nil {
+		return nil, nil
+	}
+
+	var ticketType models.TicketType
+	if err := db.Where("id = ? AND event_id = ?", *id, eventID).First(&ticketType).Error; err != nil {
+		return nil, err
+	}
+	return &ticketType, nil
+}
+
+// resolveTicketType returns the ticket type a purchase is for: the requested one, the only
+// type of the event when none was requested, or nil for events without ticket types
+func resolveTicketType(db *gorm.DB, eventID uint, id *uint) (*models.TicketType, error) {
+	if id != nil {
+		return findTicketType(db, eventID, id)
+	}
+
+	var types []models.TicketType
+	if err := db.Where("event_id = ?", eventID).Limit(2).Find(&types).Error; err != nil {
+		return nil, err
+	}
+	switch len(types) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &types[0], nil
+	default:
+		return nil, errTicketTypeRequired
+	}
+}
+
+// claimTicketTypeCapacity locks a ticket type and reports whether quantity more tickets fit
+// within its capacity. Call it inside the purchase transaction so concurrent purchases of the
+// same type are counted one after the other.
+func claimTicketTypeCapacity(tx *gorm.DB, ticketType models.TicketType, quantity int) (bool, error) {
+	var locked models.TicketType
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", ticketType.ID).First(&locked).Error; err != nil {
+		return false, err
+	}
+
+	var sold int
+	if err := tx.Model(&models.Ticket{}).Where("ticket_type_id = ? AND status <> ?", ticketType.ID, "cancelled").
+		Count(&sold).Error; err != nil {
+		return false, err
+	}
+	return sold+quantity <= locked.Capacity, nil
+}
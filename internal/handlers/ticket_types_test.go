@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"testing"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestPlanEventTicketType(t *testing.T) {
+	general := models.TicketType{ID: 7, EventID: 1, Name: generalTicketTypeName, Price: 10, Capacity: 100}
+	vip := models.TicketType{ID: 8, EventID: 1, Name: "VIP", Price: 50, Capacity: 20}
+
+	tests := []struct {
+		name            string
+		existing        []models.TicketType
+		sold            map[uint]int
+		event           models.Event
+		priceOrCapacity bool
+		wantTypes       []models.TicketType
+		wantRemoved     []uint
+		wantErr         bool
+	}{
+		{
+			name:            "single type follows the event price and capacity",
+			existing:        []models.TicketType{general},
+			sold:            map[uint]int{7: 30},
+			event:           models.Event{ID: 1, Price: 15, Capacity: 80},
+			priceOrCapacity: true,
+			wantTypes:       []models.TicketType{{ID: 7, EventID: 1, Name: generalTicketTypeName, Price: 15, Capacity: 80}},
+		},
+		{
+			name:            "single type cannot drop below its sales",
+			existing:        []models.TicketType{general},
+			sold:            map[uint]int{7: 30},
+			event:           models.Event{ID: 1, Price: 10, Capacity: 20},
+			priceOrCapacity: true,
+			wantErr:         true,
+		},
+		{
+			name:            "several types must change through ticket_types",
+			existing:        []models.TicketType{general, vip},
+			event:           models.Event{ID: 1, Price: 5, Capacity: 120},
+			priceOrCapacity: true,
+			wantErr:         true,
+		},
+		{
+			name:     "several types are left alone when only the unlimited flag is removed",
+			existing: []models.TicketType{general, vip},
+			event:    models.Event{ID: 1, Price: 10, Capacity: 120},
+		},
+		{
+			name:        "unlimited event drops its unsold type",
+			existing:    []models.TicketType{general},
+			event:       models.Event{ID: 1, Unlimited: true},
+			wantRemoved: []uint{7},
+		},
+		{
+			name:     "unlimited event keeps a type with sales",
+			existing: []models.TicketType{general},
+			sold:     map[uint]int{7: 1},
+			event:    models.Event{ID: 1, Unlimited: true},
+			wantErr:  true,
+		},
+		{
+			name:      "limited event without a type gets the General one",
+			event:     models.Event{ID: 1, Price: 12, Capacity: 40},
+			wantTypes: []models.TicketType{{EventID: 1, Name: generalTicketTypeName, Price: 12, Capacity: 40}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types, removed, err := planEventTicketType(tt.existing, tt.sold, tt.event, tt.priceOrCapacity)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got types %+v removed %v", types, removed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(types) != len(tt.wantTypes) {
+				t.Fatalf("got %d types, want %d", len(types), len(tt.wantTypes))
+			}
+			for i := range types {
+				if types[i] != tt.wantTypes[i] {
+					t.Errorf("type %d = %+v, want %+v", i, types[i], tt.wantTypes[i])
+				}
+			}
+			if len(removed) != len(tt.wantRemoved) {
+				t.Fatalf("removed %v, want %v", removed, tt.wantRemoved)
+			}
+			for i := range removed {
+				if removed[i] != tt.wantRemoved[i] {
+					t.Errorf("removed %v, want %v", removed, tt.wantRemoved)
+				}
+			}
+		})
+	}
+}
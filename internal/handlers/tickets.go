@@ -1,34 +1,49 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/database"
 	"event-ticketing-system/internal/models"
-	"event-ticketing-system/pkg/utils"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
 
 	"github.com/gorilla/mux"
 	"github.com/jinzhu/gorm"
 )
 
-
 // TicketHandler handles ticket related requests
 type TicketHandler struct {
-	db *gorm.DB
+	db              *gorm.DB
+	webhooks        webhook.Notifier
+	mailer          mailer.Sender
+	payments        payment.PaymentProcessor
+	purchaseLimiter *purchaseRateLimiter
 }
 
 // NewTicketHandler creates a new ticket handler
-func NewTicketHandler(db *gorm.DB) *TicketHandler {
-	return &TicketHandler{db: db}
+func NewTicketHandler(db *gorm.DB, notifier webhook.Notifier, sender mailer.Sender, processor payment.PaymentProcessor) *TicketHandler {
+	return &TicketHandler{db: db, webhooks: notifier, mailer: sender, payments: processor, purchaseLimiter: newPurchaseRateLimiter()}
 }
 
 // PurchaseTicketRequest represents the purchase ticket request payload
 type PurchaseTicketRequest struct {
-	Quantity int `json:"quantity" binding:"required,min=1,max=10"`
+	Quantity     int                    `json:"quantity" binding:"required,min=1,max=10"`
+	TicketTypeID *uint                  `json:"ticket_type_id"` // required when the event has several ticket types
+	CustomFields map[string]interface{} `json:"custom_fields"`  // answers to the event's custom fields, keyed by field name
+	PromoCode    string                 `json:"promo_code"`
+	PaymentToken string                 `json:"payment_token"` // required unless the order is free or held
+	SeatIDs      []uint                 `json:"seat_ids"`      // required for events with a seat map, one per ticket
+
+	// Hold reserves the tickets without paying; the order is paid with PayOrder before it expires
+	Hold bool `json:"hold"`
 }
 
 // GetTickets retrieves tickets for the current user or all tickets (admin)
@@ -37,33 +52,43 @@ func (h *TicketHandler) GetTickets(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Context().Value("user_id")
 	if userID == nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User not authenticated"})
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
 		return
 	}
 
 	userRole := r.Context().Value("user_role")
 
+	expand, err := parseExpand(r, ticketExpansions, "")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	order, err := parseSort(r, ticketSortKeys, "", "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
 	var tickets []models.Ticket
+	query := preloadExpansions(h.db, expand, ticketExpansions).Order(order)
 
 	if userRole == "admin" {
 		// Admin can see all tickets
-		if err := h.db.Preload("Event").Preload("User").Preload("AttendanceLogs").Find(&tickets).Error; err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve tickets"})
+		if err := query.Find(&tickets).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
 			return
 		}
 	} else {
 		// Regular users can only see their own tickets
-		if err := h.db.Preload("Event").Preload("AttendanceLogs").Where("user_id = ?", userID).Find(&tickets).Error; err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve tickets"})
+		if err := query.Where("user_id = ?", userID).Find(&tickets).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
 			return
 		}
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(tickets)
+	json.NewEncoder(w).Encode(newTicketResponses(tickets, expand))
 }
 
 // GetTicket retrieves a specific ticket by ID
@@ -75,22 +100,26 @@ func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 	ticketID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ticket ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
 		return
 	}
 
 	userID := r.Context().Value("user_id")
 	if userID == nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User not authenticated"})
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
 		return
 	}
 
 	userRole := r.Context().Value("user_role")
 
+	expand, err := parseExpand(r, ticketExpansions, "")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
 	var ticket models.Ticket
-	query := h.db.Preload("Event").Preload("User").Preload("AttendanceLogs")
+	query := preloadExpansions(h.db, expand, ticketExpansions)
 
 	if userRole == "admin" {
 		// Admin can see any ticket
@@ -102,17 +131,30 @@ func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 
 	if err := query.First(&ticket).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Ticket not found"})
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve ticket"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(ticket)
+	json.NewEncoder(w).Encode(newTicketResponse(ticket, expand))
+}
+
+// remainingUserAllowance returns how many more tickets a user may buy for an event with a
+// per-user limit. Tickets the user holds count toward it, except cancelled ones.
+func remainingUserAllowance(tx *gorm.DB, event models.Event, userID uint) (int, error) {
+	var held int
+	if err := tx.Model(&models.Ticket{}).
+		Where("event_id = ? AND user_id = ? AND status <> ?", event.ID, userID, "cancelled").
+		Count(&held).Error; err != nil {
+		return 0, err
+	}
+	if held >= event.MaxPerUser {
+		return 0, nil
+	}
+	return event.MaxPerUser - held, nil
 }
 
 // PurchaseTicket handles ticket purchase for an event
@@ -124,91 +166,404 @@ func (h *TicketHandler) PurchaseTicket(w http.ResponseWriter, r *http.Request) {
 	eventID := vars["id"]
 	eventIDUint, err := strconv.ParseUint(eventID, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid event ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
 		return
 	}
 
 	userID := r.Context().Value("user_id")
 	if userID == nil {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User not authenticated"})
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
 		return
 	}
 
 	var req PurchaseTicketRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
 		return
 	}
 
+	// A retried purchase with the same Idempotency-Key gets the original order back instead
+	// of buying the tickets again
+	key, err := idempotencyKey(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+	if key != "" && h.replayIdempotentPurchase(w, userID.(uint), key, uint(eventIDUint), req) {
+		return
+	}
+	fingerprint := purchaseFingerprint(uint(eventIDUint), req)
+
 	// Check if event exists
 	var event models.Event
 	if err := h.db.Where("id = ?", eventIDUint).First(&event).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Event not found"})
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve event"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if event.Status == "cancelled" {
+		recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureEventCancelled)
+		respondError(w, http.StatusBadRequest, apierror.CodeEventCancelled, "Cannot purchase tickets for cancelled events")
 		return
 	}
 
 	// Check if event date is in the future
 	if event.Date.Before(time.Now()) {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot purchase tickets for past events"})
+		recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailurePastEvent)
+		respondError(w, http.StatusBadRequest, apierror.CodeEventPast, "Cannot purchase tickets for past events")
+		return
+	}
+
+	// Throttle purchases of events with a rate limit before they reach the capacity check.
+	// Throttled attempts are not recorded as failures so a burst does not flood that table.
+	if ok, wait := h.purchaseLimiter.allow(event.ID, event.PurchaseRateLimit, time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+		respondError(w, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many purchases for this event, please retry shortly")
+		return
+	}
+
+	// Validate the answers to the event's custom registration fields
+	var customFields []models.CustomField
+	if err := h.db.Where("event_id = ?", event.ID).Find(&customFields).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve custom fields")
+		return
+	}
+	answers, err := validateCustomFields(customFields, req.CustomFields)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+
+	ticketType, err := resolveTicketType(h.db, event.ID, req.TicketTypeID)
+	if err != nil {
+		if err == errTicketTypeRequired {
+			respondError(w, http.StatusBadRequest, apierror.CodeTicketTypeRequired, err.Error())
+			return
+		}
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketTypeNotFound, "Ticket type not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket type")
+		return
+	}
+
+	promo, err := findPromoCode(h.db, event.ID, req.PromoCode, time.Now())
+	if err != nil {
+		if err == errInvalidPromoCode {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidPromoCode, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve promo code")
+		return
+	}
+
+	// Events with a seat map sell the picked seats, one ticket each; the others are counted
+	// against capacity alone
+	seated, err := hasSeatMap(h.db, event.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve seats")
+		return
+	}
+	seatIDs := parseSeatIDs(req.SeatIDs)
+	if seated {
+		if len(seatIDs) == 0 {
+			respondError(w, http.StatusBadRequest, apierror.CodeSeatRequired, "seat_ids are required for events with a seat map")
+			return
+		}
+		if req.Quantity == 0 {
+			req.Quantity = len(seatIDs)
+		}
+		if req.Quantity != len(seatIDs) {
+			respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Quantity must match the number of seats")
+			return
+		}
+	} else if len(seatIDs) > 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "This event has no seat map")
+		return
+	}
+
+	// Price the purchase the same way the quote endpoint does
+	quote, err := quotePurchase(event, ticketType, req.Quantity, promo, taxRate())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, err.Error())
+		return
+	}
+	if quote.Total > 0 && !req.Hold && strings.TrimSpace(req.PaymentToken) == "" {
+		respondError(w, http.StatusBadRequest, apierror.CodePaymentTokenRequired, "payment_token is required")
 		return
 	}
 
 	// Check available capacity
-	var existingTicketsCount int64
-	h.db.Model(&models.Ticket{}).Where("event_id = ?", eventIDUint).Count(&existingTicketsCount)
-	availableCapacity := event.Capacity - int(existingTicketsCount)
+	if !event.Unlimited && req.Quantity > event.Capacity-event.SoldCount {
+		recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureSoldOut)
+		respondError(w, http.StatusBadRequest, apierror.CodeCapacityExceeded, "Not enough tickets available")
+		return
+	}
 
-	if req.Quantity > availableCapacity {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Not enough tickets available"})
+	tx := h.db.Begin()
+
+	// Claim the seats on the sold count, which fails if a concurrent purchase took them first.
+	// The check above passed, so a failed claim means the seats were sold in the meantime.
+	claimed, err := claimSoldCount(tx, event.ID, req.Quantity)
+	if err != nil {
+		tx.Rollback()
+		recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+		return
+	}
+	if !claimed {
+		tx.Rollback()
+		recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureSoldOut)
+		respondError(w, http.StatusConflict, apierror.CodeCapacityExceeded, "Tickets were sold to another buyer while processing your purchase")
 		return
 	}
 
-	// Generate tickets
-	var tickets []models.Ticket
-	for i := 0; i < req.Quantity; i++ {
-		// Generate unique QR code using utility function
-		qrCode, err := utils.GenerateQRCode(uint(eventIDUint), userID.(uint), uint(i+1))
+	// Each ticket type has its own capacity within the event's
+	if ticketType != nil {
+		fits, err := claimTicketTypeCapacity(tx, *ticketType, req.Quantity)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to generate QR code"})
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+			return
+		}
+		if !fits {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureSoldOut)
+			respondError(w, http.StatusBadRequest, apierror.CodeCapacityExceeded, fmt.Sprintf("Not enough %s tickets available", ticketType.Name))
 			return
 		}
+	}
 
+	// Book the picked seats; the update only takes seats still available, so a seat sold to a
+	// concurrent purchase fails the whole order
+	if seated {
+		reserved, err := reserveSeats(tx, event.ID, seatIDs)
+		if err != nil {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve seats")
+			return
+		}
+		if !reserved {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureSoldOut)
+			respondError(w, http.StatusConflict, apierror.CodeSeatUnavailable, "One or more seats are not available")
+			return
+		}
+	}
+
+	// Enforce the per-user limit across purchases. The claim above locked the event row, so
+	// concurrent purchases by the same user are counted one after the other.
+	if event.MaxPerUser > 0 {
+		allowance, err := remainingUserAllowance(tx, event, userID.(uint))
+		if err != nil {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+			return
+		}
+		if req.Quantity > allowance {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureUserLimit)
+			respondError(w, http.StatusBadRequest, apierror.CodePurchaseLimit,
+				fmt.Sprintf("Each user may buy at most %d tickets for this event, %d remaining", event.MaxPerUser, allowance))
+			return
+		}
+	}
+
+	// Read back the claimed count inside the transaction, so exactly one purchase sees the
+	// availability cross the low inventory threshold
+	threshold := lowInventoryThreshold()
+	lowInventory, remaining := false, 0
+	if threshold > 0 && !event.Unlimited {
+		var claimedEvent models.Event
+		if err := tx.Select("capacity, sold_count").Where("id = ?", event.ID).First(&claimedEvent).Error; err != nil {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve tickets")
+			return
+		}
+		remaining = claimedEvent.Capacity - claimedEvent.SoldCount
+		lowInventory = crossedLowInventory(remaining, req.Quantity, threshold)
+	}
+
+	holderID := userID.(uint)
+	order := models.Order{
+		UserID:   holderID,
+		EventID:  event.ID,
+		Quantity: req.Quantity,
+		Discount: quote.Discount,
+		TaxRate:  quote.TaxRate,
+		Total:    quote.Total,
+		Status:   orderStatusFor(quote.Total),
+	}
+	if order.Status == orderStatusPending {
+		expiresAt := time.Now().Add(reservationTTL(event))
+		order.ExpiresAt = &expiresAt
+	}
+	if key != "" {
+		order.IdempotencyKey = &key
+		order.IdempotencyFingerprint = fingerprint
+	}
+
+	// Count the promo code use once the seats are claimed, so a sold out purchase does not use it
+	if promo != nil {
+		claimed, err := claimPromoCodeUse(tx, promo.ID)
+		if err != nil {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, holderID, req.Quantity, purchaseFailureError)
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to apply promo code")
+			return
+		}
+		if !claimed {
+			tx.Rollback()
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidPromoCode, errInvalidPromoCode.Error())
+			return
+		}
+		order.PromoCodeID = &promo.ID
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		// A concurrent request with the same key placed the order first
+		if key != "" && database.IsUniqueViolation(err) {
+			if !h.replayIdempotentPurchase(w, holderID, key, event.ID, req) {
+				respondError(w, http.StatusConflict, apierror.CodeConflict, "A purchase with this Idempotency-Key is in progress, please retry")
+			}
+			return
+		}
+		recordPurchaseFailure(h.db, event.ID, holderID, req.Quantity, purchaseFailureError)
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create order")
+		return
+	}
+
+	// Generate tickets, recording the share of the order total each one is charged. Tickets of
+	// an order to be paid are held as pending until its charge is confirmed.
+	prices := make([]float64, req.Quantity)
+	for i := range prices {
+		prices[i] = quote.UnitPrice
+	}
+	charges := splitOrderTotal(order.Total, prices)
+	ticketStatus := "valid"
+	if order.Status == orderStatusPending {
+		ticketStatus = "pending"
+	}
+
+	var tickets []models.Ticket
+	for i := 0; i < req.Quantity; i++ {
 		ticket := models.Ticket{
-			EventID: uint(eventIDUint),
-			UserID:  userID.(uint),
-			QRCode:  qrCode,
-			Status:  "valid",
+			EventID:       uint(eventIDUint),
+			UserID:        &holderID,
+			OrderID:       &order.ID,
+			TicketTypeID:  quote.TicketTypeID,
+			Status:        ticketStatus,
+			PricePaid:     quote.UnitPrice,
+			AmountCharged: charges[i],
+		}
+		if seated {
+			ticket.SeatID = &seatIDs[i]
 		}
 
-		if err := h.db.Create(&ticket).Error; err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create ticket"})
+		// Insert with a unique QR payload, retrying on the rare payload collision
+		if err := createTicketWithUniqueQRInTx(tx, &ticket); err != nil {
+			tx.Rollback()
+			recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create ticket")
 			return
 		}
+		if ticket.SeatID != nil {
+			if err := assignSeat(tx, *ticket.SeatID, ticket.ID); err != nil {
+				tx.Rollback()
+				recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reserve seats")
+				return
+			}
+		}
+
+		// Store the custom field answers for every ticket of the purchase
+		for fieldID, value := range answers {
+			answer := models.TicketField{TicketID: ticket.ID, CustomFieldID: fieldID, Value: value}
+			if err := tx.Create(&answer).Error; err != nil {
+				tx.Rollback()
+				recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+				respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save custom fields")
+				return
+			}
+			ticket.Fields = append(ticket.Fields, answer)
+		}
 
 		tickets = append(tickets, ticket)
 	}
 
-	response := map[string]interface{}{
-		"message": "Tickets purchased successfully",
-		"tickets": tickets,
-		"total":   len(tickets),
+	// Commit the reservation before charging, so the event row is not locked for the payment
+	// round trip
+	if err := tx.Commit().Error; err != nil {
+		recordPurchaseFailure(h.db, event.ID, userID.(uint), req.Quantity, purchaseFailureError)
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create ticket")
+		return
+	}
+
+	if lowInventory {
+		h.notifyLowInventory(event.ID, remaining, threshold)
 	}
 
+	// A held order keeps its tickets pending until it is paid
+	if order.Status == orderStatusPending && req.Hold {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(purchaseResponse(order, tickets, quote))
+		return
+	}
+
+	if order.Status == orderStatusPending && !h.chargeOrder(w, r, &order, tickets, req.PaymentToken, true) {
+		return
+	}
+	h.queueTicketsPurchased(order, tickets)
+
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(purchaseResponse(order, tickets, quote))
+}
+
+// queueTicketsPurchased queues the ticket_purchased webhook of a paid order
+func (h *TicketHandler) queueTicketsPurchased(order models.Order, tickets []models.Ticket) {
+	ticketIDs := make([]uint, 0, len(tickets))
+	for _, ticket := range tickets {
+		ticketIDs = append(ticketIDs, ticket.ID)
+	}
+	queueWebhookDeliveries(h.db, ticketPurchasedWebhookEvent, TicketPurchasedData{
+		OrderID:   order.ID,
+		EventID:   order.EventID,
+		UserID:    order.UserID,
+		Quantity:  order.Quantity,
+		Total:     order.Total,
+		TicketIDs: ticketIDs,
+	})
+}
+
+// purchaseResponse is the body of a successful purchase, or of a hold still to be paid
+func purchaseResponse(order models.Order, tickets []models.Ticket, quote PriceQuote) map[string]interface{} {
+	message := "Tickets purchased successfully"
+	if order.Status == orderStatusPending {
+		message = "Tickets reserved, pay for the order before it expires"
+	}
+	return map[string]interface{}{
+		"message":      message,
+		"order":        order,
+		"tickets":      tickets,
+		"total":        len(tickets),
+		"pricing":      quote,
+		"unit_price":   quote.UnitPrice,
+		"quantity":     quote.Quantity,
+		"total_amount": quote.Total,
+	}
 }
 
 // ValidateTicket validates a ticket using QR code (admin only)
@@ -220,53 +575,64 @@ func (h *TicketHandler) ValidateTicket(w http.ResponseWriter, r *http.Request) {
 	id := vars["id"]
 	ticketID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ticket ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
 		return
 	}
 
 	var ticket models.Ticket
 	if err := h.db.Where("id = ?", ticketID).First(&ticket).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Ticket not found"})
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve ticket"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
 		return
 	}
 
-	// Check if ticket is already used
-	if ticket.Status == "used" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Ticket has already been used"})
+	// The event's re-entry policy decides whether a used ticket can be scanned again
+	var event models.Event
+	if err := h.db.Unscoped().Where("id = ?", ticket.EventID).First(&event).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
 		return
 	}
 
-	// Mark ticket as used and create attendance log
-	ticket.Status = "used"
-	if err := h.db.Save(&ticket).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to validate ticket"})
+	// Check that the ticket has not expired or been cancelled. Used tickets are let back in
+	// when the event allows re-entry.
+	if message := ticketStatusError(ticket.Status); message != "" && ticket.Status != "used" {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, message)
 		return
 	}
 
-	// Create attendance log
-	attendanceLog := models.AttendanceLog{
-		TicketID:    ticket.ID,
-		CheckedInAt: time.Now(),
+	// Mark ticket as used and create attendance log, unless a concurrent cancellation or
+	// check-in changed its status first
+	_, err = admitTicket(h.db, r, &ticket, event, time.Now())
+	if err == errTicketAlreadyUsed {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, ticketStatusError(ticket.Status))
+		return
+	}
+	if err == errReentryNotAllowed {
+		respondError(w, http.StatusConflict, apierror.CodeReentryNotAllowed, "Re-entry is not allowed for this event")
+		return
+	}
+	if err == errTicketNoLongerValid {
+		respondError(w, http.StatusConflict, apierror.CodeTicketNoLongerValid, "Ticket is no longer valid")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate ticket")
+		return
 	}
 
-	if err := h.db.Create(&attendanceLog).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create attendance log"})
+	scans, err := countScans(h.db, ticket.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count ticket scans")
 		return
 	}
 
 	response := map[string]interface{}{
-		"message": "Ticket validated successfully",
-		"ticket":  ticket,
+		"message":    "Ticket validated successfully",
+		"ticket":     ticket,
+		"scan_count": scans,
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -282,15 +648,13 @@ func (h *TicketHandler) GetEventAttendees(w http.ResponseWriter, r *http.Request
 	eventID := vars["id"]
 	eventIDUint, err := strconv.ParseUint(eventID, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid event ID"})
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
 		return
 	}
 
 	var tickets []models.Ticket
 	if err := h.db.Preload("User").Preload("AttendanceLogs").Where("event_id = ?", eventIDUint).Find(&tickets).Error; err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to retrieve attendees"})
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendees")
 		return
 	}
 
@@ -298,48 +662,37 @@ func (h *TicketHandler) GetEventAttendees(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(tickets)
 }
 
-// ExportAttendees exports attendees for a specific event as CSV (admin only)
+// ExportAttendees exports attendees for a specific event as CSV, or as an XLSX workbook with
+// ?format=xlsx (admin only). The redact query parameter masks the listed columns, e.g.
+// ?redact=email,name, and ?status=used exports only checked-in attendees.
 func (h *TicketHandler) ExportAttendees(w http.ResponseWriter, r *http.Request) {
 	// Get ID from URL parameters (Gorilla Mux way)
 	vars := mux.Vars(r)
 	eventID := vars["id"]
 	eventIDUint, err := strconv.ParseUint(eventID, 10, 32)
 	if err != nil {
-		http.Error(w, `{"error": "Invalid event ID"}`, http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
 		return
 	}
 
-	var tickets []models.Ticket
-	if err := h.db.Preload("User").Preload("AttendanceLogs").Where("event_id = ?", eventIDUint).Find(&tickets).Error; err != nil {
-		http.Error(w, `{"error": "Failed to retrieve attendees"}`, http.StatusInternalServerError)
+	exportFormat := r.URL.Query().Get("format")
+	if exportFormat == "" {
+		exportFormat = "csv"
+	}
+	if exportFormat != "csv" && exportFormat != "xlsx" {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Format must be csv or xlsx")
 		return
 	}
 
-	// Set CSV headers
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=attendees_event_%s.csv", eventID))
-
-	// Create CSV writer
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
-
-	// Write CSV header
-	writer.Write([]string{"Ticket ID", "User Name", "User Email", "Status", "Checked In At", "Purchase Date"})
-
-	// Write attendee data
-	for _, ticket := range tickets {
-		checkedInAt := ""
-		if len(ticket.AttendanceLogs) > 0 {
-			checkedInAt = ticket.AttendanceLogs[0].CheckedInAt.Format("2006-01-02 15:04:05")
-		}
+	options, ok := h.parseAttendeeExportOptions(w, r, eventIDUint)
+	if !ok {
+		return
+	}
 
-		writer.Write([]string{
-			fmt.Sprintf("%d", ticket.ID),
-			ticket.User.Name,
-			ticket.User.Email,
-			ticket.Status,
-			checkedInAt,
-			ticket.CreatedAt.Format("2006-01-02 15:04:05"),
-		})
+	filename := fmt.Sprintf("attendees_event_%s.%s", eventID, exportFormat)
+	if exportFormat == "xlsx" {
+		writeAttendeeXLSX(w, h.db, eventIDUint, options, filename)
+		return
 	}
-}
\ No newline at end of file
+	writeAttendeeCSV(w, r, h.db, eventIDUint, options, filename)
+}
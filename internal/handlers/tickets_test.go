@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+// TestPurchaseTicketDoesNotOversell has more buyers than seats purchase at once. Only as many
+// purchases as the capacity may succeed, and the sold count must match the tickets issued.
+func TestPurchaseTicketDoesNotOversell(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+
+	const capacity = 3
+	const buyers = 12
+	event := createTestEvent(t, db, capacity, 15)
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	target := "/api/events/" + vars["id"] + "/purchase"
+
+	users := make([]models.User, buyers)
+	for i := range users {
+		users[i] = createTestUser(t, db, "user")
+	}
+
+	codes := make([]int, buyers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range users {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			w := httptest.NewRecorder()
+			h.PurchaseTicket(w, authedRequest("POST", target, `{"quantity": 1, "payment_token": "tok_test"}`, users[i], vars))
+			codes[i] = w.Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	purchased := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			purchased++
+		case http.StatusBadRequest, http.StatusConflict:
+		default:
+			t.Errorf("purchase %d returned %d", i, code)
+		}
+	}
+	if purchased != capacity {
+		t.Fatalf("%d purchases succeeded, want %d", purchased, capacity)
+	}
+
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	var issued int
+	db.Model(&models.Ticket{}).Where("event_id = ? AND status <> ?", event.ID, "cancelled").Count(&issued)
+	if stored.SoldCount != capacity || issued != capacity {
+		t.Fatalf("sold count is %d with %d tickets issued, want %d of each", stored.SoldCount, issued, capacity)
+	}
+}
+
+// hookProcessor runs onCharge before charging through a fake processor, to act while a purchase
+// waits for its payment
+type hookProcessor struct {
+	*payment.FakeProcessor
+	onCharge func()
+}
+
+func (p hookProcessor) Charge(amount float64, currency, token string) (string, error) {
+	p.onCharge()
+	return p.FakeProcessor.Charge(amount, currency, token)
+}
+
+// purchaseOne buys one ticket of the event as the user and returns the response code
+func purchaseOne(h *TicketHandler, event models.Event, user models.User, body string) int {
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.PurchaseTicket(w, authedRequest("POST", "/api/events/"+vars["id"]+"/purchase", body, user, vars))
+	return w.Code
+}
+
+func TestPurchaseTicketReleasesDeclinedOrder(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 1, 20)
+	promo := models.PromoCode{Code: "ONCE", EventID: &event.ID, PercentOff: 10, MaxUses: 1, Active: true}
+	if err := db.Create(&promo).Error; err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+
+	declined := `{"quantity": 1, "promo_code": "ONCE", "payment_token": "` + payment.FakeDeclineToken + `"}`
+	if code := purchaseOne(h, event, buyer, declined); code != http.StatusPaymentRequired {
+		t.Fatalf("declined purchase returned %d, want %d", code, http.StatusPaymentRequired)
+	}
+
+	var order models.Order
+	db.Where("event_id = ?", event.ID).First(&order)
+	var ticket models.Ticket
+	db.Where("order_id = ?", order.ID).First(&ticket)
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	db.Where("id = ?", promo.ID).First(&promo)
+	if order.Status != jobs.OrderStatusFailed || ticket.Status != "cancelled" || stored.SoldCount != 0 || promo.UsedCount != 0 {
+		t.Fatalf("declined order is %q with ticket %q, sold count %d and promo uses %d, want failed, cancelled, 0 and 0",
+			order.Status, ticket.Status, stored.SoldCount, promo.UsedCount)
+	}
+
+	// The seat and the promo code use are free for the next buyer
+	paid := `{"quantity": 1, "promo_code": "ONCE", "payment_token": "tok_test"}`
+	if code := purchaseOne(h, event, createTestUser(t, db, "user"), paid); code != http.StatusCreated {
+		t.Fatalf("purchase after the declined one returned %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestPurchaseTicketChargesWithoutLockingTheEvent(t *testing.T) {
+	db := openTestDB(t)
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 5, 20)
+
+	var lockErr error
+	var during models.Ticket
+	processor := hookProcessor{FakeProcessor: payment.NewFake(), onCharge: func() {
+		// Another purchase can lock the event while this one is being charged
+		tx := db.Begin()
+		lockErr = tx.Exec("SELECT id FROM events WHERE id = ? FOR UPDATE NOWAIT", event.ID).Error
+		tx.Rollback()
+		db.Where("event_id = ?", event.ID).First(&during)
+	}}
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, processor)
+
+	if code := purchaseOne(h, event, buyer, `{"quantity": 1, "payment_token": "tok_test"}`); code != http.StatusCreated {
+		t.Fatalf("purchase returned %d, want %d", code, http.StatusCreated)
+	}
+	if lockErr != nil {
+		t.Fatalf("event row was locked during the charge: %v", lockErr)
+	}
+	if during.Status != "pending" {
+		t.Fatalf("ticket was %q during the charge, want the committed pending reservation", during.Status)
+	}
+
+	var ticket models.Ticket
+	db.Where("id = ?", during.ID).First(&ticket)
+	if ticket.Status != "valid" {
+		t.Fatalf("paid ticket is %q, want valid", ticket.Status)
+	}
+}
+
+func TestPurchaseTicketRefundsChargeOfReleasedOrder(t *testing.T) {
+	db := openTestDB(t)
+	buyer := createTestUser(t, db, "user")
+	event := createTestEvent(t, db, 1, 20)
+
+	// The reservation is released while the charge is in flight, so it cannot be confirmed
+	fake := payment.NewFake()
+	processor := hookProcessor{FakeProcessor: fake, onCharge: func() {
+		var order models.Order
+		if err := db.Where("event_id = ? AND status = ?", event.ID, "pending").First(&order).Error; err != nil {
+			t.Errorf("find pending order: %v", err)
+			return
+		}
+		if _, _, err := jobs.ReleaseOrder(db, order.ID, jobs.OrderStatusExpired, time.Now()); err != nil {
+			t.Errorf("release order: %v", err)
+		}
+	}}
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, processor)
+
+	if code := purchaseOne(h, event, buyer, `{"quantity": 1, "payment_token": "tok_test"}`); code != http.StatusConflict {
+		t.Fatalf("purchase of a released order returned %d, want %d", code, http.StatusConflict)
+	}
+
+	charges := fake.Charges()
+	refunds := fake.Refunds()
+	if len(charges) != 1 || len(refunds) != 1 || refunds[0] != charges[0].ID {
+		t.Fatalf("charges %v were refunded as %v, want the one charge refunded", charges, refunds)
+	}
+
+	var stored models.Event
+	db.Where("id = ?", event.ID).First(&stored)
+	var issued int
+	db.Model(&models.Ticket{}).Where("event_id = ? AND status <> ?", event.ID, "cancelled").Count(&issued)
+	if stored.SoldCount != 0 || issued != 0 {
+		t.Fatalf("sold count is %d with %d tickets issued, want neither", stored.SoldCount, issued)
+	}
+}
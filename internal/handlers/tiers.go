@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// TierStats holds the sales of one ticket tier
+type TierStats struct {
+	TicketTypeID *uint   `json:"ticket_type_id"` // nil for tickets sold without a tier
+	Name         string  `json:"name"`
+	Price        float64 `json:"price"`
+	Capacity     *int    `json:"capacity"`
+	Sold         int64   `json:"sold"`
+	Remaining    *int64  `json:"remaining"`
+	Revenue      float64 `json:"revenue"`
+}
+
+// TierTotals holds the sales of all tiers of an event combined
+type TierTotals struct {
+	Sold    int64   `json:"sold"`
+	Revenue float64 `json:"revenue"`
+}
+
+// tierSalesRow is a single row of the grouped tier sales query
+type tierSalesRow struct {
+	ID       uint
+	Name     string
+	Price    float64
+	Capacity int
+	Sold     int64
+}
+
+// buildTierStats assembles per-tier figures and totals. Tickets sold without a tier are
+// reported in a separate row priced at the event price.
+func buildTierStats(event models.Event, rows []tierSalesRow, untiered int64) ([]TierStats, TierTotals) {
+	stats := []TierStats{}
+	var totals TierTotals
+
+	for _, row := range rows {
+		id := row.ID
+		capacity := row.Capacity
+		remaining := int64(row.Capacity) - row.Sold
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		tier := TierStats{
+			TicketTypeID: &id,
+			Name:         row.Name,
+			Price:        row.Price,
+			Capacity:     &capacity,
+			Sold:         row.Sold,
+			Remaining:    &remaining,
+			Revenue:      float64(row.Sold) * row.Price,
+		}
+		stats = append(stats, tier)
+		totals.Sold += tier.Sold
+		totals.Revenue += tier.Revenue
+	}
+
+	if untiered > 0 {
+		tier := TierStats{
+			Name:    "Untiered",
+			Price:   event.Price,
+			Sold:    untiered,
+			Revenue: float64(untiered) * event.Price,
+		}
+		stats = append(stats, tier)
+		totals.Sold += tier.Sold
+		totals.Revenue += tier.Revenue
+	}
+
+	return stats, totals
+}
+
+// GetTierStats retrieves ticket counts and revenue per ticket tier of an event (organizer of the event or admin)
+func (h *EventHandler) GetTierStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if !canManageEvent(r, event) {
+		respondError(w, http.StatusForbidden, apierror.CodeForbidden, "You do not manage this event")
+		return
+	}
+
+	// Reserved tickets without a holder, unpaid holds and cancelled tickets are not sold, matching the event sales summary
+	var rows []tierSalesRow
+	if err := h.db.Table("ticket_types").
+		Select("ticket_types.id, ticket_types.name, ticket_types.price, ticket_types.capacity, COUNT(tickets.id) AS sold").
+		Joins("LEFT JOIN tickets ON tickets.ticket_type_id = ticket_types.id AND tickets.user_id IS NOT NULL AND tickets.status NOT IN ('cancelled', 'pending')").
+		Where("ticket_types.event_id = ?", event.ID).
+		Group("ticket_types.id").Order("ticket_types.id asc").
+		Scan(&rows).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute tier stats")
+		return
+	}
+
+	var untiered int64
+	if err := h.db.Model(&models.Ticket{}).
+		Where("event_id = ? AND ticket_type_id IS NULL AND user_id IS NOT NULL AND status NOT IN (?)", event.ID, []string{"cancelled", "pending"}).
+		Count(&untiered).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute tier stats")
+		return
+	}
+
+	tiers, totals := buildTierStats(event, rows, untiered)
+
+	response := map[string]interface{}{
+		"event_id": event.ID,
+		"tiers":    tiers,
+		"totals":   totals,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
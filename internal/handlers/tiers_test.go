@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestGetTierStatsAcrossTiers(t *testing.T) {
+	db := openTestDB(t)
+	h := NewEventHandler(db)
+	admin := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+	standard := models.TicketType{EventID: event.ID, Name: "Standard", Price: 10, Capacity: 5}
+	vip := models.TicketType{EventID: event.ID, Name: "VIP", Price: 50, Capacity: 2}
+	for _, tier := range []*models.TicketType{&standard, &vip} {
+		if err := db.Create(tier).Error; err != nil {
+			t.Fatalf("create %s tier: %v", tier.Name, err)
+		}
+	}
+
+	// ticket creates a sold ticket in the tier, nil for an untiered one, with the given status
+	ticket := func(tier *models.TicketType, status string) models.Ticket {
+		created := createTestTicket(t, db, event, createTestUser(t, db, "user"))
+		update := map[string]interface{}{"status": status}
+		if tier != nil {
+			update["ticket_type_id"] = tier.ID
+		}
+		db.Model(&models.Ticket{}).Where("id = ?", created.ID).UpdateColumns(update)
+		return created
+	}
+	ticket(&standard, "valid")
+	ticket(&standard, "used")
+	ticket(&vip, "valid")
+	ticket(&vip, "cancelled")
+	ticket(nil, "valid")
+	// Reserved tickets without a holder are not sold
+	reserved := ticket(&standard, "valid")
+	db.Model(&models.Ticket{}).Where("id = ?", reserved.ID).UpdateColumn("user_id", nil)
+
+	vars := map[string]string{"id": strconv.Itoa(int(event.ID))}
+	w := httptest.NewRecorder()
+	h.GetTierStats(w, authedRequest("GET", "/api/events/"+vars["id"]+"/tiers/stats", "", admin, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("tier stats returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Tiers  []TierStats `json:"tiers"`
+		Totals TierTotals  `json:"totals"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode tier stats: %v", err)
+	}
+
+	want := []struct {
+		name      string
+		sold      int64
+		remaining int64
+		revenue   float64
+	}{
+		{name: "Standard", sold: 2, remaining: 3, revenue: 20},
+		{name: "VIP", sold: 1, remaining: 1, revenue: 50},
+		{name: "Untiered", sold: 1, remaining: -1, revenue: 20},
+	}
+	if len(response.Tiers) != len(want) {
+		t.Fatalf("got %d tiers, want %d: %+v", len(response.Tiers), len(want), response.Tiers)
+	}
+	for i, tier := range response.Tiers {
+		expected := want[i]
+		if tier.Name != expected.name || tier.Sold != expected.sold || tier.Revenue != expected.revenue {
+			t.Errorf("tier %d is %s with %d sold for %.2f, want %s with %d sold for %.2f", i, tier.Name, tier.Sold, tier.Revenue, expected.name, expected.sold, expected.revenue)
+		}
+		if expected.remaining < 0 {
+			if tier.Remaining != nil {
+				t.Errorf("%s tier has %d remaining, want no limit", tier.Name, *tier.Remaining)
+			}
+		} else if tier.Remaining == nil || *tier.Remaining != expected.remaining {
+			t.Errorf("%s tier has %v remaining, want %d", tier.Name, tier.Remaining, expected.remaining)
+		}
+	}
+
+	if response.Totals.Sold != 4 || response.Totals.Revenue != 90 {
+		t.Fatalf("totals are %+v, want 4 sold for 90.00", response.Totals)
+	}
+}
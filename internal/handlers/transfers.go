@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	errTransferCooldown     = errors.New("ticket was transferred too recently")
+	errTransferLimitReached = errors.New("ticket has reached the maximum number of transfers for this event")
+)
+
+// TransferTicketRequest represents the transfer ticket request payload
+type TransferTicketRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// transferCooldown returns the minimum time between two transfers of the same ticket
+func transferCooldown() time.Duration {
+	return time.Duration(config.GetInt("TICKET_TRANSFER_COOLDOWN_HOURS", 0)) * time.Hour
+}
+
+// checkTransferAllowed applies the per-event transfer limit and the cooldown to a ticket's
+// transfer history. On a cooldown violation it also returns the next allowed transfer time.
+func checkTransferAllowed(event models.Event, history []models.TicketTransfer, cooldown time.Duration, now time.Time) (time.Time, error) {
+	if event.MaxTransfers > 0 && len(history) >= event.MaxTransfers {
+		return time.Time{}, errTransferLimitReached
+	}
+
+	if cooldown > 0 && len(history) > 0 {
+		last := history[0].CreatedAt
+		for _, transfer := range history[1:] {
+			if transfer.CreatedAt.After(last) {
+				last = transfer.CreatedAt
+			}
+		}
+
+		nextAllowed := last.Add(cooldown)
+		if now.Before(nextAllowed) {
+			return nextAllowed, errTransferCooldown
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+// TransferTicket transfers a ticket owned by the current user to another user
+func (h *TicketHandler) TransferTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	ticketID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid ticket ID")
+		return
+	}
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var req TransferTicketRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	var ticket models.Ticket
+	if err := h.db.Preload("Event").Where("id = ? AND user_id = ?", ticketID, userID).First(&ticket).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, "Ticket not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	if ticket.Status != "valid" {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, "Only valid tickets can be transferred")
+		return
+	}
+
+	if ticket.Event.Date.Before(time.Now()) {
+		respondError(w, http.StatusBadRequest, apierror.CodeEventPast, "Cannot transfer tickets for past events")
+		return
+	}
+
+	var recipient models.User
+	if err := h.db.Where("email = ?", req.Email).First(&recipient).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeUserNotFound, "Recipient not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve recipient")
+		return
+	}
+
+	if recipient.ID == *ticket.UserID {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Cannot transfer a ticket to yourself")
+		return
+	}
+
+	// Change ownership, record the transfer and reissue the codes together. The ticket is locked
+	// and its history read under the lock, so concurrent transfers of one ticket are taken one
+	// after the other and each sees the transfers committed before it.
+	tx := h.db.Begin()
+	var locked models.Ticket
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", ticket.ID).First(&locked).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+	if locked.UserID == nil || *locked.UserID != *ticket.UserID || locked.Status != "valid" {
+		tx.Rollback()
+		respondError(w, http.StatusConflict, apierror.CodeConflict, "Ticket was changed by another request")
+		return
+	}
+
+	// Check transfer history against the event limit and the cooldown
+	var history []models.TicketTransfer
+	if err := tx.Where("ticket_id = ?", ticket.ID).Find(&history).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve transfer history")
+		return
+	}
+
+	nextAllowed, err := checkTransferAllowed(ticket.Event, history, transferCooldown(), time.Now())
+	if err == errTransferCooldown {
+		tx.Rollback()
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(nextAllowed).Seconds())+1))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":           apierror.New(apierror.CodeTransferCooldown, "Ticket was transferred too recently"),
+			"next_allowed_at": nextAllowed,
+		})
+		return
+	}
+	if err == errTransferLimitReached {
+		tx.Rollback()
+		respondError(w, http.StatusBadRequest, apierror.CodeTransferLimitReached,
+			fmt.Sprintf("Ticket has reached the maximum of %d transfers for this event", ticket.Event.MaxTransfers))
+		return
+	}
+
+	transfer := models.TicketTransfer{
+		TicketID:   ticket.ID,
+		FromUserID: *ticket.UserID,
+		ToUserID:   recipient.ID,
+	}
+	result := tx.Model(&models.Ticket{}).Where("id = ? AND user_id = ? AND status = ?", ticket.ID, *ticket.UserID, "valid").
+		Update("user_id", recipient.ID)
+	if result.Error != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to transfer ticket")
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		respondError(w, http.StatusConflict, apierror.CodeConflict, "Ticket was changed by another request")
+		return
+	}
+	if err := tx.Create(&transfer).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to record transfer")
+		return
+	}
+
+	// The QR payload embeds the holder, and the previous holder may have kept a copy of both
+	// codes, so the recipient gets new ones and the old ones stop scanning
+	locked.UserID = &recipient.ID
+	if err := regenerateTicketQRInTx(tx, &locked); err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to reissue ticket codes")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to transfer ticket")
+		return
+	}
+
+	response := map[string]interface{}{
+		"message":  "Ticket transferred successfully",
+		"transfer": transfer,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
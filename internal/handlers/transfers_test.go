@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+)
+
+func TestCheckTransferAllowed(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	history := []models.TicketTransfer{
+		{CreatedAt: now.Add(-5 * time.Hour)},
+		{CreatedAt: now.Add(-2 * time.Hour)},
+		{CreatedAt: now.Add(-3 * time.Hour)},
+	}
+
+	tests := []struct {
+		name        string
+		event       models.Event
+		history     []models.TicketTransfer
+		cooldown    time.Duration
+		now         time.Time
+		wantErr     error
+		nextAllowed time.Time
+	}{
+		{name: "first transfer", event: models.Event{MaxTransfers: 1}, cooldown: time.Hour, now: now},
+		{name: "no limit and no cooldown", history: history, now: now},
+		{name: "below the limit", event: models.Event{MaxTransfers: 4}, history: history, now: now},
+		{name: "limit reached", event: models.Event{MaxTransfers: 3}, history: history, now: now, wantErr: errTransferLimitReached},
+		{name: "limit checked before the cooldown", event: models.Event{MaxTransfers: 3}, history: history, cooldown: 24 * time.Hour, now: now, wantErr: errTransferLimitReached},
+		{
+			name:        "cooldown runs from the latest transfer",
+			history:     history,
+			cooldown:    3 * time.Hour,
+			now:         now,
+			wantErr:     errTransferCooldown,
+			nextAllowed: now.Add(time.Hour),
+		},
+		{
+			name:        "just before the cooldown ends",
+			history:     history,
+			cooldown:    2 * time.Hour,
+			now:         now.Add(-time.Nanosecond),
+			wantErr:     errTransferCooldown,
+			nextAllowed: now,
+		},
+		{name: "exactly when the cooldown ends", history: history, cooldown: 2 * time.Hour, now: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextAllowed, err := checkTransferAllowed(tt.event, tt.history, tt.cooldown, tt.now)
+			if err != tt.wantErr {
+				t.Fatalf("checkTransferAllowed() error = %v, want %v", err, tt.wantErr)
+			}
+			if !nextAllowed.Equal(tt.nextAllowed) {
+				t.Errorf("next allowed at %v, want %v", nextAllowed, tt.nextAllowed)
+			}
+		})
+	}
+}
+
+// transfer transfers the ticket as its holder to the recipient and returns the recorded response
+func transfer(h *TicketHandler, ticket models.Ticket, holder, recipient models.User) *httptest.ResponseRecorder {
+	vars := map[string]string{"id": strconv.Itoa(int(ticket.ID))}
+	w := httptest.NewRecorder()
+	h.TransferTicket(w, authedRequest("POST", "/api/tickets/"+vars["id"]+"/transfer", `{"email": "`+recipient.Email+`"}`, holder, vars))
+	return w
+}
+
+func TestTransferTicketCooldownAndLimit(t *testing.T) {
+	db := openTestDB(t)
+	h := NewTicketHandler(db, webhook.LogNotifier{}, mailer.LogSender{}, payment.NewFake())
+	t.Setenv("TICKET_TRANSFER_COOLDOWN_HOURS", "2")
+
+	event := createTestEvent(t, db, 5, 20)
+	if err := db.Model(&models.Event{}).Where("id = ?", event.ID).UpdateColumn("max_transfers", 2).Error; err != nil {
+		t.Fatalf("set transfer limit: %v", err)
+	}
+	first, second, third := createTestUser(t, db, "user"), createTestUser(t, db, "user"), createTestUser(t, db, "user")
+	ticket := createTestTicket(t, db, event, first)
+
+	if w := transfer(h, ticket, first, second); w.Code != http.StatusOK {
+		t.Fatalf("first transfer returned %d: %s", w.Code, w.Body.String())
+	}
+
+	w := transfer(h, ticket, second, third)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("transfer within the cooldown returned %d with Retry-After %q, want %d with one", w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+	var cooldown struct {
+		NextAllowedAt time.Time `json:"next_allowed_at"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&cooldown); err != nil {
+		t.Fatalf("decode cooldown response: %v", err)
+	}
+	var previous models.TicketTransfer
+	db.Where("ticket_id = ?", ticket.ID).First(&previous)
+	if !cooldown.NextAllowedAt.Equal(previous.CreatedAt.Add(2 * time.Hour)) {
+		t.Fatalf("next transfer allowed at %v, want two hours after %v", cooldown.NextAllowedAt, previous.CreatedAt)
+	}
+
+	// Once the cooldown has passed the ticket moves on, until the event's limit is reached
+	if err := db.Model(&models.TicketTransfer{}).Where("id = ?", previous.ID).
+		UpdateColumn("created_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatalf("backdate transfer: %v", err)
+	}
+	if w := transfer(h, ticket, second, third); w.Code != http.StatusOK {
+		t.Fatalf("transfer after the cooldown returned %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := db.Model(&models.TicketTransfer{}).Where("ticket_id = ?", ticket.ID).
+		UpdateColumn("created_at", time.Now().Add(-3*time.Hour)).Error; err != nil {
+		t.Fatalf("backdate transfers: %v", err)
+	}
+	if w := transfer(h, ticket, third, first); w.Code != http.StatusBadRequest || responseErrorCode(t, w) != apierror.CodeTransferLimitReached {
+		t.Fatalf("transfer past the limit returned %d: %s", w.Code, w.Body.String())
+	}
+}
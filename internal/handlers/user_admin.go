@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// userRoles are the roles allowed on models.User
+var userRoles = map[string]bool{"admin": true, "organizer": true, "user": true}
+
+// UserSummary is a user in the admin user list, with the number of tickets they hold
+type UserSummary struct {
+	models.User
+	TicketCount int `json:"ticket_count"`
+}
+
+// userTicketCounts returns the number of tickets held by each of the users, not counting
+// cancelled ones, in one grouped query
+func userTicketCounts(db *gorm.DB, userIDs []uint) (map[uint]int, error) {
+	counts := map[uint]int{}
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID uint
+		Count  int
+	}
+	if err := db.Table("tickets").Select("user_id, COUNT(*) AS count").
+		Where("user_id IN (?) AND status <> ?", userIDs, "cancelled").
+		Group("user_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetUsers lists registered users a page at a time with their ticket counts (admin only).
+// ?search= matches name or email, ?role= filters by role.
+func (h *AdminHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	page, err := parsePagination(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	order, err := parseSort(r, userSortKeys, "created_at", "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	params := r.URL.Query()
+	query := h.db.Model(&models.User{})
+
+	if search := strings.TrimSpace(params.Get("search")); search != "" {
+		contains := "%" + escapeLike(search) + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ?", contains, contains)
+	}
+
+	if role := params.Get("role"); role != "" {
+		if !userRoles[role] {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Role must be admin, organizer or user")
+			return
+		}
+		query = query.Where("role = ?", role)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve users")
+		return
+	}
+
+	var users []models.User
+	if err := query.Order(order).Offset(page.Offset()).Limit(page.PerPage).Find(&users).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve users")
+		return
+	}
+
+	userIDs := make([]uint, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	counts, err := userTicketCounts(h.db, userIDs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count tickets")
+		return
+	}
+
+	summaries := make([]UserSummary, 0, len(users))
+	for _, user := range users {
+		summaries = append(summaries, UserSummary{User: user, TicketCount: counts[user.ID]})
+	}
+
+	response := PaginatedResponse{
+		Data:    summaries,
+		Page:    page.Page,
+		PerPage: page.PerPage,
+		Total:   total,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// errLastAdmin is returned when a role change would leave the platform without an admin
+var errLastAdmin = errors.New("cannot demote the last remaining admin")
+
+// UpdateUserRoleRequest represents the change user role request payload. The roles are the
+// ones in userRoles.
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin organizer user"`
+}
+
+// changeUserRole sets a user's role in one transaction. The admins are locked first, so two
+// admins demoting each other at once cannot both succeed and leave none behind.
+func changeUserRole(db *gorm.DB, userID uint64, role string) (models.User, string, error) {
+	tx := db.Begin()
+
+	var admins []models.User
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Select("id").Where("role = ?", "admin").Find(&admins).Error; err != nil {
+		tx.Rollback()
+		return models.User{}, "", err
+	}
+
+	var user models.User
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", userID).First(&user).Error; err != nil {
+		tx.Rollback()
+		return models.User{}, "", err
+	}
+	previous := user.Role
+
+	if previous == "admin" && role != "admin" && len(admins) <= 1 {
+		tx.Rollback()
+		return models.User{}, "", errLastAdmin
+	}
+
+	now := time.Now()
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+		UpdateColumns(map[string]interface{}{"role": role, "updated_at": now}).Error; err != nil {
+		tx.Rollback()
+		return models.User{}, "", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return models.User{}, "", err
+	}
+	user.Role, user.UpdatedAt = role, now
+	return user, previous, nil
+}
+
+// UpdateUserRole promotes or demotes a user (admin only). The last remaining admin cannot be
+// demoted. JWTAuth reads the role from the database, so the change applies from the user's
+// next request, tokens issued before it included.
+func (h *AdminHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	userID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid user ID")
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	user, previous, err := changeUserRole(h.db, userID, req.Role)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeUserNotFound, "User not found")
+			return
+		}
+		if err == errLastAdmin {
+			respondError(w, http.StatusConflict, apierror.CodeConflict, "Cannot demote the last remaining admin")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update user role")
+		return
+	}
+
+	if previous != user.Role {
+		recordAudit(h.db, r, "user.role_changed", "user", user.ID, previous+" -> "+user.Role)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/middleware"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// updateRole changes the user's role as the admin and returns the response code
+func updateRole(h *AdminHandler, admin, user models.User, role string) int {
+	vars := map[string]string{"id": strconv.Itoa(int(user.ID))}
+	w := httptest.NewRecorder()
+	h.UpdateUserRole(w, authedRequest("PUT", "/api/admin/users/"+vars["id"]+"/role", `{"role": "`+role+`"}`, admin, vars))
+	return w.Code
+}
+
+// bearerRequest sends a request with the token through JWTAuth and the role check, as the
+// router does, and returns the response code
+func bearerRequest(db *gorm.DB, roleCheck func(http.Handler) http.Handler, token string) int {
+	handler := middleware.JWTAuth(roleCheck(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	r := httptest.NewRequest("GET", "/api/admin/users", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	r = r.WithContext(context.WithValue(r.Context(), "db", db))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestDemotedUserLosesAccessWithExistingToken(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAdminHandler(db)
+	admin := createTestUser(t, db, "admin")
+	demotedAdmin := createTestUser(t, db, "admin")
+	organizer := createTestUser(t, db, "organizer")
+
+	adminToken, err := auth.GenerateToken(demotedAdmin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	organizerToken, err := auth.GenerateToken(organizer)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if code := bearerRequest(db, middleware.AdminAuth, adminToken); code != http.StatusOK {
+		t.Fatalf("admin request before the demotion returned %d, want %d", code, http.StatusOK)
+	}
+	if code := bearerRequest(db, middleware.OrganizerAuth, organizerToken); code != http.StatusOK {
+		t.Fatalf("organizer request before the demotion returned %d, want %d", code, http.StatusOK)
+	}
+
+	if code := updateRole(h, admin, demotedAdmin, "user"); code != http.StatusOK {
+		t.Fatalf("demoting the admin returned %d, want %d", code, http.StatusOK)
+	}
+	if code := updateRole(h, admin, organizer, "user"); code != http.StatusOK {
+		t.Fatalf("demoting the organizer returned %d, want %d", code, http.StatusOK)
+	}
+
+	if code := bearerRequest(db, middleware.AdminAuth, adminToken); code != http.StatusForbidden {
+		t.Fatalf("demoted admin's token returned %d on an admin route, want %d", code, http.StatusForbidden)
+	}
+	if code := bearerRequest(db, middleware.OrganizerAuth, organizerToken); code != http.StatusForbidden {
+		t.Fatalf("demoted organizer's token returned %d on an organizer route, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestUpdateUserRoleKeepsLastAdmin(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAdminHandler(db)
+	admin := createTestUser(t, db, "admin")
+
+	if code := updateRole(h, admin, admin, "user"); code != http.StatusConflict {
+		t.Fatalf("demoting the only admin returned %d, want %d", code, http.StatusConflict)
+	}
+	var stored models.User
+	db.Where("id = ?", admin.ID).First(&stored)
+	if stored.Role != "admin" {
+		t.Fatalf("only admin is now %q, want admin", stored.Role)
+	}
+
+	// With a second admin the first can step down
+	if code := updateRole(h, admin, createTestUser(t, db, "user"), "admin"); code != http.StatusOK {
+		t.Fatalf("promoting a user returned %d, want %d", code, http.StatusOK)
+	}
+	if code := updateRole(h, admin, admin, "user"); code != http.StatusOK {
+		t.Fatalf("demoting one of two admins returned %d, want %d", code, http.StatusOK)
+	}
+}
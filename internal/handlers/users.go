@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/logging"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/mailer"
+
+	"github.com/jinzhu/gorm"
+)
+
+// UserHandler handles requests about the current user's account
+type UserHandler struct {
+	db     *gorm.DB
+	mailer mailer.Sender
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(db *gorm.DB, sender mailer.Sender) *UserHandler {
+	return &UserHandler{db: db, mailer: sender}
+}
+
+// UpdateProfileRequest represents the profile update request payload. Empty fields are left
+// unchanged.
+type UpdateProfileRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// validEmail reports whether value is a bare email address such as jane@example.com
+func validEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value && strings.Contains(value[strings.LastIndex(value, "@"):], ".")
+}
+
+// ProfileResponse is the current user's record with a summary of their tickets
+type ProfileResponse struct {
+	models.User
+	TicketsOwned   int `json:"tickets_owned"`
+	EventsAttended int `json:"events_attended"`
+}
+
+// GetProfile retrieves the current user's profile with the number of tickets they hold and
+// the number of events they checked in to
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve user")
+		return
+	}
+
+	response := ProfileResponse{User: user}
+
+	if err := h.db.Model(&models.Ticket{}).Where("user_id = ? AND status <> ?", user.ID, "cancelled").
+		Count(&response.TicketsOwned).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve tickets")
+		return
+	}
+
+	var attended struct{ Count int }
+	if err := h.db.Table("tickets").Select("COUNT(DISTINCT tickets.event_id) AS count").
+		Joins("JOIN attendance_logs ON attendance_logs.ticket_id = tickets.id").
+		Where("tickets.user_id = ?", user.ID).Scan(&attended).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve attendance")
+		return
+	}
+	response.EventsAttended = attended.Count
+
+	// Remove password from response
+	response.Password = ""
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateProfile changes the current user's name and email. A new email must not belong to
+// another account; it is marked unverified and a verification link is sent to it.
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	email := strings.TrimSpace(req.Email)
+	if name == "" && email == "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Name or email is required")
+		return
+	}
+	if email != "" && !validEmail(email) {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Invalid email address")
+		return
+	}
+
+	var user models.User
+	if err := h.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve user")
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if name != "" && name != user.Name {
+		updates["name"] = name
+	}
+
+	emailChanged := email != "" && !strings.EqualFold(email, user.Email)
+	if email != "" && email != user.Email {
+		// Compare canonical forms as registration does, so address variants of another
+		// account are rejected too
+		canonicalEmail := auth.CanonicalEmail(email, auth.EmailCanonicalizationMode())
+		var existingUser models.User
+		err := h.db.Where("(email = ? OR canonical_email = ?) AND id <> ?", email, canonicalEmail, user.ID).First(&existingUser).Error
+		if err == nil {
+			respondError(w, http.StatusConflict, apierror.CodeEmailAlreadyExists, "User already exists with this email")
+			return
+		}
+		if !gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve user")
+			return
+		}
+
+		updates["email"] = email
+		updates["canonical_email"] = canonicalEmail
+		if emailChanged {
+			updates["email_verified"] = false
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update profile")
+			return
+		}
+		if err := h.db.Where("id = ?", user.ID).First(&user).Error; err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve user")
+			return
+		}
+	}
+
+	// Send verification email to the new address, the update still succeeds if delivery fails
+	if emailChanged {
+		if err := sendVerificationEmail(h.db, h.mailer, user); err != nil {
+			logging.FromContext(r.Context()).Error("Failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}
+
+	// Remove password from response
+	user.Password = ""
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// errTicketNoLongerValid is returned when a ticket changed status, for example by a concurrent
+// cancellation or check-in, between being read and being checked in
+var errTicketNoLongerValid = errors.New("ticket is no longer valid")
+
+// ValidateByQRRequest represents the validate by QR payload request. Either the scanned QR
+// payload or the scanned barcode value is given.
+type ValidateByQRRequest struct {
+	QRCode  string `json:"qr_code"`
+	Barcode string `json:"barcode"`
+}
+
+// ticketStatusError returns the reason a ticket with the given status cannot be checked in,
+// or an empty string when it is valid
+func ticketStatusError(status string) string {
+	switch status {
+	case "pending":
+		return "Ticket has not been paid for"
+	case "used":
+		return "Ticket has already been used"
+	case "expired":
+		return "Ticket has expired"
+	case "cancelled":
+		return "Ticket has been cancelled"
+	}
+	return ""
+}
+
+// checkedInBy returns the authenticated user recording a check-in
+func checkedInBy(r *http.Request) *uint {
+	if id, ok := r.Context().Value("user_id").(uint); ok {
+		return &id
+	}
+	return nil
+}
+
+// checkInTicket marks a valid ticket as used and records its attendance log in one
+// transaction. The update is guarded on the ticket still being valid, so when a cancellation
+// or another check-in commits first this returns errTicketNoLongerValid.
+func checkInTicket(db *gorm.DB, r *http.Request, ticket *models.Ticket, checkedInAt time.Time) (models.AttendanceLog, error) {
+	tx := db.Begin()
+
+	// Update the column only, so preloaded associations are not written back
+	result := tx.Model(&models.Ticket{}).Where("id = ? AND status = ?", ticket.ID, "valid").Update("status", "used")
+	if result.Error != nil {
+		tx.Rollback()
+		return models.AttendanceLog{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return models.AttendanceLog{}, errTicketNoLongerValid
+	}
+
+	attendanceLog := models.AttendanceLog{
+		TicketID:    ticket.ID,
+		CheckedInAt: checkedInAt,
+		CheckedInBy: checkedInBy(r),
+	}
+	if err := tx.Create(&attendanceLog).Error; err != nil {
+		tx.Rollback()
+		return models.AttendanceLog{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return models.AttendanceLog{}, err
+	}
+	ticket.Status = "used"
+
+	recordAudit(db, r, "ticket.status_changed", "ticket", ticket.ID, "valid -> used")
+	queueWebhookDeliveries(db, ticketValidatedWebhookEvent, TicketValidatedData{TicketID: ticket.ID, EventID: ticket.EventID, UserID: ticket.UserID, CheckedInAt: checkedInAt})
+	return attendanceLog, nil
+}
+
+// ValidateTicketByQR validates the ticket matching a scanned QR payload or barcode (admin only). The
+// response names the event and attendee so the gate operator can see who entered.
+func (h *TicketHandler) ValidateTicketByQR(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ValidateByQRRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	query := h.db.Preload("Event").Preload("User")
+	notFound := "No ticket matches this QR code"
+	switch {
+	case req.QRCode != "":
+		claims, err := verifyQRPayload(req.QRCode)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeQRCodeInvalid, "QR code is not authentic")
+			return
+		}
+		// A payload replaced since it was issued, e.g. by a transfer, no longer matches its ticket
+		query = query.Where("id = ? AND qr_code = ?", claims.TicketID, req.QRCode)
+	case req.Barcode != "":
+		query = query.Where("barcode = ?", req.Barcode)
+		notFound = "No ticket matches this barcode"
+	default:
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "QR code or barcode is required")
+		return
+	}
+
+	var ticket models.Ticket
+	if err := query.First(&ticket).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeTicketNotFound, notFound)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve ticket")
+		return
+	}
+
+	// Used tickets are let back in when the event allows re-entry
+	if message := ticketStatusError(ticket.Status); message != "" && ticket.Status != "used" {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, message)
+		return
+	}
+
+	attendanceLog, err := admitTicket(h.db, r, &ticket, ticket.Event, time.Now())
+	if err == errTicketAlreadyUsed {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketNotValid, ticketStatusError(ticket.Status))
+		return
+	}
+	if err == errReentryNotAllowed {
+		respondError(w, http.StatusConflict, apierror.CodeReentryNotAllowed, "Re-entry is not allowed for this event")
+		return
+	}
+	if err == errTicketNoLongerValid {
+		respondError(w, http.StatusConflict, apierror.CodeTicketNoLongerValid, "Ticket is no longer valid")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate ticket")
+		return
+	}
+
+	scans, err := countScans(h.db, ticket.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count ticket scans")
+		return
+	}
+
+	var attendeeName string
+	if ticket.UserID != nil {
+		attendeeName = ticket.User.Name
+	}
+
+	response := map[string]interface{}{
+		"message":       "Ticket validated successfully",
+		"ticket_id":     ticket.ID,
+		"event_title":   ticket.Event.Title,
+		"attendee_name": attendeeName,
+		"checked_in_at": attendanceLog.CheckedInAt,
+		"scan_count":    scans,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
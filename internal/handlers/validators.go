@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// GetValidatorCheckins lists the attendance logs recorded by one admin, newest first, with
+// their ticket and event, optionally limited to a check-in time range (admin only)
+func (h *AdminHandler) GetValidatorCheckins(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	adminID, err := strconv.ParseUint(vars["adminId"], 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid admin ID")
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	var validator models.User
+	if err := h.db.Where("id = ?", adminID).First(&validator).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeUserNotFound, "User not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve user")
+		return
+	}
+
+	params := r.URL.Query()
+	query := h.db.Model(&models.AttendanceLog{}).Where("checked_in_by = ?", validator.ID)
+
+	if value := params.Get("from"); value != "" {
+		from, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid from date")
+			return
+		}
+		query = query.Where("checked_in_at >= ?", from)
+	}
+
+	if value := params.Get("to"); value != "" {
+		to, err := parseDateParam(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidParameter, "Invalid to date")
+			return
+		}
+		query = query.Where("checked_in_at < ?", to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve check-ins")
+		return
+	}
+
+	logs := []models.AttendanceLog{}
+	if err := query.Preload("Ticket").Preload("Ticket.Event").
+		Order(stableOrder("checked_in_at desc", "id")).Offset(page.Offset()).Limit(page.PerPage).
+		Find(&logs).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve check-ins")
+		return
+	}
+
+	response := PaginatedResponse{
+		Data:    logs,
+		Page:    page.Page,
+		PerPage: page.PerPage,
+		Total:   total,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
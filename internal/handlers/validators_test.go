@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/models"
+)
+
+func TestGetValidatorCheckinsFiltersByAdminAndRange(t *testing.T) {
+	db := openTestDB(t)
+	h := NewAdminHandler(db)
+	validator := createTestUser(t, db, "admin")
+	colleague := createTestUser(t, db, "admin")
+	event := createTestEvent(t, db, 10, 20)
+	now := time.Now().UTC()
+
+	// checkIn records a check-in of a new ticket by the admin, nil for an unattributed one
+	checkIn := func(by *models.User, age time.Duration) models.AttendanceLog {
+		ticket := createTestTicket(t, db, event, createTestUser(t, db, "user"))
+		log := models.AttendanceLog{TicketID: ticket.ID, CheckedInAt: now.Add(-age)}
+		if by != nil {
+			log.CheckedInBy = &by.ID
+		}
+		if err := db.Create(&log).Error; err != nil {
+			t.Fatalf("record attendance: %v", err)
+		}
+		return log
+	}
+	older := checkIn(&validator, 3*24*time.Hour)
+	newer := checkIn(&validator, 24*time.Hour)
+	checkIn(&validator, 10*24*time.Hour)
+	checkIn(&colleague, 24*time.Hour)
+	checkIn(nil, 24*time.Hour)
+
+	vars := map[string]string{"adminId": strconv.Itoa(int(validator.ID))}
+	target := "/api/admin/validators/" + vars["adminId"] + "/checkins?from=" + now.Add(-5*24*time.Hour).Format(time.RFC3339) + "&to=" + now.Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	h.GetValidatorCheckins(w, authedRequest("GET", target, "", colleague, vars))
+	if w.Code != http.StatusOK {
+		t.Fatalf("validator check-ins returned %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data  []models.AttendanceLog `json:"data"`
+		Total int64                  `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("decode check-ins: %v", err)
+	}
+
+	if response.Total != 2 || len(response.Data) != 2 {
+		t.Fatalf("got %d of %d check-ins, want 2", len(response.Data), response.Total)
+	}
+	if response.Data[0].ID != newer.ID || response.Data[1].ID != older.ID {
+		t.Fatalf("got check-ins %d and %d, want %d then %d", response.Data[0].ID, response.Data[1].ID, newer.ID, older.ID)
+	}
+	for _, log := range response.Data {
+		if log.CheckedInBy == nil || *log.CheckedInBy != validator.ID {
+			t.Errorf("check-in %d was recorded by %v", log.ID, log.CheckedInBy)
+		}
+		if log.Ticket.ID != log.TicketID || log.Ticket.Event.ID != event.ID {
+			t.Errorf("check-in %d is missing its ticket or event details", log.ID)
+		}
+	}
+}
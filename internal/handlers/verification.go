@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/logging"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/mailer"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ResendVerificationRequest represents the resend verification request payload
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// resendLimiter allows one verification email per address within a configured interval
+type resendLimiter struct {
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+func newResendLimiter() *resendLimiter {
+	return &resendLimiter{lastSent: make(map[string]time.Time)}
+}
+
+// allow reports whether a verification email may be sent to the address now
+func (l *resendLimiter) allow(email string, interval time.Duration, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop stale entries so the map does not grow without bound
+	for key, sentAt := range l.lastSent {
+		if now.Sub(sentAt) >= interval {
+			delete(l.lastSent, key)
+		}
+	}
+
+	if _, ok := l.lastSent[email]; ok {
+		return false
+	}
+	l.lastSent[email] = now
+	return true
+}
+
+// verificationTokenTTL returns how long verification links stay valid
+func verificationTokenTTL() time.Duration {
+	return time.Duration(config.GetInt("VERIFICATION_TOKEN_TTL_HOURS", 24)) * time.Hour
+}
+
+// verificationResendInterval returns the minimum time between verification emails to one address
+func verificationResendInterval() time.Duration {
+	return time.Duration(config.GetInt("VERIFICATION_RESEND_INTERVAL_SECONDS", 60)) * time.Second
+}
+
+// issueVerificationToken invalidates any outstanding tokens for the user, stores a new
+// one and returns the raw token to be emailed
+func issueVerificationToken(db *gorm.DB, user models.User) (string, error) {
+	token, err := auth.GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx := db.Begin()
+	if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.EmailVerificationToken{}).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	record := models.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(verificationTokenTTL()),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendVerificationEmail issues a new verification token and emails the verification link
+func sendVerificationEmail(db *gorm.DB, sender mailer.Sender, user models.User) error {
+	token, err := issueVerificationToken(db, user)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/auth/verify-email?token=%s", config.GetEnv("APP_BASE_URL", "http://localhost:8000"), token)
+	return sender.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires on %s.\n",
+			user.Name, link, format.Date(time.Now().Add(verificationTokenTTL()))),
+	})
+}
+
+// VerifyEmail marks the user owning a valid verification token as verified
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, "Verification token required")
+		return
+	}
+
+	var record models.EmailVerificationToken
+	if err := h.db.Where("token_hash = ? AND used_at IS NULL", auth.HashToken(token)).First(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusBadRequest, apierror.CodeInvalidToken, "Invalid or expired verification token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify email")
+		return
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidToken, "Invalid or expired verification token")
+		return
+	}
+
+	now := time.Now()
+	tx := h.db.Begin()
+	if err := tx.Model(&models.EmailVerificationToken{}).Where("id = ?", record.ID).Update("used_at", now).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify email")
+		return
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", record.UserID).Update("email_verified", true).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify email")
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify email")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email verified successfully"})
+}
+
+// ResendVerification re-sends the verification email to an unverified user. The response is
+// the same whether or not the email belongs to an account to avoid user enumeration.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.db == nil {
+		respondError(w, http.StatusServiceUnavailable, apierror.CodeDatabaseUnavailable, "Database connection not available")
+		return
+	}
+
+	var req ResendVerificationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email != "" && h.resendLimiter.allow(email, verificationResendInterval(), time.Now()) {
+		var user models.User
+		if err := h.db.Where("LOWER(email) = ?", email).First(&user).Error; err == nil && !user.EmailVerified {
+			if err := sendVerificationEmail(h.db, h.mailer, user); err != nil {
+				logging.FromContext(r.Context()).Error("Failed to resend verification email", "user_id", user.ID, "error", err)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the email belongs to an unverified account, a new verification link has been sent",
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/mailer"
+)
+
+// recordingSender keeps the messages sent through it
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (s *recordingSender) Send(msg mailer.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// sent returns the messages sent so far
+func (s *recordingSender) sent() []mailer.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mailer.Message(nil), s.messages...)
+}
+
+// verificationLink matches the token of the link in a verification email
+var verificationLink = regexp.MustCompile(`verify-email\?token=(\S+)`)
+
+// resendVerification asks for a new verification email and returns the response code
+func resendVerification(h *AuthHandler, email string) int {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/auth/resend-verification", strings.NewReader(`{"email": "`+email+`"}`))
+	r.Header.Set("Content-Type", "application/json")
+	h.ResendVerification(w, r)
+	return w.Code
+}
+
+// verifyEmail opens the verification link with the token and returns the response code
+func verifyEmail(h *AuthHandler, token string) int {
+	w := httptest.NewRecorder()
+	h.VerifyEmail(w, httptest.NewRequest("GET", "/api/auth/verify-email?token="+token, nil))
+	return w.Code
+}
+
+func TestResendVerificationReplacesToken(t *testing.T) {
+	db := openTestDB(t)
+	sender := &recordingSender{}
+	h := NewAuthHandler(db, sender)
+	t.Setenv("VERIFICATION_RESEND_INTERVAL_SECONDS", "0")
+	user := createTestUser(t, db, "user")
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		if code := resendVerification(h, user.Email); code != http.StatusOK {
+			t.Fatalf("resend %d returned %d", i+1, code)
+		}
+		messages := sender.sent()
+		if len(messages) != i+1 || messages[i].To != user.Email {
+			t.Fatalf("resend %d sent %+v", i+1, messages)
+		}
+		match := verificationLink.FindStringSubmatch(messages[i].Body)
+		if match == nil {
+			t.Fatalf("verification email has no link: %s", messages[i].Body)
+		}
+		tokens = append(tokens, match[1])
+	}
+	if tokens[0] == tokens[1] {
+		t.Fatal("resend issued the same token again")
+	}
+
+	if code := verifyEmail(h, tokens[0]); code != http.StatusBadRequest {
+		t.Fatalf("verification with the replaced token returned %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := verifyEmail(h, tokens[1]); code != http.StatusOK {
+		t.Fatalf("verification with the new token returned %d, want %d", code, http.StatusOK)
+	}
+	var stored models.User
+	db.Where("id = ?", user.ID).First(&stored)
+	if !stored.EmailVerified {
+		t.Fatal("user is not verified")
+	}
+
+	// Verified and unknown addresses get the same answer and no email
+	for _, email := range []string{user.Email, "nobody@example.com"} {
+		if code := resendVerification(h, email); code != http.StatusOK {
+			t.Fatalf("resend to %s returned %d, want %d", email, code, http.StatusOK)
+		}
+	}
+	if messages := sender.sent(); len(messages) != 2 {
+		t.Fatalf("%d emails sent, want no more after verification", len(messages))
+	}
+}
+
+func TestResendVerificationIsRateLimitedPerEmail(t *testing.T) {
+	db := openTestDB(t)
+	sender := &recordingSender{}
+	h := NewAuthHandler(db, sender)
+	t.Setenv("VERIFICATION_RESEND_INTERVAL_SECONDS", "60")
+	first := createTestUser(t, db, "user")
+	second := createTestUser(t, db, "user")
+
+	for _, email := range []string{first.Email, strings.ToUpper(first.Email), second.Email} {
+		if code := resendVerification(h, email); code != http.StatusOK {
+			t.Fatalf("resend to %s returned %d, want %d", email, code, http.StatusOK)
+		}
+	}
+	messages := sender.sent()
+	if len(messages) != 2 || messages[0].To != first.Email || messages[1].To != second.Email {
+		t.Fatalf("sent %+v, want one email to each address", messages)
+	}
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"event-ticketing-system/internal/version"
+)
+
+// GetVersion reports the build version, commit and build time of the running server along
+// with its Go runtime version
+func GetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(version.Get())
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/format"
+	"event-ticketing-system/pkg/mailer"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// WaitlistEntry is a waitlist enrollment with its position in the queue, starting at 1
+type WaitlistEntry struct {
+	models.Waitlist
+	Position int `json:"position"`
+}
+
+// waitlistAutoPromote reports whether a freed seat is issued to the oldest waitlisted user as
+// a ticket instead of only notifying them
+func waitlistAutoPromote() bool {
+	return config.GetEnv("WAITLIST_AUTO_PROMOTE", "false") == "true"
+}
+
+// JoinWaitlist enrolls the authenticated user on the waitlist of a sold out event
+func (h *TicketHandler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	userID := r.Context().Value("user_id")
+	if userID == nil {
+		respondError(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not authenticated")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	if event.Status == "cancelled" {
+		respondError(w, http.StatusBadRequest, apierror.CodeEventCancelled, "Cannot join the waitlist of cancelled events")
+		return
+	}
+	if event.Date.Before(time.Now()) {
+		respondError(w, http.StatusBadRequest, apierror.CodeEventPast, "Cannot join the waitlist of past events")
+		return
+	}
+	if event.Unlimited || event.Capacity-event.SoldCount > 0 {
+		respondError(w, http.StatusBadRequest, apierror.CodeTicketsAvailable, "Tickets are still available for this event")
+		return
+	}
+
+	var existing models.Waitlist
+	err = h.db.Where("event_id = ? AND user_id = ?", event.ID, userID.(uint)).First(&existing).Error
+	if err == nil {
+		respondError(w, http.StatusConflict, apierror.CodeAlreadyWaitlisted, "Already on the waitlist for this event")
+		return
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to join waitlist")
+		return
+	}
+
+	entry := models.Waitlist{EventID: event.ID, UserID: userID.(uint)}
+	if err := h.db.Create(&entry).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to join waitlist")
+		return
+	}
+
+	// Count the entries still waiting ahead of this one
+	var ahead int
+	if err := h.db.Model(&models.Waitlist{}).
+		Where("event_id = ? AND notified_at IS NULL AND promoted_ticket_id IS NULL AND (created_at < ? OR (created_at = ? AND id < ?))",
+			event.ID, entry.CreatedAt, entry.CreatedAt, entry.ID).
+		Count(&ahead).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to join waitlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(WaitlistEntry{Waitlist: entry, Position: ahead + 1})
+}
+
+// GetWaitlist lists the waitlist of an event in queue order with each entry's position and
+// whether it was notified or promoted (admin only)
+func (h *TicketHandler) GetWaitlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	eventID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid event ID")
+		return
+	}
+
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeEventNotFound, "Event not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve event")
+		return
+	}
+
+	var waitlist []models.Waitlist
+	if err := h.db.Preload("User").Where("event_id = ?", event.ID).
+		Order(stableOrder("created_at asc", "id")).Find(&waitlist).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve waitlist")
+		return
+	}
+
+	entries := make([]WaitlistEntry, 0, len(waitlist))
+	for i, entry := range waitlist {
+		entries = append(entries, WaitlistEntry{Waitlist: entry, Position: i + 1})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event_id": event.ID,
+		"waitlist": entries,
+	})
+}
+
+// serveWaitlist offers seats freed by a cancellation to the oldest waiting users of the event,
+// one user per seat. Failures are logged since the cancellation has already been committed.
+func (h *TicketHandler) serveWaitlist(eventID uint, seats int) {
+	for i := 0; i < seats; i++ {
+		served, err := h.serveNextWaitlistEntry(eventID)
+		if err != nil {
+			log.Printf("Failed to serve waitlist of event %d: %v", eventID, err)
+			return
+		}
+		if !served {
+			return
+		}
+	}
+}
+
+// serveNextWaitlistEntry notifies the oldest waiting user of the event, issuing them a ticket
+// first when auto-promotion is enabled. It reports false when nobody is waiting.
+func (h *TicketHandler) serveNextWaitlistEntry(eventID uint) (bool, error) {
+	var event models.Event
+	if err := h.db.Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if event.Status == "cancelled" || event.Date.Before(time.Now()) {
+		return false, nil
+	}
+
+	// A promoted ticket cannot pick a seat, so users waiting for seated events are only notified
+	seated, err := hasSeatMap(h.db, event.ID)
+	if err != nil {
+		return false, err
+	}
+
+	tx := h.db.Begin()
+
+	// Lock the entry so concurrent cancellations serve different users
+	var entry models.Waitlist
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").
+		Where("event_id = ? AND notified_at IS NULL AND promoted_ticket_id IS NULL", event.ID).
+		Order(stableOrder("created_at asc", "id")).First(&entry).Error; err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var user models.User
+	if err := tx.Where("id = ?", entry.UserID).First(&user).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	var ticket *models.Ticket
+	if waitlistAutoPromote() && !seated {
+		promoted, err := promoteWaitlistEntry(tx, event, user.ID)
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		ticket = promoted
+	}
+
+	updates := map[string]interface{}{"notified_at": time.Now()}
+	if ticket != nil {
+		updates["promoted_ticket_id"] = ticket.ID
+	}
+	if err := tx.Model(&models.Waitlist{}).Where("id = ?", entry.ID).UpdateColumns(updates).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+
+	if err := h.mailer.Send(waitlistMessage(event, user, ticket)); err != nil {
+		log.Printf("Failed to send waitlist email to user %d for event %d: %v", user.ID, event.ID, err)
+	}
+	return true, nil
+}
+
+// promoteWaitlistEntry issues a ticket for a freed seat to a waitlisted user inside tx. It
+// returns nil without an error when the seat cannot be issued automatically, because the event
+// has several ticket types, the type is full or the seat was bought in the meantime; the user
+// is then only notified.
+func promoteWaitlistEntry(tx *gorm.DB, event models.Event, userID uint) (*models.Ticket, error) {
+	ticketType, err := resolveTicketType(tx, event.ID, nil)
+	if err == errTicketTypeRequired {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	price := event.Price
+	var ticketTypeID *uint
+	if ticketType != nil {
+		fits, err := claimTicketTypeCapacity(tx, *ticketType, 1)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			return nil, nil
+		}
+		price = ticketType.Price
+		ticketTypeID = &ticketType.ID
+	}
+
+	claimed, err := claimSoldCount(tx, event.ID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, nil
+	}
+
+	ticket := models.Ticket{
+		EventID:      event.ID,
+		UserID:       &userID,
+		TicketTypeID: ticketTypeID,
+		Status:       "valid",
+		PricePaid:    price,
+	}
+	if err := createTicketWithUniqueQRInTx(tx, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// waitlistMessage builds the email telling a waitlisted user a seat opened up, or that they
+// were issued a ticket for it
+func waitlistMessage(event models.Event, user models.User, ticket *models.Ticket) mailer.Message {
+	if ticket != nil {
+		return mailer.Message{
+			To:      user.Email,
+			Subject: fmt.Sprintf("You got a ticket for %s", event.Title),
+			Body: fmt.Sprintf("Hi %s,\n\nA seat opened up for %s on %s and you were next on the waitlist, so a ticket has been issued to you.\n\nYour ticket ID is %d.\n",
+				user.Name, event.Title, format.Date(event.Date), ticket.ID),
+		}
+	}
+	return mailer.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("A seat opened up for %s", event.Title),
+		Body: fmt.Sprintf("Hi %s,\n\nA seat opened up for %s on %s and you are next on the waitlist. Tickets are sold first come, first served, so purchase yours soon.\n",
+			user.Name, event.Title, format.Date(event.Date)),
+	}
+}
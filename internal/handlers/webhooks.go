@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/webhook"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// Event types registered webhooks can subscribe to
+const (
+	ticketPurchasedWebhookEvent = "ticket_purchased"
+	ticketValidatedWebhookEvent = "ticket_validated"
+	orderExpiredWebhookEvent    = "order_expired"
+)
+
+// webhookEventTypes lists the event types a webhook can be registered for
+var webhookEventTypes = map[string]bool{
+	ticketPurchasedWebhookEvent: true,
+	ticketValidatedWebhookEvent: true,
+	lowInventoryWebhookEvent:    true,
+	orderExpiredWebhookEvent:    true,
+}
+
+// TicketPurchasedData is the data of a ticket_purchased webhook event
+type TicketPurchasedData struct {
+	OrderID   uint    `json:"order_id"`
+	EventID   uint    `json:"event_id"`
+	UserID    uint    `json:"user_id"`
+	Quantity  int     `json:"quantity"`
+	Total     float64 `json:"total"`
+	TicketIDs []uint  `json:"ticket_ids"`
+}
+
+// TicketValidatedData is the data of a ticket_validated webhook event
+type TicketValidatedData struct {
+	TicketID    uint      `json:"ticket_id"`
+	EventID     uint      `json:"event_id"`
+	UserID      *uint     `json:"user_id"`
+	CheckedInAt time.Time `json:"checked_in_at"`
+}
+
+// OrderExpiredData is the data of an order_expired webhook event
+type OrderExpiredData struct {
+	OrderID   uint   `json:"order_id"`
+	EventID   uint   `json:"event_id"`
+	UserID    uint   `json:"user_id"`
+	TicketIDs []uint `json:"ticket_ids"`
+}
+
+// NotifyOrderExpired queues the order_expired webhooks of an order released by
+// jobs.ExpirePendingOrders and offers its freed seats to the event's waitlist
+func (h *TicketHandler) NotifyOrderExpired(order models.Order) {
+	ticketIDs := make([]uint, 0, len(order.Tickets))
+	for _, ticket := range order.Tickets {
+		ticketIDs = append(ticketIDs, ticket.ID)
+	}
+	queueWebhookDeliveries(h.db, orderExpiredWebhookEvent, OrderExpiredData{
+		OrderID:   order.ID,
+		EventID:   order.EventID,
+		UserID:    order.UserID,
+		TicketIDs: ticketIDs,
+	})
+
+	if len(order.Tickets) > 0 {
+		h.serveWaitlist(order.EventID, len(order.Tickets))
+	}
+}
+
+// WebhookRequest represents the create and update webhook request payload
+type WebhookRequest struct {
+	URL       string `json:"url" binding:"required,url"`
+	EventType string `json:"event_type" binding:"required"`
+	Secret    string `json:"secret"` // generated on create when empty
+	Active    *bool  `json:"active"`
+}
+
+// validateWebhookRequest checks the URL and event type of a webhook request, returning the
+// error message for the client or an empty string
+func validateWebhookRequest(req WebhookRequest) string {
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if !webhookEventTypes[req.EventType] {
+		return "Event type must be ticket_purchased, ticket_validated, low_inventory or order_expired"
+	}
+	return ""
+}
+
+// queueWebhookDeliveries queues an event for every active webhook registered for its type.
+// The delivery worker sends them in the background, so failures here are only logged.
+func queueWebhookDeliveries(db *gorm.DB, eventType string, data interface{}) {
+	var hooks []models.Webhook
+	if err := db.Where("event_type = ? AND active", eventType).Find(&hooks).Error; err != nil {
+		log.Printf("Failed to queue %s webhooks: %v", eventType, err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(webhook.Event{Type: eventType, OccurredAt: now.UTC(), Data: data})
+	if err != nil {
+		log.Printf("Failed to encode %s webhook: %v", eventType, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        "pending",
+			NextAttemptAt: now,
+		}
+		if err := db.Create(&delivery).Error; err != nil {
+			log.Printf("Failed to queue %s webhook %d: %v", eventType, hook.ID, err)
+		}
+	}
+}
+
+// CreateWebhook registers a webhook endpoint for an event type (admin only). The signing secret
+// is only returned in this response.
+func (h *AdminHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req WebhookRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if message := validateWebhookRequest(req); message != "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, message)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := auth.GenerateSecureToken()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate webhook secret")
+			return
+		}
+		secret = generated
+	}
+
+	userID, _ := r.Context().Value("user_id").(uint)
+	hook := models.Webhook{
+		URL:       strings.TrimSpace(req.URL),
+		EventType: req.EventType,
+		Secret:    secret,
+		Active:    req.Active == nil || *req.Active,
+		CreatedBy: userID,
+	}
+	if err := h.db.Create(&hook).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create webhook")
+		return
+	}
+
+	recordAudit(h.db, r, "webhook.created", "webhook", hook.ID, hook.EventType+" "+hook.URL)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook": hook,
+		"secret":  secret,
+	})
+}
+
+// GetWebhooks lists the registered webhooks, optionally filtered by event type (admin only)
+func (h *AdminHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := h.db.Model(&models.Webhook{})
+	if eventType := r.URL.Query().Get("event_type"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	hooks := []models.Webhook{}
+	if err := query.Order(stableOrder("created_at desc", "id")).Find(&hooks).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve webhooks")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// UpdateWebhook changes the URL, event type, secret or active flag of a webhook (admin only)
+func (h *AdminHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	webhookID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid webhook ID")
+		return
+	}
+
+	var req WebhookRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidRequestBody, err.Error())
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	if message := validateWebhookRequest(req); message != "" {
+		respondError(w, http.StatusBadRequest, apierror.CodeValidationFailed, message)
+		return
+	}
+
+	var hook models.Webhook
+	if err := h.db.Where("id = ?", webhookID).First(&hook).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			respondError(w, http.StatusNotFound, apierror.CodeWebhookNotFound, "Webhook not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to retrieve webhook")
+		return
+	}
+
+	hook.URL = strings.TrimSpace(req.URL)
+	hook.EventType = req.EventType
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if err := h.db.Save(&hook).Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update webhook")
+		return
+	}
+
+	recordAudit(h.db, r, "webhook.updated", "webhook", hook.ID, hook.EventType+" "+hook.URL)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hook)
+}
+
+// DeleteWebhook removes a webhook and its pending deliveries (admin only)
+func (h *AdminHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get ID from URL parameters (Gorilla Mux way)
+	vars := mux.Vars(r)
+	id := vars["id"]
+	webhookID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, apierror.CodeInvalidID, "Invalid webhook ID")
+		return
+	}
+
+	tx := h.db.Begin()
+
+	result := tx.Where("id = ?", webhookID).Delete(&models.Webhook{})
+	if result.Error != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete webhook")
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		respondError(w, http.StatusNotFound, apierror.CodeWebhookNotFound, "Webhook not found")
+		return
+	}
+
+	if err := tx.Where("webhook_id = ? AND status = ?", webhookID, "pending").Delete(&models.WebhookDelivery{}).Error; err != nil {
+		tx.Rollback()
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete webhook")
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		respondError(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete webhook")
+		return
+	}
+
+	recordAudit(h.db, r, "webhook.deleted", "webhook", uint(webhookID), "")
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Webhook deleted successfully"})
+}
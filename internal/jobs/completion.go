@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Post-event ticket policies for POST_EVENT_TICKET_STATUS
+const (
+	PostEventExpire = "expired" // unused tickets expire once the event has ended
+	PostEventKeep   = "valid"   // unused tickets stay valid for late entry
+)
+
+// ExpireCompletedEventTickets marks the unused tickets of events that ended before now as
+// expired, treating an event as ended duration after its start. It returns the number of
+// tickets expired.
+func ExpireCompletedEventTickets(db *gorm.DB, duration time.Duration, now time.Time) (int64, error) {
+	result := db.Exec(
+		"UPDATE tickets SET status = 'expired', updated_at = ? WHERE status = 'valid' AND event_id IN (SELECT id FROM events WHERE date < ?)",
+		now, now.Add(-duration),
+	)
+	return result.RowsAffected, result.Error
+}
+
+// StartEventCompletionWorker applies the post-event ticket policy every interval in the
+// background. With the keep policy no tickets are changed. The returned function stops
+// the worker.
+func StartEventCompletionWorker(db *gorm.DB, interval, duration time.Duration, policy string) func() {
+	stop := make(chan struct{})
+	if policy != PostEventExpire {
+		return func() { close(stop) }
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				expired, err := ExpireCompletedEventTickets(db, duration, time.Now())
+				if err != nil {
+					log.Printf("Expiring tickets of completed events failed: %v", err)
+				} else if expired > 0 {
+					log.Printf("Expired %d unused tickets of completed events", expired)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
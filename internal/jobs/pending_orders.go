@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"event-ticketing-system/internal/models"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Order states a pending order is released into: expired once its reservation TTL passed,
+// failed when its payment did not go through
+const (
+	orderStatusPending = "pending"
+	OrderStatusExpired = "expired"
+	OrderStatusFailed  = "failed"
+)
+
+// ExpirePendingOrders releases, through ReleaseOrder, the pending orders whose reservation
+// expired before now. Orders without an expiry are left alone. notify, when set, is called with
+// each order once its release is committed. It returns the number of orders expired.
+func ExpirePendingOrders(db *gorm.DB, now time.Time, notify func(models.Order)) (int, error) {
+	var orderIDs []uint
+	if err := db.Model(&models.Order{}).Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", orderStatusPending, now).
+		Order("id").Pluck("id", &orderIDs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		order, ok, err := ReleaseOrder(db, orderID, OrderStatusExpired, now)
+		if err != nil {
+			return expired, err
+		}
+		if !ok {
+			continue
+		}
+		expired++
+		if notify != nil {
+			notify(order)
+		}
+	}
+	return expired, nil
+}
+
+// ReleaseOrder gives back what a pending order reserved and moves it to status. Its pending
+// tickets are cancelled, and the seats, sold count and promo code use they held are released.
+// The order's Idempotency-Key is cleared so the buyer can retry with it. It reports false when
+// the order is no longer pending.
+func ReleaseOrder(db *gorm.DB, orderID uint, status string, now time.Time) (models.Order, bool, error) {
+	tx := db.Begin()
+
+	// Lock the order so a payment completing at the same time either lands first or not at all
+	var order models.Order
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ? AND status = ?", orderID, orderStatusPending).First(&order).Error; err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			return models.Order{}, false, nil
+		}
+		return models.Order{}, false, err
+	}
+
+	var tickets []models.Ticket
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("order_id = ? AND status = ?", order.ID, "pending").
+		Order("id").Find(&tickets).Error; err != nil {
+		tx.Rollback()
+		return models.Order{}, false, err
+	}
+
+	// Ticket type sales are counted from uncancelled tickets, so cancelling frees them too
+	for _, ticket := range tickets {
+		if err := tx.Model(&models.Ticket{}).Where("id = ?", ticket.ID).
+			UpdateColumns(map[string]interface{}{"status": "cancelled", "updated_at": now}).Error; err != nil {
+			tx.Rollback()
+			return models.Order{}, false, err
+		}
+		if ticket.SeatID != nil {
+			if err := tx.Model(&models.Seat{}).Where("id = ?", *ticket.SeatID).
+				UpdateColumns(map[string]interface{}{"status": "available", "ticket_id": gorm.Expr("NULL"), "updated_at": now}).Error; err != nil {
+				tx.Rollback()
+				return models.Order{}, false, err
+			}
+		}
+	}
+
+	if len(tickets) > 0 {
+		if err := tx.Model(&models.Event{}).Where("id = ?", order.EventID).
+			UpdateColumn("sold_count", gorm.Expr("GREATEST(sold_count - ?, 0)", len(tickets))).Error; err != nil {
+			tx.Rollback()
+			return models.Order{}, false, err
+		}
+	}
+
+	if order.PromoCodeID != nil {
+		if err := tx.Model(&models.PromoCode{}).Where("id = ?", *order.PromoCodeID).
+			UpdateColumn("used_count", gorm.Expr("GREATEST(used_count - 1, 0)")).Error; err != nil {
+			tx.Rollback()
+			return models.Order{}, false, err
+		}
+	}
+
+	if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).
+		UpdateColumns(map[string]interface{}{"status": status, "idempotency_key": gorm.Expr("NULL"), "updated_at": now}).Error; err != nil {
+		tx.Rollback()
+		return models.Order{}, false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return models.Order{}, false, err
+	}
+
+	order.Status = status
+	order.IdempotencyKey = nil
+	order.Tickets = tickets
+	log.Printf("Released pending order %d as %s, freeing %d tickets of event %d", order.ID, status, len(tickets), order.EventID)
+	return order, true, nil
+}
+
+// StartPendingOrderSweeper runs ExpirePendingOrders every interval in the background. The
+// returned function stops the sweeper.
+func StartPendingOrderSweeper(db *gorm.DB, interval time.Duration, notify func(models.Order)) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := ExpirePendingOrders(db, time.Now(), notify); err != nil {
+					log.Printf("Pending order sweep failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
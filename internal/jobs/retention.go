@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RetentionPolicy configures the purge of data belonging to long past events
+type RetentionPolicy struct {
+	Window       time.Duration // events dated longer ago than this are purged
+	PurgeTickets bool          // delete the tickets as well as their attendance logs
+	DryRun       bool          // only report what would be purged
+}
+
+// RetentionReport counts what a retention run purged, or would purge on a dry run
+type RetentionReport struct {
+	Events         int
+	AttendanceLogs int64
+	Tickets        int64
+}
+
+// expiredEventIDs selects the events dated before cutoff that still hold data to purge:
+// attendance logs, or any tickets when tickets are purged too
+func expiredEventIDs(db *gorm.DB, cutoff time.Time, purgeTickets bool) ([]uint, error) {
+	condition := "EXISTS (SELECT 1 FROM attendance_logs JOIN tickets ON tickets.id = attendance_logs.ticket_id WHERE tickets.event_id = events.id)"
+	if purgeTickets {
+		condition += " OR EXISTS (SELECT 1 FROM tickets WHERE tickets.event_id = events.id)"
+	}
+
+	var eventIDs []uint
+	err := db.Table("events").Where("date < ?", cutoff).Where(condition).Order("id asc").Pluck("id", &eventIDs).Error
+	return eventIDs, err
+}
+
+// snapshotEvents records the sales and attendance figures of events that have no snapshot
+// yet, so the aggregates survive the purge of their tickets and attendance logs
+func snapshotEvents(tx *gorm.DB, eventIDs []uint, ticketsPurged bool, now time.Time) error {
+	if err := tx.Exec(
+		"INSERT INTO event_snapshots (event_id, sold, checked_in, revenue, tickets_purged, created_at) "+
+			"SELECT events.id, COUNT(tickets.id), COUNT(checkins.ticket_id), COALESCE(SUM(tickets.price_paid), 0), ?, ? "+
+			"FROM events "+
+			"LEFT JOIN tickets ON tickets.event_id = events.id AND tickets.user_id IS NOT NULL AND tickets.status <> 'cancelled' "+
+			"LEFT JOIN (SELECT DISTINCT ticket_id FROM attendance_logs) checkins ON checkins.ticket_id = tickets.id "+
+			"WHERE events.id IN (?) AND NOT EXISTS (SELECT 1 FROM event_snapshots WHERE event_snapshots.event_id = events.id) "+
+			"GROUP BY events.id",
+		ticketsPurged, now, eventIDs,
+	).Error; err != nil {
+		return err
+	}
+
+	// A snapshot taken when only the attendance logs were purged still holds the full figures
+	if ticketsPurged {
+		return tx.Exec("UPDATE event_snapshots SET tickets_purged = true WHERE event_id IN (?)", eventIDs).Error
+	}
+	return nil
+}
+
+// PurgeExpiredEventData deletes the attendance logs, and with PurgeTickets the tickets, of
+// events dated more than the retention window before now, after snapshotting their
+// aggregate figures. On a dry run nothing is changed and the report shows what would be
+// purged.
+func PurgeExpiredEventData(db *gorm.DB, policy RetentionPolicy, now time.Time) (RetentionReport, error) {
+	var report RetentionReport
+
+	eventIDs, err := expiredEventIDs(db, now.Add(-policy.Window), policy.PurgeTickets)
+	if err != nil || len(eventIDs) == 0 {
+		return report, err
+	}
+	report.Events = len(eventIDs)
+
+	tickets := "SELECT id FROM tickets WHERE event_id IN (?)"
+
+	if policy.DryRun {
+		if err := db.Table("attendance_logs").Where("ticket_id IN ("+tickets+")", eventIDs).Count(&report.AttendanceLogs).Error; err != nil {
+			return report, err
+		}
+		if policy.PurgeTickets {
+			if err := db.Table("tickets").Where("event_id IN (?)", eventIDs).Count(&report.Tickets).Error; err != nil {
+				return report, err
+			}
+		}
+		return report, nil
+	}
+
+	tx := db.Begin()
+
+	if err := snapshotEvents(tx, eventIDs, policy.PurgeTickets, now); err != nil {
+		tx.Rollback()
+		return RetentionReport{}, err
+	}
+
+	result := tx.Exec("DELETE FROM attendance_logs WHERE ticket_id IN ("+tickets+")", eventIDs)
+	if result.Error != nil {
+		tx.Rollback()
+		return RetentionReport{}, result.Error
+	}
+	report.AttendanceLogs = result.RowsAffected
+
+	if policy.PurgeTickets {
+		// Refunds are kept as financial records, everything else hanging off the tickets goes
+		for _, table := range []string{"ticket_fields", "ticket_transfers"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE ticket_id IN ("+tickets+")", eventIDs).Error; err != nil {
+				tx.Rollback()
+				return RetentionReport{}, err
+			}
+		}
+
+		result := tx.Exec("DELETE FROM tickets WHERE event_id IN (?)", eventIDs)
+		if result.Error != nil {
+			tx.Rollback()
+			return RetentionReport{}, result.Error
+		}
+		report.Tickets = result.RowsAffected
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return RetentionReport{}, err
+	}
+	return report, nil
+}
+
+// StartRetentionWorker applies the retention policy every interval in the background. The
+// returned function stops the worker.
+func StartRetentionWorker(db *gorm.DB, interval time.Duration, policy RetentionPolicy) func() {
+	stop := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				report, err := PurgeExpiredEventData(db, policy, time.Now())
+				if err != nil {
+					log.Printf("Purging data of expired events failed: %v", err)
+				} else if report.Events > 0 && policy.DryRun {
+					log.Printf("Retention dry run: would purge %d attendance logs and %d tickets of %d events", report.AttendanceLogs, report.Tickets, report.Events)
+				} else if report.Events > 0 {
+					log.Printf("Purged %d attendance logs and %d tickets of %d events", report.AttendanceLogs, report.Tickets, report.Events)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ReconcileSoldCounts recomputes each event's maintained sold count from its uncancelled tickets and
+// returns the number of events that had drifted. Each event is locked while it is
+// recounted so a purchase in flight is either fully counted or not at all.
+func ReconcileSoldCounts(db *gorm.DB) (int, error) {
+	// Events whose tickets were purged by the retention job keep their last sold count
+	var eventIDs []uint
+	if err := db.Table("events").Where("id NOT IN (SELECT event_id FROM event_snapshots WHERE tickets_purged)").
+		Pluck("id", &eventIDs).Error; err != nil {
+		return 0, err
+	}
+
+	corrected := 0
+	for _, eventID := range eventIDs {
+		fixed, err := reconcileEventSoldCount(db, eventID)
+		if err != nil {
+			return corrected, err
+		}
+		if fixed {
+			corrected++
+		}
+	}
+	return corrected, nil
+}
+
+func reconcileEventSoldCount(db *gorm.DB, eventID uint) (bool, error) {
+	tx := db.Begin()
+
+	var event struct{ SoldCount int }
+	if err := tx.Table("events").Set("gorm:query_option", "FOR UPDATE").
+		Select("sold_count").Where("id = ?", eventID).Scan(&event).Error; err != nil {
+		tx.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var sold int
+	if err := tx.Table("tickets").Where("event_id = ? AND status <> ?", eventID, "cancelled").Count(&sold).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if sold == event.SoldCount {
+		return false, tx.Rollback().Error
+	}
+
+	if err := tx.Table("events").Where("id = ?", eventID).UpdateColumn("sold_count", sold).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+
+	log.Printf("Corrected sold count for event %d from %d to %d", eventID, event.SoldCount, sold)
+	return true, nil
+}
+
+// StartSoldCountReconciler runs ReconcileSoldCounts every interval in the background.
+// The returned function stops the reconciler.
+func StartSoldCountReconciler(db *gorm.DB, interval time.Duration) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := ReconcileSoldCounts(db); err != nil {
+					log.Printf("Sold count reconciliation failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// PurgeExpiredBlacklistedTokens deletes the blacklist entries of tokens that have expired
+// and would be rejected anyway, returning the number of entries removed
+func PurgeExpiredBlacklistedTokens(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Exec("DELETE FROM blacklisted_tokens WHERE expires_at < ?", now)
+	return result.RowsAffected, result.Error
+}
+
+// StartTokenBlacklistCleanup runs PurgeExpiredBlacklistedTokens every interval in the
+// background. The returned function stops the cleanup.
+func StartTokenBlacklistCleanup(db *gorm.DB, interval time.Duration) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := PurgeExpiredBlacklistedTokens(db, time.Now()); err != nil {
+					log.Printf("Purging expired blacklisted tokens failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
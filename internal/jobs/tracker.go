@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job tracks the progress of a long running background task
+type Job struct {
+	ID          string     `json:"id"`
+	Type        string     `json:"type"`
+	OwnerID     uint       `json:"owner_id"`
+	Status      string     `json:"status"` // pending, running, completed
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Failed      int        `json:"failed"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Tracker keeps background jobs in memory so clients can poll their progress
+type Tracker struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewTracker creates an empty job tracker
+func NewTracker() *Tracker {
+	return &Tracker{jobs: make(map[string]*Job)}
+}
+
+// Create registers a new pending job
+func (t *Tracker) Create(jobType string, ownerID uint, total int) Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	job := &Job{
+		ID:        uuid.New().String(),
+		Type:      jobType,
+		OwnerID:   ownerID,
+		Status:    "pending",
+		Total:     total,
+		CreatedAt: time.Now(),
+	}
+	t.jobs[job.ID] = job
+	return *job
+}
+
+// Get returns a snapshot of a job
+func (t *Tracker) Get(id string) (Job, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	job, ok := t.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Start marks a job as running
+func (t *Tracker) Start(id string) {
+	t.update(id, func(job *Job) { job.Status = "running" })
+}
+
+// Progress records the outcome of one processed item
+func (t *Tracker) Progress(id string, succeeded bool) {
+	t.update(id, func(job *Job) {
+		job.Processed++
+		if !succeeded {
+			job.Failed++
+		}
+	})
+}
+
+// Complete marks a job as finished
+func (t *Tracker) Complete(id string) {
+	t.update(id, func(job *Job) {
+		now := time.Now()
+		job.Status = "completed"
+		job.CompletedAt = &now
+	})
+}
+
+func (t *Tracker) update(id string, fn func(job *Job)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if job, ok := t.jobs[id]; ok {
+		fn(job)
+	}
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"event-ticketing-system/internal/models"
+	"event-ticketing-system/pkg/webhook"
+
+	"github.com/jinzhu/gorm"
+)
+
+// webhookDeliveryBatch caps the deliveries sent per run so one run cannot block for long
+const webhookDeliveryBatch = 100
+
+// Backoff between webhook delivery attempts: doubling from the base, up to the cap
+const (
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = time.Hour
+)
+
+// webhookRetryDelay is the wait before the next attempt after attempts failed deliveries
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay
+}
+
+// DeliverPendingWebhooks sends the pending webhook deliveries that are due, oldest first. A
+// failed delivery is retried with backoff until maxAttempts, then marked failed. It returns
+// the number of deliveries that succeeded.
+func DeliverPendingWebhooks(db *gorm.DB, client *http.Client, now time.Time, maxAttempts int) (int, error) {
+	var deliveries []models.WebhookDelivery
+	if err := db.Where("status = ? AND next_attempt_at <= ?", "pending", now).
+		Order("next_attempt_at asc, id asc").Limit(webhookDeliveryBatch).Find(&deliveries).Error; err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		updates := map[string]interface{}{"attempts": delivery.Attempts + 1, "updated_at": time.Now()}
+
+		var hook models.Webhook
+		err := db.Where("id = ?", delivery.WebhookID).First(&hook).Error
+		switch {
+		case gorm.IsRecordNotFoundError(err) || (err == nil && !hook.Active):
+			updates["status"], updates["last_error"] = "failed", "webhook was removed or deactivated"
+		case err != nil:
+			return delivered, err
+		default:
+			err = webhook.Deliver(client, hook.URL, hook.Secret, []byte(delivery.Payload))
+			if err == nil {
+				deliveredAt := time.Now()
+				updates["status"], updates["delivered_at"], updates["last_error"] = "delivered", deliveredAt, ""
+				delivered++
+			} else if delivery.Attempts+1 >= maxAttempts {
+				updates["status"], updates["last_error"] = "failed", err.Error()
+			} else {
+				updates["next_attempt_at"], updates["last_error"] = now.Add(webhookRetryDelay(delivery.Attempts+1)), err.Error()
+			}
+		}
+
+		if err := db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).UpdateColumns(updates).Error; err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// StartWebhookDeliveryWorker runs DeliverPendingWebhooks every interval in the background.
+// The returned function stops the worker.
+func StartWebhookDeliveryWorker(db *gorm.DB, interval time.Duration, maxAttempts int) func() {
+	stop := make(chan struct{})
+	client := &http.Client{Timeout: 10 * time.Second}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := DeliverPendingWebhooks(db, client, time.Now(), maxAttempts); err != nil {
+					log.Printf("Delivering webhooks failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
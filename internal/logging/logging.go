@@ -0,0 +1,43 @@
+// Package logging sets up the structured logger and carries a request scoped logger in the
+// request context, so every line logged while serving a request names its request ID.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"event-ticketing-system/internal/config"
+)
+
+// contextKey is the type of the context key the request logger is stored under
+type contextKey struct{}
+
+// New builds the logger described by LOG_FORMAT (json or text) and LOG_LEVEL (debug, info,
+// warn or error), writing to stderr
+func New() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.GetEnv("LOG_LEVEL", "info"))); err != nil {
+		level = slog.LevelInfo
+	}
+	options := &slog.HandlerOptions{Level: level}
+
+	if strings.ToLower(config.GetEnv("LOG_FORMAT", "json")) == "text" {
+		return slog.New(slog.NewTextHandler(os.Stderr, options))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stderr, options))
+}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger outside a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
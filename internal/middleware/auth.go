@@ -5,10 +5,11 @@ import (
 	"net/http"
 	"strings"
 
+	"event-ticketing-system/internal/apierror"
 	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/logging"
 	"event-ticketing-system/internal/models"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/jinzhu/gorm"
 )
 
@@ -17,46 +18,66 @@ func JWTAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, `{"error": "Authorization header required"}`, http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "Authorization header required")
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 		if tokenString == authHeader {
-			http.Error(w, `{"error": "Bearer token required"}`, http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "Bearer token required")
 			return
 		}
 
-		// Parse and validate token
+		// Parse and validate token, telling clients whether a refresh would help
 		token, err := auth.ValidateToken(tokenString)
+		if err == auth.ErrTokenExpired {
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeTokenExpired, "Token has expired")
+			return
+		}
 		if err != nil {
-			http.Error(w, `{"error": "Invalid token"}`, http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Invalid token")
 			return
 		}
 
 		// Set user information in context
-		claims, ok := token.Claims.(jwt.MapClaims)
+		claims, ok := token.Claims.(*auth.Claims)
 		if !ok {
-			http.Error(w, `{"error": "Invalid token claims"}`, http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Invalid token claims")
 			return
 		}
 
-		userID := uint(claims["user_id"].(float64))
-		userRole := claims["role"].(string)
+		userID := claims.UserID
 
-		// Get user from database to ensure they still exist
 		db := r.Context().Value("db").(*gorm.DB)
+
+		// Reject tokens revoked on logout
+		revoked, err := auth.IsTokenBlacklisted(db, claims, tokenString)
+		if err != nil {
+			apierror.Respond(w, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate token")
+			return
+		}
+		if revoked {
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeTokenRevoked, "Token has been revoked")
+			return
+		}
+
+		// Get user from database to ensure they still exist
 		var user models.User
 		if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
-			http.Error(w, `{"error": "User not found"}`, http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User not found")
 			return
 		}
 
-		// Set user info in context for handlers to use
+		// Set user info in context for handlers to use. The role comes from the database rather
+		// than the token, so a role change applies to tokens issued before it.
 		ctx := context.WithValue(r.Context(), "user_id", userID)
-		ctx = context.WithValue(ctx, "user_role", userRole)
+		ctx = context.WithValue(ctx, "user_role", user.Role)
 		ctx = context.WithValue(ctx, "user", user)
+		ctx = context.WithValue(ctx, "elevated", claims.Elevated)
+
+		// Tag the rest of the request's log lines with the caller
+		ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("user_id", userID))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -67,15 +88,46 @@ func AdminAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userRole := r.Context().Value("user_role")
 		if userRole == nil {
-			http.Error(w, `{"error": "User role not found"}`, http.StatusUnauthorized)
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User role not found")
 			return
 		}
 
 		if userRole != "admin" {
-			http.Error(w, `{"error": "Admin access required"}`, http.StatusForbidden)
+			apierror.Respond(w, http.StatusForbidden, apierror.CodeForbidden, "Admin access required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireStepUp middleware rejects requests made without an elevated token when
+// REQUIRE_STEP_UP is enabled. Elevated tokens are issued by POST /api/admin/reauth.
+func RequireStepUp(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.StepUpRequired() && r.Context().Value("elevated") != true {
+			apierror.Respond(w, http.StatusForbidden, apierror.CodeStepUpRequired, "Re-authentication required for this action")
 			return
 		}
 
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+
+// OrganizerAuth middleware ensures user has organizer or admin role
+func OrganizerAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userRole := r.Context().Value("user_role")
+		if userRole == nil {
+			apierror.Respond(w, http.StatusUnauthorized, apierror.CodeUnauthenticated, "User role not found")
+			return
+		}
+
+		if userRole != "admin" && userRole != "organizer" {
+			apierror.Respond(w, http.StatusForbidden, apierror.CodeForbidden, "Organizer access required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
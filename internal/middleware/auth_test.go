@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/auth"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const testJWTSecret = "test-jwt-signing-secret-of-32-bytes"
+
+// signToken signs claims for user 1 expiring at expiresAt with the given secret
+func signToken(t *testing.T, secret string, expiresAt time.Time) string {
+	t.Helper()
+	claims := &auth.Claims{
+		UserID:         1,
+		Role:           "user",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: expiresAt.Unix(), IssuedAt: expiresAt.Add(-time.Hour).Unix()},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestJWTAuthRejectsExpiredAndInvalidTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	if err := auth.LoadSecret(); err != nil {
+		t.Fatalf("LoadSecret: %v", err)
+	}
+
+	expired := signToken(t, testJWTSecret, time.Now().Add(-time.Minute))
+	tests := []struct {
+		name   string
+		header string
+		code   string
+	}{
+		{name: "no header", header: "", code: apierror.CodeUnauthenticated},
+		{name: "not a bearer token", header: "Basic dXNlcjpwYXNz", code: apierror.CodeUnauthenticated},
+		{name: "expired", header: "Bearer " + expired, code: apierror.CodeTokenExpired},
+		{name: "malformed", header: "Bearer not-a-jwt", code: apierror.CodeTokenInvalid},
+		{name: "wrong signature", header: "Bearer " + signToken(t, "another-signing-secret-of-32-bytes", time.Now().Add(time.Hour)), code: apierror.CodeTokenInvalid},
+		{name: "expired with a wrong signature", header: "Bearer " + signToken(t, "another-signing-secret-of-32-bytes", time.Now().Add(-time.Minute)), code: apierror.CodeTokenInvalid},
+	}
+
+	handler := JWTAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler reached with a rejected token")
+	}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/me", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			var envelope apierror.Envelope
+			if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("decode error response %q: %v", w.Body.String(), err)
+			}
+			if w.Code != http.StatusUnauthorized || envelope.Error.Code != tt.code {
+				t.Errorf("got %d with code %q, want %d with %q", w.Code, envelope.Error.Code, http.StatusUnauthorized, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"os"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+// RequireJSON middleware rejects write requests whose body is not declared as
+// application/json with 415 Unsupported Media Type. Requests without a body are
+// allowed through. Set CONTENT_TYPE_ENFORCEMENT=lenient to disable the check in development.
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if os.Getenv("CONTENT_TYPE_ENFORCEMENT") == "lenient" || !hasBody(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				apierror.Respond(w, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBody reports whether the request carries a body
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || len(r.TransferEncoding) > 0
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+func TestRequireJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		contentType string
+		enforcement string
+		want        int
+	}{
+		{name: "JSON body", method: "POST", body: `{}`, contentType: "application/json", want: http.StatusOK},
+		{name: "JSON with charset", method: "PUT", body: `{}`, contentType: "application/json; charset=utf-8", want: http.StatusOK},
+		{name: "form post", method: "POST", body: "a=1", contentType: "application/x-www-form-urlencoded", want: http.StatusUnsupportedMediaType},
+		{name: "plain text", method: "PATCH", body: `{}`, contentType: "text/plain", want: http.StatusUnsupportedMediaType},
+		{name: "missing content type", method: "POST", body: `{}`, want: http.StatusUnsupportedMediaType},
+		{name: "write without a body", method: "POST", want: http.StatusOK},
+		{name: "read with a body", method: "GET", body: "a=1", contentType: "text/plain", want: http.StatusOK},
+		{name: "lenient mode", method: "POST", body: "a=1", contentType: "text/plain", enforcement: "lenient", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONTENT_TYPE_ENFORCEMENT", tt.enforcement)
+			handler := RequireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(tt.method, "/api/events", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("got %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnsupportedMediaType && !strings.Contains(w.Body.String(), apierror.CodeUnsupportedMediaType) {
+				t.Errorf("body = %s, want the %s error", w.Body.String(), apierror.CodeUnsupportedMediaType)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"event-ticketing-system/internal/config"
+)
+
+// Methods and request headers allowed cross-origin when CORS_ALLOWED_METHODS and
+// CORS_ALLOWED_HEADERS are not set
+const (
+	defaultCORSMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	defaultCORSHeaders = "Content-Type, Authorization, X-Request-ID, Idempotency-Key"
+)
+
+// splitList parses a comma separated list, trimming entries and skipping empty ones
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// CORSMiddleware allows cross-origin requests from the origins listed in CORS_ALLOWED_ORIGINS,
+// echoing the request origin in Access-Control-Allow-Origin; "*" allows any origin. When the
+// variable is not set no other origin is allowed. Preflight requests are answered with 204
+// without reaching the handlers.
+func CORSMiddleware(next http.Handler) http.Handler {
+	origins := make(map[string]bool)
+	for _, origin := range splitList(os.Getenv("CORS_ALLOWED_ORIGINS")) {
+		origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	methods := strings.Join(splitList(config.GetEnv("CORS_ALLOWED_METHODS", defaultCORSMethods)), ", ")
+	headers := strings.Join(splitList(config.GetEnv("CORS_ALLOWED_HEADERS", defaultCORSHeaders)), ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" {
+			// The response depends on the origin, so caches must not share it across origins
+			w.Header().Add("Vary", "Origin")
+		}
+		allowed := origin != "" && (origins["*"] || origins[origin])
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
+		}
+
+		if r.Method == http.MethodOptions {
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     string
+		method      string
+		origin      string
+		wantOrigin  string
+		wantStatus  int
+		wantMethods bool
+	}{
+		{name: "allowed origin", allowed: "https://app.example.com", method: "GET", origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantStatus: http.StatusOK},
+		{name: "disallowed origin", allowed: "https://app.example.com", method: "GET", origin: "https://evil.example.com", wantStatus: http.StatusOK},
+		{name: "no origins configured", allowed: "", method: "GET", origin: "https://app.example.com", wantStatus: http.StatusOK},
+		{name: "trailing slash in the allowlist", allowed: "https://app.example.com/", method: "GET", origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantStatus: http.StatusOK},
+		{name: "wildcard echoes the origin", allowed: "*", method: "GET", origin: "https://any.example.com", wantOrigin: "https://any.example.com", wantStatus: http.StatusOK},
+		{name: "allowed preflight", allowed: "https://app.example.com", method: "OPTIONS", origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantStatus: http.StatusNoContent, wantMethods: true},
+		{name: "disallowed preflight", allowed: "https://app.example.com", method: "OPTIONS", origin: "https://evil.example.com", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.allowed)
+			handler := CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(tt.method, "/api/events", nil)
+			r.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods") != ""; got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods set = %v, want %v", got, tt.wantMethods)
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"event-ticketing-system/internal/apierror"
+)
+
+// parseCIDRList parses a comma separated list of CIDR ranges or bare IP addresses,
+// logging and skipping invalid entries
+func parseCIDRList(value string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil {
+				bits := 128
+				if ip.To4() != nil {
+					ip = ip.To4()
+					bits = 32
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("Warning: ignoring invalid IP range %q", entry)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client that sent the request. X-Forwarded-For is only
+// honored when the direct peer is a trusted proxy, and is walked from the right so entries
+// added by the client itself are ignored.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(trustedProxies, ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !containsIP(trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// ClientIP returns the client address of the request, honoring X-Forwarded-For from the
+// proxies listed in TRUSTED_PROXIES
+func ClientIP(r *http.Request) string {
+	ip := clientIP(r, parseCIDRList(os.Getenv("TRUSTED_PROXIES")))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// AdminIPAllowlist middleware rejects requests from outside the ranges listed in
+// ADMIN_IP_ALLOWLIST with 403. When the variable is not set or empty, all addresses are
+// allowed; when it is set but none of its entries parse, every request is rejected.
+func AdminIPAllowlist(next http.Handler) http.Handler {
+	configured := strings.TrimSpace(os.Getenv("ADMIN_IP_ALLOWLIST"))
+	allowed := parseCIDRList(configured)
+	trustedProxies := parseCIDRList(os.Getenv("TRUSTED_PROXIES"))
+	if configured != "" && len(allowed) == 0 {
+		log.Printf("Warning: ADMIN_IP_ALLOWLIST %q has no valid entries, rejecting all admin requests", configured)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if configured == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := clientIP(r, trustedProxies)
+		if ip == nil || !containsIP(allowed, ip) {
+			apierror.Respond(w, http.StatusForbidden, apierror.CodeForbidden, "Admin access is not allowed from this address")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminIPAllowlist(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowlist  string
+		proxies    string
+		remoteAddr string
+		forwarded  string
+		want       int
+	}{
+		{name: "unset allows all", allowlist: "", remoteAddr: "203.0.113.7:4000", want: http.StatusOK},
+		{name: "allowed IP", allowlist: "203.0.113.7", remoteAddr: "203.0.113.7:4000", want: http.StatusOK},
+		{name: "blocked IP", allowlist: "203.0.113.7", remoteAddr: "198.51.100.1:4000", want: http.StatusForbidden},
+		{name: "inside CIDR", allowlist: "10.0.0.0/8, 192.168.1.0/24", remoteAddr: "192.168.1.42:4000", want: http.StatusOK},
+		{name: "outside CIDR", allowlist: "10.0.0.0/8, 192.168.1.0/24", remoteAddr: "192.168.2.42:4000", want: http.StatusForbidden},
+		{name: "IPv6 range", allowlist: "2001:db8::/32", remoteAddr: "[2001:db8::1]:4000", want: http.StatusOK},
+		{name: "bad entries are skipped", allowlist: "not-an-ip, 203.0.113.7", remoteAddr: "203.0.113.7:4000", want: http.StatusOK},
+		{name: "no valid entry rejects all", allowlist: "not-an-ip, 10.0.0.0/33", remoteAddr: "203.0.113.7:4000", want: http.StatusForbidden},
+		{name: "forwarded by a trusted proxy", allowlist: "203.0.113.7", proxies: "10.0.0.1", remoteAddr: "10.0.0.1:4000", forwarded: "203.0.113.7", want: http.StatusOK},
+		{name: "forwarded by an untrusted peer", allowlist: "203.0.113.7", remoteAddr: "198.51.100.1:4000", forwarded: "203.0.113.7", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_IP_ALLOWLIST", tt.allowlist)
+			t.Setenv("TRUSTED_PROXIES", tt.proxies)
+			handler := AdminIPAllowlist(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest("GET", "/api/admin/users", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("request from %s returned %d, want %d", tt.remoteAddr, w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"event-ticketing-system/internal/apierror"
+	"event-ticketing-system/internal/config"
+)
+
+// slidingWindow counts the requests of each key within the last window and admits at most
+// limit of them
+type slidingWindow struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	requests map[string][]time.Time
+}
+
+func newSlidingWindow(limit int, window time.Duration) *slidingWindow {
+	return &slidingWindow{limit: limit, window: window, requests: make(map[string][]time.Time)}
+}
+
+// allow records a request for key and reports whether it is within the limit. When it is
+// not, the returned duration is how long until the oldest counted request leaves the window.
+func (s *slidingWindow) allow(key string, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Forget requests that left the window, dropping keys with none left so the map does
+	// not grow without bound
+	for k, times := range s.requests {
+		kept := times[:0]
+		for _, at := range times {
+			if now.Sub(at) < s.window {
+				kept = append(kept, at)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.requests, k)
+		} else {
+			s.requests[k] = kept
+		}
+	}
+
+	times := s.requests[key]
+	if len(times) >= s.limit {
+		return false, times[0].Add(s.window).Sub(now)
+	}
+	s.requests[key] = append(times, now)
+	return true, 0
+}
+
+// requestEmail reads the email field of a JSON request body, leaving the body in place for
+// the handler. At most MAX_REQUEST_BODY_BYTES are buffered; the handler rejects larger bodies.
+func requestEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	limit := int64(config.GetInt("MAX_REQUEST_BODY_BYTES", 1<<20))
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || int64(len(body)) > limit {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+// RateLimit middleware allows each client IP at most AUTH_RATE_LIMIT requests per
+// AUTH_RATE_WINDOW_SECONDS to the wrapped route, answering excess requests with 429 and a
+// Retry-After header. With AUTH_RATE_LIMIT_BY_EMAIL=true attempts are counted per IP and
+// email of the request body instead. Each wrapped route counts separately; a limit of 0
+// disables the middleware.
+func RateLimit(next http.Handler) http.Handler {
+	limit := config.GetInt("AUTH_RATE_LIMIT", 5)
+	if limit <= 0 {
+		return next
+	}
+	window := time.Duration(config.GetInt("AUTH_RATE_WINDOW_SECONDS", 60)) * time.Second
+	byEmail := config.GetEnv("AUTH_RATE_LIMIT_BY_EMAIL", "false") == "true"
+	limiter := newSlidingWindow(limit, window)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ClientIP(r)
+		if byEmail {
+			key += "|" + requestEmail(r)
+		}
+
+		if ok, wait := limiter.allow(key, time.Now()); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Respond(w, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests, please try again later")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
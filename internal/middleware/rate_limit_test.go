@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// login sends a login attempt for email from remoteAddr and returns the recorded response
+func login(handler http.Handler, remoteAddr, email string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"email": "`+email+`", "password": "secret"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimitThrottlesAfterLimit(t *testing.T) {
+	t.Setenv("AUTH_RATE_LIMIT", "3")
+	t.Setenv("AUTH_RATE_WINDOW_SECONDS", "60")
+	handler := RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 1; i <= 3; i++ {
+		if w := login(handler, "203.0.113.7:4000", "user@example.com"); w.Code != http.StatusOK {
+			t.Fatalf("attempt %d returned %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+
+	w := login(handler, "203.0.113.7:4001", "other@example.com")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt 4 returned %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Fatalf("Retry-After = %q, want seconds within the window", w.Header().Get("Retry-After"))
+	}
+
+	// Other clients have their own allowance
+	if w := login(handler, "198.51.100.1:4000", "user@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("attempt from another IP returned %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimitByEmail(t *testing.T) {
+	t.Setenv("AUTH_RATE_LIMIT", "2")
+	t.Setenv("AUTH_RATE_LIMIT_BY_EMAIL", "true")
+	var bodies []string
+	handler := RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 1; i <= 2; i++ {
+		if w := login(handler, "203.0.113.7:4000", "User@Example.com"); w.Code != http.StatusOK {
+			t.Fatalf("attempt %d returned %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+	if w := login(handler, "203.0.113.7:4000", "user@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt 3 for the same email returned %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := login(handler, "203.0.113.7:4000", "other@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("attempt for another email returned %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// The handler still reads the whole body
+	if len(bodies) != 3 || !strings.Contains(bodies[0], `"password": "secret"`) {
+		t.Fatalf("handler read bodies %q", bodies)
+	}
+}
+
+func TestSlidingWindowAdmitsAgainAfterWindow(t *testing.T) {
+	limiter := newSlidingWindow(2, time.Minute)
+	start := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	limiter.allow("client", start)
+	limiter.allow("client", start.Add(30*time.Second))
+	if ok, wait := limiter.allow("client", start.Add(40*time.Second)); ok || wait != 20*time.Second {
+		t.Fatalf("third request in the window allowed %v with wait %v, want refused for 20s", ok, wait)
+	}
+	if ok, _ := limiter.allow("client", start.Add(time.Minute)); !ok {
+		t.Fatal("request after the oldest left the window was refused")
+	}
+}
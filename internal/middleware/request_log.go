@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"event-ticketing-system/internal/logging"
+
+	"github.com/google/uuid"
+)
+
+// requestIDHeader carries the request ID in both directions
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits the request IDs accepted from clients, so a forwarded ID cannot
+// inject arbitrary text into the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// statusRecorder remembers the status and size of a response for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush passes flushes through, so streamed responses such as the CSV export stay streamed
+func (w *statusRecorder) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// RequestLogging middleware gives each request an ID, taken from a well formed X-Request-ID
+// header or generated, and echoes it in the response. Handlers log through
+// logging.FromContext, which tags their lines with the ID. Every request is logged once it
+// completes, with its method, path, status and latency.
+func RequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		logger := logging.FromContext(r.Context()).With("request_id", requestID)
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(logging.WithLogger(r.Context(), logger)))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		logger.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", ClientIP(r),
+		)
+	})
+}
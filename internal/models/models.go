@@ -3,65 +3,345 @@ package models
 import (
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // User represents a user in the system
 type User struct {
+	ID             uint      `json:"id" gorm:"primary_key"`
+	Name           string    `json:"name" gorm:"not null" validate:"required"`
+	Email          string    `json:"email" gorm:"unique;not null" validate:"required,email"`
+	CanonicalEmail string    `json:"-" gorm:"index"` // used for duplicate checks, see auth.CanonicalEmail
+	Password       string    `json:"-" gorm:"not null" validate:"required"`
+	Role           string    `json:"role" gorm:"default:'user'" validate:"required,oneof=admin organizer user"`
+	EmailVerified  bool      `json:"email_verified" gorm:"default:false"`
+	InvitesOptOut  bool      `json:"invites_opt_out" gorm:"not null;default:false"` // unsubscribed from event invitations
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Event represents an event in the system
+type Event struct {
+	ID           uint      `json:"id" gorm:"primary_key"`
+	Title        string    `json:"title" gorm:"not null" validate:"required"`
+	Description  string    `json:"description" gorm:"not null" validate:"required"`
+	Date         time.Time `json:"date" gorm:"not null" validate:"required"`
+	Location     string    `json:"location" gorm:"not null" validate:"required"`
+	Category     string    `json:"category" gorm:"not null;default:'';index"` // lower case, empty when uncategorized
+	Capacity     int       `json:"capacity" gorm:"not null" validate:"required_unless=Unlimited true,omitempty,min=1"`
+	Unlimited    bool      `json:"unlimited" gorm:"not null;default:false"` // capacity is not enforced when set
+	SoldCount    int       `json:"sold_count" gorm:"not null;default:0"`    // maintained by purchases, see jobs.ReconcileSoldCounts
+	IsSoldOut    bool      `json:"is_sold_out" gorm:"-"`
+	Price        float64   `json:"price" gorm:"not null" validate:"required,min=0"`
+	OrganizerID  uint      `json:"organizer_id" gorm:"index"`
+	MaxTransfers int       `json:"max_transfers" gorm:"default:0"`         // 0 means unlimited
+	MaxPerUser   int       `json:"max_per_user" gorm:"not null;default:0"` // tickets one user may hold, 0 means unlimited
+	Status       string    `json:"status" gorm:"default:'active'" validate:"oneof=active cancelled"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Incremented by every update, so an update made from a stale copy of the event is rejected
+	Version int `json:"version" gorm:"not null;default:1"`
+
+	// Purchases admitted per second across all users during an on-sale, 0 means no limit
+	PurchaseRateLimit int `json:"purchase_rate_limit" gorm:"not null;default:0"`
+
+	// Lets tickets be scanned again without checking out, recording an attendance log per scan
+	AllowReentry bool `json:"allow_reentry" gorm:"not null;default:false"`
+
+	// Minutes an unpaid order holds its tickets before they are released, 0 uses
+	// PENDING_ORDER_TTL_MINUTES
+	ReservationTTLMinutes int `json:"reservation_ttl_minutes" gorm:"not null;default:0"`
+
+	// Set when the event is deleted; deleted events are hidden from queries but keep their tickets
+	DeletedAt *time.Time `json:"deleted_at,omitempty" sql:"index"`
+
+	// Relationships
+	Tickets     []Ticket     `json:"tickets,omitempty" gorm:"foreignkey:EventID"`
+	TicketTypes []TicketType `json:"ticket_types,omitempty" gorm:"foreignkey:EventID"`
+}
+
+// TicketType is a priced tier of tickets for an event, such as VIP or general admission
+type TicketType struct {
 	ID        uint      `json:"id" gorm:"primary_key"`
+	EventID   uint      `json:"event_id" gorm:"not null;index"`
 	Name      string    `json:"name" gorm:"not null" validate:"required"`
-	Email     string    `json:"email" gorm:"unique;not null" validate:"required,email"`
-	Password  string    `json:"-" gorm:"not null" validate:"required"`
-	Role      string    `json:"role" gorm:"default:'user'" validate:"required,oneof=admin user"`
+	Price     float64   `json:"price" gorm:"not null" validate:"min=0"`
+	Capacity  int       `json:"capacity" gorm:"not null" validate:"required,min=1"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// Event represents an event in the system
-type Event struct {
+// Order groups the tickets bought together in one purchase
+type Order struct {
 	ID          uint      `json:"id" gorm:"primary_key"`
-	Title       string    `json:"title" gorm:"not null" validate:"required"`
-	Description string    `json:"description" gorm:"not null" validate:"required"`
-	Date        time.Time `json:"date" gorm:"not null" validate:"required"`
-	Location    string    `json:"location" gorm:"not null" validate:"required"`
-	Capacity    int       `json:"capacity" gorm:"not null" validate:"required,min=1"`
-	Price       float64   `json:"price" gorm:"not null" validate:"required,min=0"`
+	UserID      uint      `json:"user_id" gorm:"not null;index;unique_index:idx_order_idempotency_key"`
+	EventID     uint      `json:"event_id" gorm:"not null;index"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	Discount    float64   `json:"discount" gorm:"not null;default:0"`
+	TaxRate     float64   `json:"tax_rate" gorm:"not null;default:0"` // percentage applied after the discount
+	PromoCodeID *uint     `json:"promo_code_id" gorm:"index"`         // promo code that gave the discount, if any
+	Total       float64   `json:"total" gorm:"not null;default:0"`    // amount owed after discount and tax
+	Status      string    `json:"status" gorm:"not null;default:'pending'" validate:"required,oneof=pending paid expired failed"`
+	ChargeID    string    `json:"charge_id,omitempty" gorm:"index"` // payment provider charge, for reconciliation
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
+	// When a pending order's tickets are released if it is still unpaid. Orders placed before
+	// reservations expired have none and are never released.
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+
+	// Idempotency-Key the purchase was made with, unique per buyer together with UserID
+	IdempotencyKey *string `json:"-" gorm:"unique_index:idx_order_idempotency_key"`
+	// Hash of the purchase request sent with the key, so the key cannot replay a different one
+	IdempotencyFingerprint string `json:"-"`
+
 	// Relationships
-	Tickets []Ticket `json:"tickets,omitempty" gorm:"foreignkey:EventID"`
+	Tickets []Ticket `json:"tickets,omitempty" gorm:"foreignkey:OrderID"`
 }
 
 // Ticket represents a ticket for an event
 type Ticket struct {
-	ID        uint      `json:"id" gorm:"primary_key"`
-	EventID   uint      `json:"event_id" gorm:"not null"`
-	UserID    uint      `json:"user_id" gorm:"not null"`
-	QRCode    string    `json:"qr_code" gorm:"unique;not null"`
-	Status    string    `json:"status" gorm:"default:'valid'" validate:"required,oneof=valid used"`
+	ID      uint  `json:"id" gorm:"primary_key"`
+	EventID uint  `json:"event_id" gorm:"not null;unique_index:idx_ticket_serial"`
+	UserID  *uint `json:"user_id"`               // nil for reserved tickets not yet assigned to a holder
+	OrderID *uint `json:"order_id" gorm:"index"` // nil for tickets not created by a purchase
+
+	TicketTypeID *uint   `json:"ticket_type_id" gorm:"index"` // nil for tickets sold before tiers existed
+	SeatID       *uint   `json:"seat_id" gorm:"index"`        // nil unless the event has a seat map
+	QRCode       string  `json:"qr_code" gorm:"unique;not null"`
+	Barcode      *string `json:"barcode,omitempty" gorm:"unique"` // numeric Code128 value, set when TICKET_BARCODES is enabled
+
+	// Tickets of an order that is not paid yet are pending and cannot be used
+	Status string `json:"status" gorm:"default:'valid'" validate:"required,oneof=pending valid used expired cancelled"`
+
+	// Price charged for the ticket at purchase time, before order level discounts and tax
+	PricePaid float64 `json:"price_paid" gorm:"not null;default:0"`
+
+	// Share of the order total charged for the ticket, after the order discount and tax. Tickets
+	// not bought through an order were not charged.
+	AmountCharged float64 `json:"amount_charged" gorm:"not null;default:0"`
+
+	// Sequential per-event number for pre-printed tickets, nil for purchased tickets
+	SerialNumber *int `json:"serial_number,omitempty" gorm:"unique_index:idx_ticket_serial"`
+
+	// Payment collected at the door for comp or unpaid tickets
+	DoorPaymentAmount *float64   `json:"door_payment_amount,omitempty"`
+	DoorPaymentMethod string     `json:"door_payment_method,omitempty"`
+	DoorPaidAt        *time.Time `json:"door_paid_at,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
 	// Relationships
-	Event           Event            `json:"event,omitempty" gorm:"foreignkey:EventID"`
-	User            User             `json:"user,omitempty" gorm:"foreignkey:UserID"`
-	AttendanceLogs  []AttendanceLog  `json:"attendance_logs,omitempty" gorm:"foreignkey:TicketID"`
+	Event          Event           `json:"event,omitempty" gorm:"foreignkey:EventID"`
+	User           User            `json:"user,omitempty" gorm:"foreignkey:UserID"`
+	AttendanceLogs []AttendanceLog `json:"attendance_logs,omitempty" gorm:"foreignkey:TicketID"`
+	Fields         []TicketField   `json:"custom_fields,omitempty" gorm:"foreignkey:TicketID"`
 }
 
 // AttendanceLog represents a check-in record for a ticket
 type AttendanceLog struct {
-	ID           uint      `json:"id" gorm:"primary_key"`
-	TicketID     uint      `json:"ticket_id" gorm:"not null"`
-	CheckedInAt  time.Time `json:"checked_in_at" gorm:"not null"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uint       `json:"id" gorm:"primary_key"`
+	TicketID     uint       `json:"ticket_id" gorm:"not null"`
+	CheckedInAt  time.Time  `json:"checked_in_at" gorm:"not null"`
+	CheckedInBy  *uint      `json:"checked_in_by" gorm:"index"` // the admin who validated the ticket
+	CheckedOutAt *time.Time `json:"checked_out_at"`             // set when the holder leaves, nil while on site
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 
 	// Relationships
 	Ticket Ticket `json:"ticket,omitempty" gorm:"foreignkey:TicketID"`
 }
 
+// TicketTransfer records a change of ownership for a ticket
+type TicketTransfer struct {
+	ID         uint      `json:"id" gorm:"primary_key"`
+	TicketID   uint      `json:"ticket_id" gorm:"not null;index"`
+	FromUserID uint      `json:"from_user_id" gorm:"not null"`
+	ToUserID   uint      `json:"to_user_id" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// EmailVerificationToken is a single-use token emailed to confirm a user's address
+type EmailVerificationToken struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RefreshToken is a long-lived token exchanged for new access tokens until it expires or is revoked
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PasswordResetToken is a single-use token emailed to let a user choose a new password
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PromoCode discounts purchases by a percentage or a fixed amount per order. Codes without an
+// event apply to every event.
+type PromoCode struct {
+	ID         uint       `json:"id" gorm:"primary_key"`
+	Code       string     `json:"code" gorm:"unique;not null"` // stored upper case, matched case-insensitively
+	EventID    *uint      `json:"event_id" gorm:"index"`
+	PercentOff float64    `json:"percent_off" gorm:"not null;default:0"`
+	AmountOff  float64    `json:"amount_off" gorm:"not null;default:0"`
+	MaxUses    int        `json:"max_uses" gorm:"not null;default:0"` // 0 means unlimited
+	UsedCount  int        `json:"used_count" gorm:"not null;default:0"`
+	Active     bool       `json:"active" gorm:"not null;default:true"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedBy  uint       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Webhook is an endpoint registered for one event type. Deliveries are signed with its secret.
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	URL       string    `json:"url" gorm:"not null"`
+	EventType string    `json:"event_type" gorm:"not null;index"`
+	Secret    string    `json:"-" gorm:"not null"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event queued for a webhook, retried with backoff until it is
+// delivered or runs out of attempts
+type WebhookDelivery struct {
+	ID            uint       `json:"id" gorm:"primary_key"`
+	WebhookID     uint       `json:"webhook_id" gorm:"not null;index"`
+	EventType     string     `json:"event_type" gorm:"not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"not null;default:'pending';index" validate:"required,oneof=pending delivered failed"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Waitlist enrolls a user for a sold out event. Entries are served oldest first when a
+// cancellation frees a seat.
+type Waitlist struct {
+	ID               uint       `json:"id" gorm:"primary_key"`
+	EventID          uint       `json:"event_id" gorm:"not null;unique_index:idx_waitlist_user"`
+	UserID           uint       `json:"user_id" gorm:"not null;unique_index:idx_waitlist_user"`
+	NotifiedAt       *time.Time `json:"notified_at"`        // set once the user was told a seat opened up
+	PromotedTicketID *uint      `json:"promoted_ticket_id"` // ticket issued by auto-promotion
+	CreatedAt        time.Time  `json:"created_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignkey:UserID"`
+}
+
+// Seat is a reserved seat of an event's seat map. Events with a seat map sell their seats
+// individually; a seat is reserved by the ticket holding it and freed when that ticket is
+// cancelled.
+type Seat struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	EventID   uint      `json:"event_id" gorm:"not null;unique_index:idx_seat_position"`
+	Section   string    `json:"section" gorm:"not null;unique_index:idx_seat_position"`
+	Row       string    `json:"row" gorm:"not null;unique_index:idx_seat_position"`
+	Number    string    `json:"number" gorm:"not null;unique_index:idx_seat_position"`
+	Status    string    `json:"status" gorm:"not null;default:'available'" validate:"oneof=available reserved"`
+	TicketID  *uint     `json:"ticket_id"` // ticket holding the seat, nil while available
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BlacklistedToken is an access token revoked on logout before its expiry. It is identified
+// by its jti claim, or by the hash of the token for tokens issued without one.
+type BlacklistedToken struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	TokenID   string    `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLog records an action performed on an entity for later review
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primary_key"`
+	ActorID    uint      `json:"actor_id"` // 0 for system actions
+	Action     string    `json:"action" gorm:"not null"`
+	EntityType string    `json:"entity_type" gorm:"not null;index:idx_audit_entity"`
+	EntityID   uint      `json:"entity_id" gorm:"not null;index:idx_audit_entity"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PurchaseFailure records a rejected ticket purchase attempt so organizers can gauge unmet demand
+type PurchaseFailure struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	EventID   uint      `json:"event_id" gorm:"not null;index"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Reason    string    `json:"reason" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CustomField is an extra registration question asked when buying tickets for an event
+type CustomField struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	EventID   uint      `json:"event_id" gorm:"not null;unique_index:idx_custom_field_name"`
+	Name      string    `json:"name" gorm:"not null;unique_index:idx_custom_field_name" validate:"required"`
+	Type      string    `json:"type" gorm:"not null;default:'text'" validate:"oneof=text number boolean"`
+	Required  bool      `json:"required" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TicketField stores the answer to an event's custom field for one ticket
+type TicketField struct {
+	ID            uint      `json:"id" gorm:"primary_key"`
+	TicketID      uint      `json:"ticket_id" gorm:"not null;index"`
+	CustomFieldID uint      `json:"custom_field_id" gorm:"not null"`
+	Value         string    `json:"value"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Refund records money owed back to a user for a cancelled ticket
+type Refund struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	TicketID  uint      `json:"ticket_id" gorm:"not null;index"`
+	OrderID   *uint     `json:"order_id" gorm:"index"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Amount    float64   `json:"amount" gorm:"not null"`
+	Status    string    `json:"status" gorm:"not null;default:'pending'" validate:"oneof=pending processed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EventSnapshot keeps the aggregate figures of an event whose tickets or attendance logs were
+// purged by the retention job
+type EventSnapshot struct {
+	ID            uint      `json:"id" gorm:"primary_key"`
+	EventID       uint      `json:"event_id" gorm:"unique;not null"`
+	Sold          int       `json:"sold"`
+	CheckedIn     int       `json:"checked_in"`
+	Revenue       float64   `json:"revenue"`
+	TicketsPurged bool      `json:"tickets_purged"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 // TableName overrides the table name used by User to `users`
 func (User) TableName() string {
 	return "users"
@@ -72,6 +352,16 @@ func (Event) TableName() string {
 	return "events"
 }
 
+// TableName overrides the table name used by TicketType to `ticket_types`
+func (TicketType) TableName() string {
+	return "ticket_types"
+}
+
+// TableName overrides the table name used by Order to `orders`
+func (Order) TableName() string {
+	return "orders"
+}
+
 // TableName overrides the table name used by Ticket to `tickets`
 func (Ticket) TableName() string {
 	return "tickets"
@@ -82,6 +372,82 @@ func (AttendanceLog) TableName() string {
 	return "attendance_logs"
 }
 
+// TableName overrides the table name used by TicketTransfer to `ticket_transfers`
+func (TicketTransfer) TableName() string {
+	return "ticket_transfers"
+}
+
+// TableName overrides the table name used by EmailVerificationToken to `email_verification_tokens`
+func (EmailVerificationToken) TableName() string {
+	return "email_verification_tokens"
+}
+
+// TableName overrides the table name used by AuditLog to `audit_logs`
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// TableName overrides the table name used by PurchaseFailure to `purchase_failures`
+func (PurchaseFailure) TableName() string {
+	return "purchase_failures"
+}
+
+// TableName overrides the table name used by CustomField to `custom_fields`
+func (CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// TableName overrides the table name used by TicketField to `ticket_fields`
+func (TicketField) TableName() string {
+	return "ticket_fields"
+}
+
+// TableName overrides the table name used by RefreshToken to `refresh_tokens`
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// TableName overrides the table name used by PasswordResetToken to `password_reset_tokens`
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// TableName overrides the table name used by BlacklistedToken to `blacklisted_tokens`
+func (BlacklistedToken) TableName() string {
+	return "blacklisted_tokens"
+}
+
+// TableName overrides the table name used by PromoCode to `promo_codes`
+func (PromoCode) TableName() string {
+	return "promo_codes"
+}
+
+// TableName overrides the table name used by Webhook to `webhooks`
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// TableName overrides the table name used by WebhookDelivery to `webhook_deliveries`
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// TableName overrides the table name used by Waitlist to `waitlists`
+func (Waitlist) TableName() string {
+	return "waitlists"
+}
+
+// TableName overrides the table name used by Refund to `refunds`
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// AfterFind hook to compute whether the event is sold out, which unlimited events never are
+func (e *Event) AfterFind() error {
+	e.IsSoldOut = !e.Unlimited && e.SoldCount >= e.Capacity
+	return nil
+}
+
 // BeforeCreate hook to hash password before saving
 func (u *User) BeforeCreate(scope *gorm.Scope) error {
 	if len(u.Password) == 0 {
@@ -114,4 +480,4 @@ func (u *User) BeforeUpdate(scope *gorm.Scope) error {
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
-}
\ No newline at end of file
+}
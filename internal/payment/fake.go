@@ -0,0 +1,79 @@
+package payment
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeDeclineToken is the token the fake processor declines, to exercise failed payments
+const FakeDeclineToken = "tok_decline"
+
+// FakeCharge is a charge recorded by the fake processor
+type FakeCharge struct {
+	ID       string
+	Amount   float64
+	Currency string
+	Token    string
+}
+
+// FakeProcessor accepts every token except FakeDeclineToken without moving money, and
+// records the charges it made
+type FakeProcessor struct {
+	mu      sync.Mutex
+	charges []FakeCharge
+	refunds []string
+}
+
+// NewFake creates an empty fake processor
+func NewFake() *FakeProcessor {
+	return &FakeProcessor{}
+}
+
+// Charge records the charge and returns a generated charge ID
+func (p *FakeProcessor) Charge(amount float64, currency, token string) (string, error) {
+	if token == FakeDeclineToken {
+		return "", ErrDeclined
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge := FakeCharge{
+		ID:       fmt.Sprintf("fake_ch_%d", len(p.charges)+1),
+		Amount:   amount,
+		Currency: currency,
+		Token:    token,
+	}
+	p.charges = append(p.charges, charge)
+	return charge.ID, nil
+}
+
+// Refund records the refund of a charge made by the processor
+func (p *FakeProcessor) Refund(chargeID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, charge := range p.charges {
+		if charge.ID == chargeID {
+			p.refunds = append(p.refunds, chargeID)
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to refund payment: unknown charge %s", chargeID)
+}
+
+// Charges returns the charges made so far, oldest first
+func (p *FakeProcessor) Charges() []FakeCharge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]FakeCharge(nil), p.charges...)
+}
+
+// Refunds returns the IDs of the charges refunded so far, oldest first
+func (p *FakeProcessor) Refunds() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.refunds...)
+}
@@ -0,0 +1,40 @@
+package payment
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrDeclined is returned when the provider refuses a charge, for example for an invalid
+// token or insufficient funds, as opposed to the provider being unreachable
+var ErrDeclined = errors.New("payment declined")
+
+// PaymentProcessor charges a payment token, returning the provider's charge ID, and refunds
+// charges in full
+type PaymentProcessor interface {
+	Charge(amount float64, currency, token string) (string, error)
+	Refund(chargeID string) error
+}
+
+// NewFromEnv creates the processor selected by PAYMENT_PROVIDER. Without a provider, or when
+// its credentials are missing, charges go to a fake processor that accepts every token.
+func NewFromEnv() PaymentProcessor {
+	provider := strings.ToLower(os.Getenv("PAYMENT_PROVIDER"))
+	switch provider {
+	case "stripe":
+		secretKey := os.Getenv("STRIPE_SECRET_KEY")
+		if secretKey == "" {
+			log.Println("Warning: STRIPE_SECRET_KEY is not set, payments will not be charged")
+			return NewFake()
+		}
+		return &StripeProcessor{SecretKey: secretKey}
+	case "", "fake":
+		log.Println("Warning: no payment provider is configured, payments will not be charged")
+		return NewFake()
+	default:
+		log.Printf("Warning: unknown PAYMENT_PROVIDER %q, payments will not be charged", provider)
+		return NewFake()
+	}
+}
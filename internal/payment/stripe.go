@@ -0,0 +1,115 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stripe charges and refunds APIs
+const (
+	defaultStripeEndpoint       = "https://api.stripe.com/v1/charges"
+	defaultStripeRefundEndpoint = "https://api.stripe.com/v1/refunds"
+)
+
+// StripeProcessor charges card tokens through the Stripe charges API
+type StripeProcessor struct {
+	SecretKey      string
+	Endpoint       string
+	RefundEndpoint string
+	Client         *http.Client
+}
+
+type stripeResponse struct {
+	ID    string `json:"id"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Charge charges amount, in major currency units, to the token. Card errors are reported as
+// ErrDeclined.
+func (p *StripeProcessor) Charge(amount float64, currency, token string) (string, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(int64(math.Round(amount*100)), 10))
+	form.Set("currency", strings.ToLower(currency))
+	form.Set("source", token)
+
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = defaultStripeEndpoint
+	}
+
+	charge, status, err := p.post(endpoint, form)
+	if err != nil {
+		return "", fmt.Errorf("failed to charge payment: %v", err)
+	}
+
+	if status < 200 || status >= 300 {
+		if charge.Error != nil && (charge.Error.Type == "card_error" || charge.Error.Type == "invalid_request_error") {
+			return "", fmt.Errorf("%w: %s", ErrDeclined, charge.Error.Message)
+		}
+		return "", fmt.Errorf("failed to charge payment: provider returned %d", status)
+	}
+	if charge.ID == "" {
+		return "", fmt.Errorf("failed to charge payment: provider returned no charge ID")
+	}
+	return charge.ID, nil
+}
+
+// Refund refunds a charge in full
+func (p *StripeProcessor) Refund(chargeID string) error {
+	form := url.Values{}
+	form.Set("charge", chargeID)
+
+	endpoint := p.RefundEndpoint
+	if endpoint == "" {
+		endpoint = defaultStripeRefundEndpoint
+	}
+
+	refund, status, err := p.post(endpoint, form)
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %v", err)
+	}
+	if status < 200 || status >= 300 {
+		if refund.Error != nil {
+			return fmt.Errorf("failed to refund payment: %s", refund.Error.Message)
+		}
+		return fmt.Errorf("failed to refund payment: provider returned %d", status)
+	}
+	return nil
+}
+
+// post sends a form to a Stripe endpoint and decodes the response, returning its status code
+func (p *StripeProcessor) post(endpoint string, form url.Values) (stripeResponse, int, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return stripeResponse{}, 0, err
+	}
+	req.SetBasicAuth(p.SecretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return stripeResponse{}, 0, err
+	}
+	defer resp.Body.Close()
+
+	var body stripeResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return stripeResponse{}, resp.StatusCode, fmt.Errorf("provider returned %d", resp.StatusCode)
+	}
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stripeServer answers Stripe requests with status and body, recording the last form posted
+func stripeServer(t *testing.T, status int, body string, form *map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); !ok || user != "sk_test" {
+			t.Errorf("request authenticated as %q, want the secret key", user)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		recorded := map[string]string{}
+		for key := range r.PostForm {
+			recorded[key] = r.PostForm.Get(key)
+		}
+		*form = recorded
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStripeCharge(t *testing.T) {
+	var form map[string]string
+	server := stripeServer(t, http.StatusOK, `{"id": "ch_123"}`, &form)
+	processor := &StripeProcessor{SecretKey: "sk_test", Endpoint: server.URL}
+
+	chargeID, err := processor.Charge(12.345, "USD", "tok_visa")
+	if err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	if chargeID != "ch_123" {
+		t.Errorf("charge ID = %q, want ch_123", chargeID)
+	}
+	if form["amount"] != "1235" || form["currency"] != "usd" || form["source"] != "tok_visa" {
+		t.Errorf("charge form = %v, want 1235 usd from tok_visa", form)
+	}
+}
+
+func TestStripeChargeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		declined bool
+	}{
+		{name: "card error", status: http.StatusPaymentRequired, body: `{"error": {"type": "card_error", "message": "Your card was declined."}}`, declined: true},
+		{name: "invalid token", status: http.StatusBadRequest, body: `{"error": {"type": "invalid_request_error", "message": "No such token"}}`, declined: true},
+		{name: "provider error", status: http.StatusInternalServerError, body: `{"error": {"type": "api_error", "message": "Oops"}}`},
+		{name: "unreadable body", status: http.StatusBadGateway, body: `<html>`},
+		{name: "no charge ID", status: http.StatusOK, body: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form map[string]string
+			server := stripeServer(t, tt.status, tt.body, &form)
+			processor := &StripeProcessor{SecretKey: "sk_test", Endpoint: server.URL}
+
+			_, err := processor.Charge(10, "usd", "tok_visa")
+			if err == nil {
+				t.Fatal("Charge succeeded, want an error")
+			}
+			if errors.Is(err, ErrDeclined) != tt.declined {
+				t.Errorf("Charge error %v, declined = %v, want %v", err, errors.Is(err, ErrDeclined), tt.declined)
+			}
+		})
+	}
+}
+
+func TestStripeRefund(t *testing.T) {
+	var form map[string]string
+	server := stripeServer(t, http.StatusOK, `{"id": "re_123"}`, &form)
+	processor := &StripeProcessor{SecretKey: "sk_test", RefundEndpoint: server.URL}
+
+	if err := processor.Refund("ch_123"); err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if form["charge"] != "ch_123" {
+		t.Errorf("refund form = %v, want charge ch_123", form)
+	}
+
+	failing := stripeServer(t, http.StatusBadRequest, `{"error": {"type": "invalid_request_error", "message": "Charge has already been refunded"}}`, &form)
+	processor.RefundEndpoint = failing.URL
+	if err := processor.Refund("ch_123"); err == nil {
+		t.Fatal("Refund of an already refunded charge succeeded, want an error")
+	}
+}
+
+func TestFakeProcessor(t *testing.T) {
+	processor := NewFake()
+	if _, err := processor.Charge(10, "usd", FakeDeclineToken); !errors.Is(err, ErrDeclined) {
+		t.Fatalf("Charge with the decline token returned %v, want ErrDeclined", err)
+	}
+
+	chargeID, err := processor.Charge(10, "usd", "tok_test")
+	if err != nil {
+		t.Fatalf("Charge: %v", err)
+	}
+	if err := processor.Refund(chargeID); err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if err := processor.Refund("ch_unknown"); err == nil {
+		t.Fatal("Refund of an unknown charge succeeded, want an error")
+	}
+	if refunds := processor.Refunds(); len(refunds) != 1 || refunds[0] != chargeID {
+		t.Fatalf("refunds = %v, want [%s]", refunds, chargeID)
+	}
+}
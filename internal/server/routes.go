@@ -0,0 +1,160 @@
+package server
+
+import (
+	"net/http"
+
+	"event-ticketing-system/internal/handlers"
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/middleware"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// setupRoutes configures all API routes
+func setupRoutes(r *mux.Router, db *gorm.DB) {
+	// Initialize handlers
+	sender := mailer.NewFromEnv()
+	authHandler := handlers.NewAuthHandler(db, sender)
+	eventHandler := handlers.NewEventHandler(db)
+	ticketHandler := handlers.NewTicketHandler(db, webhook.NewFromEnv(), sender, payment.NewFromEnv())
+	adminHandler := handlers.NewAdminHandler(db)
+	userHandler := handlers.NewUserHandler(db, sender)
+	notificationHandler := handlers.NewNotificationHandler(db, sender, jobs.NewTracker())
+	healthHandler := handlers.NewHealthHandler(db)
+
+	// Liveness and readiness probes, outside /api so they need no token
+	r.HandleFunc("/health", healthHandler.Health).Methods("GET")
+	r.HandleFunc("/ready", healthHandler.Ready).Methods("GET")
+
+	// Public routes
+	public := r.PathPrefix("/api").Subrouter()
+	{
+		public.HandleFunc("/version", handlers.GetVersion).Methods("GET")
+
+		// Authentication routes
+		public.Handle("/register", middleware.RateLimit(http.HandlerFunc(authHandler.Register))).Methods("POST")
+		public.Handle("/login", middleware.RateLimit(http.HandlerFunc(authHandler.Login))).Methods("POST")
+		public.HandleFunc("/logout", authHandler.Logout).Methods("POST")
+		public.HandleFunc("/refresh", authHandler.Refresh).Methods("POST")
+		public.HandleFunc("/auth/verify-email", authHandler.VerifyEmail).Methods("GET")
+		public.HandleFunc("/auth/resend-verification", authHandler.ResendVerification).Methods("POST")
+		public.Handle("/forgot-password", middleware.RateLimit(http.HandlerFunc(authHandler.ForgotPassword))).Methods("POST")
+		public.Handle("/reset-password", middleware.RateLimit(http.HandlerFunc(authHandler.ResetPassword))).Methods("POST")
+	}
+
+	// Protected routes
+	protected := r.PathPrefix("/api").Subrouter()
+	protected.Use(middleware.JWTAuth)
+	{
+		// Event routes (public for browsing, protected for creation)
+		protected.HandleFunc("/events", eventHandler.GetEvents).Methods("GET")
+		protected.HandleFunc("/categories", eventHandler.GetCategories).Methods("GET")
+		protected.HandleFunc("/events/calendar", eventHandler.GetEventCalendar).Methods("GET")
+		protected.HandleFunc("/events/locations", eventHandler.GetEventLocations).Methods("GET")
+		protected.HandleFunc("/events/{id}/custom-fields", eventHandler.GetCustomFields).Methods("GET")
+		protected.HandleFunc("/events/{id}", eventHandler.GetEvent).Methods("GET")
+
+		// Ticket routes
+		protected.HandleFunc("/events/{id}/purchase", ticketHandler.PurchaseTicket).Methods("POST")
+		protected.HandleFunc("/events/{id}/quote", ticketHandler.QuotePurchase).Methods("POST")
+		protected.HandleFunc("/events/{id}/waitlist", ticketHandler.JoinWaitlist).Methods("POST")
+		protected.HandleFunc("/events/{id}/seats", eventHandler.GetSeats).Methods("GET")
+		protected.HandleFunc("/tickets", ticketHandler.GetTickets).Methods("GET")
+		protected.HandleFunc("/tickets/{id}", ticketHandler.GetTicket).Methods("GET")
+		protected.HandleFunc("/tickets/{id}/transfer", ticketHandler.TransferTicket).Methods("POST")
+		protected.HandleFunc("/tickets/{id}/cancel", ticketHandler.CancelTicket).Methods("POST")
+		protected.HandleFunc("/tickets/{id}/history", ticketHandler.GetTicketHistory).Methods("GET")
+		protected.HandleFunc("/tickets/{id}/qr.png", ticketHandler.GetTicketQRImage).Methods("GET")
+		protected.HandleFunc("/tickets/{id}/barcode.png", ticketHandler.GetTicketBarcodeImage).Methods("GET")
+		protected.HandleFunc("/tickets/{id}/pdf", ticketHandler.GetTicketPDF).Methods("GET")
+		protected.HandleFunc("/tickets/{id}/qr/regenerate", ticketHandler.RegenerateTicketQR).Methods("POST")
+		protected.HandleFunc("/orders/{id}/receipt", ticketHandler.GetOrderReceipt).Methods("GET")
+		protected.HandleFunc("/orders/{id}/pay", ticketHandler.PayOrder).Methods("POST")
+
+		// Account routes
+		protected.HandleFunc("/me", userHandler.GetProfile).Methods("GET")
+		protected.HandleFunc("/me", userHandler.UpdateProfile).Methods("PUT")
+		protected.HandleFunc("/me/activity", userHandler.GetActivity).Methods("GET")
+		protected.HandleFunc("/me/unsubscribe", notificationHandler.Unsubscribe).Methods("POST")
+		protected.HandleFunc("/me/tickets/cancel", ticketHandler.CancelMyTickets).Methods("POST")
+	}
+
+	// Organizer routes (organizers and admins)
+	organizer := r.PathPrefix("/api").Subrouter()
+	organizer.Use(middleware.JWTAuth)
+	organizer.Use(middleware.OrganizerAuth)
+	{
+		// Event creation routes
+		organizer.HandleFunc("/events", eventHandler.CreateEvent).Methods("POST")
+
+		// Attendee notification routes
+		organizer.HandleFunc("/events/{id}/send-qr-all", notificationHandler.SendQRToAll).Methods("POST")
+		organizer.HandleFunc("/events/{id}/invite-from/{sourceEventId}", notificationHandler.InviteFromEvent).Methods("POST")
+		organizer.HandleFunc("/jobs/{id}", notificationHandler.GetJob).Methods("GET")
+		organizer.HandleFunc("/events/{id}/purchase-failures", ticketHandler.GetPurchaseFailures).Methods("GET")
+		organizer.HandleFunc("/events/{id}/tiers/stats", eventHandler.GetTierStats).Methods("GET")
+		organizer.HandleFunc("/promo-codes", ticketHandler.CreatePromoCode).Methods("POST")
+		organizer.HandleFunc("/promo-codes", ticketHandler.GetPromoCodes).Methods("GET")
+
+		// Organizer dashboard routes
+		organizer.HandleFunc("/organizer/tickets", ticketHandler.GetOrganizerTickets).Methods("GET")
+		organizer.HandleFunc("/organizer/events/export", eventHandler.ExportOrganizerEvents).Methods("GET")
+		organizer.HandleFunc("/organizer/no-show-rate", eventHandler.GetNoShowRate).Methods("GET")
+		organizer.HandleFunc("/me/events/grouped", eventHandler.GetMyEventsGrouped).Methods("GET")
+	}
+
+	// Admin routes
+	admin := r.PathPrefix("/api").Subrouter()
+	admin.Use(middleware.AdminIPAllowlist)
+	admin.Use(middleware.JWTAuth)
+	admin.Use(middleware.AdminAuth)
+	{
+		// Event management routes
+		admin.HandleFunc("/events/{id}", eventHandler.UpdateEvent).Methods("PUT")
+		admin.Handle("/events/{id}", middleware.RequireStepUp(http.HandlerFunc(eventHandler.DeleteEvent))).Methods("DELETE")
+		admin.HandleFunc("/events/{id}/custom-fields", eventHandler.CreateCustomField).Methods("POST")
+		admin.HandleFunc("/events/{id}/custom-fields/{fieldId}", eventHandler.DeleteCustomField).Methods("DELETE")
+
+		// Ticket validation routes
+		admin.HandleFunc("/tickets/validate", ticketHandler.ValidateTicketByQR).Methods("POST")
+		admin.HandleFunc("/tickets/validate/batch", ticketHandler.ValidateTicketBatch).Methods("POST")
+		admin.HandleFunc("/tickets/{id}/validate", ticketHandler.ValidateTicket).Methods("POST")
+		admin.HandleFunc("/tickets/{id}/checkin-and-pay", ticketHandler.CheckInAndPay).Methods("POST")
+		admin.HandleFunc("/tickets/{id}/checkout", ticketHandler.CheckOutTicket).Methods("POST")
+		admin.HandleFunc("/orders/{id}/checkin", ticketHandler.CheckInOrder).Methods("POST")
+
+		// Attendee management routes
+		admin.HandleFunc("/events/{id}/attendees", ticketHandler.GetEventAttendees).Methods("GET")
+		admin.HandleFunc("/events/{id}/attendees/export", ticketHandler.ExportAttendees).Methods("GET")
+		admin.HandleFunc("/events/{id}/attendees/export/preview", ticketHandler.PreviewAttendeesExport).Methods("GET")
+		admin.HandleFunc("/events/{id}/qr-manifest", ticketHandler.GetQRManifest).Methods("GET")
+		admin.HandleFunc("/events/{id}/waitlist", ticketHandler.GetWaitlist).Methods("GET")
+		admin.HandleFunc("/events/{id}/stats", eventHandler.GetEventStats).Methods("GET")
+		admin.HandleFunc("/events/{id}/seats", eventHandler.CreateSeats).Methods("POST")
+
+		// Pre-printed ticket routes
+		admin.HandleFunc("/events/{id}/reserve-range", ticketHandler.ReserveRange).Methods("POST")
+		admin.HandleFunc("/events/{id}/tickets/assign", ticketHandler.AssignTickets).Methods("POST")
+
+		// Platform metrics routes
+		admin.HandleFunc("/admin/metrics", adminHandler.GetMetrics).Methods("GET")
+		admin.Handle("/admin/webhooks", middleware.RequireStepUp(http.HandlerFunc(adminHandler.CreateWebhook))).Methods("POST")
+		admin.HandleFunc("/admin/webhooks", adminHandler.GetWebhooks).Methods("GET")
+		admin.Handle("/admin/webhooks/{id}", middleware.RequireStepUp(http.HandlerFunc(adminHandler.UpdateWebhook))).Methods("PUT")
+		admin.Handle("/admin/webhooks/{id}", middleware.RequireStepUp(http.HandlerFunc(adminHandler.DeleteWebhook))).Methods("DELETE")
+		admin.HandleFunc("/admin/search", adminHandler.Search).Methods("GET")
+		admin.HandleFunc("/admin/reconciliation", adminHandler.GetReconciliation).Methods("GET")
+		admin.HandleFunc("/admin/validators/{adminId}/checkins", adminHandler.GetValidatorCheckins).Methods("GET")
+
+		// User management routes
+		admin.HandleFunc("/admin/users", adminHandler.GetUsers).Methods("GET")
+		admin.Handle("/admin/users/{id}/role", middleware.RequireStepUp(http.HandlerFunc(adminHandler.UpdateUserRole))).Methods("PUT")
+
+		// Step-up authentication for destructive actions
+		admin.HandleFunc("/admin/reauth", authHandler.Reauth).Methods("POST")
+	}
+}
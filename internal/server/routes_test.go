@@ -0,0 +1,216 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// serve sends a request through the router, with a JSON body for writes and the bearer token
+// when one is given, from remoteAddr, and returns the response code
+func serve(router http.Handler, method, target, token, remoteAddr string) int {
+	var body *strings.Reader
+	if method == "GET" || method == "DELETE" {
+		body = strings.NewReader("")
+	} else {
+		body = strings.NewReader("{}")
+	}
+	r := httptest.NewRequest(method, target, body)
+	if body.Len() > 0 {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if remoteAddr != "" {
+		r.RemoteAddr = remoteAddr
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+// Routes of each access level, with a method the route answers to
+var (
+	protectedRoutes = [][2]string{
+		{"GET", "/api/events"},
+		{"GET", "/api/events/1"},
+		{"POST", "/api/events/1/purchase"},
+		{"GET", "/api/tickets"},
+		{"POST", "/api/orders/1/pay"},
+		{"GET", "/api/me"},
+	}
+	organizerRoutes = [][2]string{
+		{"POST", "/api/events"},
+		{"GET", "/api/promo-codes"},
+		{"GET", "/api/organizer/tickets"},
+		{"GET", "/api/events/1/tiers/stats"},
+	}
+	adminRoutes = [][2]string{
+		{"PUT", "/api/events/1"},
+		{"DELETE", "/api/events/1"},
+		{"POST", "/api/tickets/validate"},
+		{"GET", "/api/events/1/attendees/export"},
+		{"GET", "/api/admin/users"},
+		{"PUT", "/api/admin/users/1/role"},
+		{"POST", "/api/admin/webhooks"},
+	}
+)
+
+func TestRoutesResolve(t *testing.T) {
+	router := NewRouter(nil, "")
+
+	routes := append(append(append([][2]string{}, protectedRoutes...), organizerRoutes...), adminRoutes...)
+	routes = append(routes, [][2]string{{"GET", "/api/version"}, {"POST", "/api/login"}, {"POST", "/api/refresh"}, {"GET", "/health"}}...)
+	for _, route := range routes {
+		r := httptest.NewRequest(route[0], route[1], nil)
+		var match mux.RouteMatch
+		if !router.Match(r, &match) || match.MatchErr != nil {
+			t.Errorf("%s %s does not resolve: %v", route[0], route[1], match.MatchErr)
+		}
+	}
+}
+
+func TestPublicRoutesNeedNoToken(t *testing.T) {
+	router := NewRouter(nil, "")
+
+	for _, target := range []string{"/health", "/api/version"} {
+		if code := serve(router, "GET", target, "", ""); code != http.StatusOK {
+			t.Errorf("GET %s without a token returned %d, want %d", target, code, http.StatusOK)
+		}
+	}
+}
+
+func TestProtectedRoutesNeedToken(t *testing.T) {
+	router := NewRouter(nil, "")
+
+	routes := append(append(append([][2]string{}, protectedRoutes...), organizerRoutes...), adminRoutes...)
+	for _, route := range routes {
+		if code := serve(router, route[0], route[1], "", ""); code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a token returned %d, want %d", route[0], route[1], code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestAdminRoutesCheckIPAllowlist(t *testing.T) {
+	t.Setenv("ADMIN_IP_ALLOWLIST", "203.0.113.0/24")
+	router := NewRouter(nil, "")
+
+	for _, route := range adminRoutes {
+		if code := serve(router, route[0], route[1], "", "198.51.100.1:4000"); code != http.StatusForbidden {
+			t.Errorf("%s %s from outside the allowlist returned %d, want %d", route[0], route[1], code, http.StatusForbidden)
+		}
+		if code := serve(router, route[0], route[1], "", "203.0.113.9:4000"); code != http.StatusUnauthorized {
+			t.Errorf("%s %s from the allowlist without a token returned %d, want %d", route[0], route[1], code, http.StatusUnauthorized)
+		}
+	}
+
+	// Routes outside the admin group are reachable from anywhere
+	for _, route := range append(append([][2]string{}, protectedRoutes...), organizerRoutes...) {
+		if code := serve(router, route[0], route[1], "", "198.51.100.1:4000"); code != http.StatusUnauthorized {
+			t.Errorf("%s %s from outside the allowlist returned %d, want %d", route[0], route[1], code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestRoutesCheckRole(t *testing.T) {
+	db := openTestDB(t)
+	router := NewRouter(db, "")
+
+	tokens := map[string]string{}
+	for _, role := range []string{"user", "organizer", "admin"} {
+		user := models.User{Name: role, Email: role + "@example.com", Password: "not-a-real-hash", Role: role}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		token, err := auth.GenerateToken(user)
+		if err != nil {
+			t.Fatalf("GenerateToken: %v", err)
+		}
+		tokens[role] = token
+	}
+
+	allowed := func(code int) bool {
+		return code != http.StatusUnauthorized && code != http.StatusForbidden
+	}
+	for _, route := range protectedRoutes {
+		if code := serve(router, route[0], route[1], tokens["user"], ""); !allowed(code) {
+			t.Errorf("%s %s as a user returned %d", route[0], route[1], code)
+		}
+	}
+	for _, route := range organizerRoutes {
+		if code := serve(router, route[0], route[1], tokens["user"], ""); code != http.StatusForbidden {
+			t.Errorf("%s %s as a user returned %d, want %d", route[0], route[1], code, http.StatusForbidden)
+		}
+		if code := serve(router, route[0], route[1], tokens["organizer"], ""); !allowed(code) {
+			t.Errorf("%s %s as an organizer returned %d", route[0], route[1], code)
+		}
+	}
+	for _, route := range adminRoutes {
+		for _, role := range []string{"user", "organizer"} {
+			if code := serve(router, route[0], route[1], tokens[role], ""); code != http.StatusForbidden {
+				t.Errorf("%s %s as a %s returned %d, want %d", route[0], route[1], role, code, http.StatusForbidden)
+			}
+		}
+		if code := serve(router, route[0], route[1], tokens["admin"], ""); !allowed(code) {
+			t.Errorf("%s %s as an admin returned %d", route[0], route[1], code)
+		}
+	}
+
+	// GET /api/events/{id} is open to every user, while PUT and DELETE on the same path are
+	// admin routes
+	if code := serve(router, "GET", "/api/events/1", tokens["user"], ""); !allowed(code) {
+		t.Errorf("GET /api/events/1 as a user returned %d", code)
+	}
+	for _, method := range []string{"PUT", "DELETE"} {
+		if code := serve(router, method, "/api/events/1", tokens["user"], ""); code != http.StatusForbidden {
+			t.Errorf("%s /api/events/1 as a user returned %d, want %d", method, code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestStepUpRoutesNeedElevatedToken(t *testing.T) {
+	db := openTestDB(t)
+	t.Setenv("REQUIRE_STEP_UP", "true")
+	router := NewRouter(db, "")
+
+	admin := models.User{Name: "admin", Email: "admin@example.com", Password: "not-a-real-hash", Role: "admin"}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	token, err := auth.GenerateToken(admin)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	elevated, err := auth.GenerateElevatedToken(admin)
+	if err != nil {
+		t.Fatalf("GenerateElevatedToken: %v", err)
+	}
+
+	routes := [][2]string{
+		{"DELETE", "/api/events/1"},
+		{"PUT", "/api/admin/users/1/role"},
+		{"POST", "/api/admin/webhooks"},
+		{"PUT", "/api/admin/webhooks/1"},
+		{"DELETE", "/api/admin/webhooks/1"},
+	}
+	for _, route := range routes {
+		if code := serve(router, route[0], route[1], token, ""); code != http.StatusForbidden {
+			t.Errorf("%s %s with a plain admin token returned %d, want %d", route[0], route[1], code, http.StatusForbidden)
+		}
+		if code := serve(router, route[0], route[1], elevated, ""); code == http.StatusUnauthorized || code == http.StatusForbidden {
+			t.Errorf("%s %s with an elevated token returned %d", route[0], route[1], code)
+		}
+	}
+
+	// Reads stay open to plain admin tokens
+	if code := serve(router, "GET", "/api/admin/webhooks", token, ""); code != http.StatusOK {
+		t.Errorf("GET /api/admin/webhooks with a plain admin token returned %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,103 @@
+// Package server wires the handlers, middleware and documentation routes into the router
+// served by the application entry point.
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"event-ticketing-system/internal/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+)
+
+// NewRouter builds the application router: the API routes behind their middleware, the
+// health probes, and the Swagger document and UI served from swaggerFilePath. db may be nil
+// when the database is unavailable.
+func NewRouter(db *gorm.DB, swaggerFilePath string) *mux.Router {
+	r := mux.NewRouter()
+
+	// Add CORS middleware, allowing the origins in CORS_ALLOWED_ORIGINS
+	r.Use(middleware.CORSMiddleware)
+
+	// Require JSON bodies on write requests
+	r.Use(middleware.RequireJSON)
+
+	// Middleware to inject database into context
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), "db", db)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+
+	// Setup routes
+	setupRoutes(r, db)
+
+	// Match preflight requests on every path, so the CORS middleware answers them rather than
+	// the router rejecting the method
+	r.PathPrefix("/").Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Swagger JSON endpoint, serving the file SWAGGER_URL points at
+	r.Path("/docs/swagger.json").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, swaggerFilePath)
+	}))
+
+	// Swagger UI routes
+	r.PathPrefix("/swagger/").Handler(http.StripPrefix("/swagger/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
+			// Serve Swagger UI HTML page
+			w.Header().Set("Content-Type", "text/html")
+			html := `<!DOCTYPE html>
+<html lang="en">
+<head>
+	   <meta charset="UTF-8">
+	   <meta name="viewport" content="width=device-width, initial-scale=1.0">
+	   <title>Event Ticketing System API - Swagger UI</title>
+	   <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui.css" />
+	   <style>
+	       html { box-sizing: border-box; overflow: -moz-scrollbars-vertical; overflow-y: scroll; }
+	       *, *:before, *:after { box-sizing: inherit; }
+	       body { margin:0; background: #fafafa; }
+	   </style>
+</head>
+<body>
+	   <div id="swagger-ui"></div>
+	   <script src="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui-bundle.js"></script>
+	   <script src="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui-standalone-preset.js"></script>
+	   <script>
+	       window.onload = function() {
+	           const ui = SwaggerUIBundle({
+	               url: '/docs/swagger.json',
+	               dom_id: '#swagger-ui',
+	               deepLinking: true,
+	               presets: [
+	                   SwaggerUIBundle.presets.apis,
+	                   SwaggerUIStandalonePreset
+	               ],
+	               plugins: [
+	                   SwaggerUIBundle.plugins.DownloadUrl
+	               ],
+	               layout: "StandaloneLayout"
+	           });
+	       };
+	   </script>
+</body>
+</html>`
+			w.Write([]byte(html))
+		} else {
+			// For other assets, redirect to CDN
+			http.Redirect(w, r, "https://unpkg.com/swagger-ui-dist@4.15.5"+r.URL.Path, http.StatusMovedPermanently)
+		}
+	})))
+
+	// Redirect root path to Swagger UI
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/swagger/index.html", http.StatusFound)
+	})
+
+	return r
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/database"
+	"event-ticketing-system/internal/handlers"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+)
+
+// testQRSecret and testJWTSecret sign the QR payloads and tokens issued in tests
+const (
+	testQRSecret  = "test-qr-signing-secret-of-32-bytes!"
+	testJWTSecret = "test-jwt-signing-secret-of-32-bytes"
+)
+
+// openTestDB connects to the PostgreSQL database named by TEST_DATABASE_URL and migrates a
+// schema of its own, dropped when the test ends, as the handler tests do. Tests needing a
+// database are skipped when TEST_DATABASE_URL is not set.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := strings.TrimSpace(os.Getenv("TEST_DATABASE_URL"))
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	admin, err := gorm.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+		t.Fatalf("connect to test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	t.Setenv("QR_SIGNING_SECRET", testQRSecret)
+	if err := handlers.LoadQRSigningSecret(); err != nil {
+		t.Fatalf("load QR signing secret: %v", err)
+	}
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	if err := auth.LoadSecret(); err != nil {
+		t.Fatalf("load JWT secret: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test schema: %v", err)
+	}
+	return db
+}
+
+// withSearchPath points a connection string, in URL or key=value form, at a schema
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
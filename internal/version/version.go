@@ -0,0 +1,34 @@
+// Package version reports the build of the running server. The values are injected at
+// compile time, for example:
+//
+//	go build -ldflags "-X event-ticketing-system/internal/version.Version=1.2.0 \
+//		-X event-ticketing-system/internal/version.Commit=$(git rev-parse --short HEAD) \
+//		-X event-ticketing-system/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import "runtime"
+
+// Build values, "dev" when not injected
+var (
+	Version   = "dev"
+	Commit    = "dev"
+	BuildTime = "dev"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information of the running server
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}
@@ -17,29 +17,36 @@
 //
 // SecurityDefinitions:
 // Bearer:
-//   type: apiKey
-//   name: Authorization
-//   in: header
-//   description: "Enter the token in the format: Bearer {token}"
+//
+//	type: apiKey
+//	name: Authorization
+//	in: header
+//	description: "Enter the token in the format: Bearer {token}"
 //
 // swagger:meta
 package main
 
 import (
-	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"event-ticketing-system/internal/auth"
+	"event-ticketing-system/internal/config"
 	"event-ticketing-system/internal/database"
 	"event-ticketing-system/internal/handlers"
+	"event-ticketing-system/internal/jobs"
+	"event-ticketing-system/internal/logging"
 	"event-ticketing-system/internal/middleware"
-	"event-ticketing-system/internal/models"
+	"event-ticketing-system/internal/payment"
+	"event-ticketing-system/internal/server"
+	"event-ticketing-system/pkg/mailer"
+	"event-ticketing-system/pkg/webhook"
 
-	"github.com/gorilla/mux"
-	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	"github.com/joho/godotenv"
 )
@@ -50,95 +57,90 @@ func main() {
 		log.Println("Warning: No .env file found or error loading it:", err)
 	}
 
-	// Initialize Gorilla Mux router
-	r := mux.NewRouter()
+	// Log as structured records; the standard log package is routed through the same handler
+	slog.SetDefault(logging.New())
+
+	// Load the token signing key before any token can be issued or checked
+	if err := auth.LoadSecret(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Likewise the ticket QR signing key, before any ticket can be issued or scanned
+	if err := handlers.LoadQRSigningSecret(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize database connection
 	db := database.InitDB()
 	if db != nil {
 		defer db.Close()
 
-		// Auto-migrate the schema
-		db.AutoMigrate(&models.User{}, &models.Event{}, &models.Ticket{}, &models.AttendanceLog{})
-	} else {
-		log.Println("Warning: Database connection is not available. API endpoints requiring database will not work.")
-	}
+		// Auto-migrate the schema unless migrations are run separately via cmd/migrate
+		if ran, err := database.MigrateOnStartup(db); err != nil {
+			log.Printf("Warning: Auto-migration failed: %v", err)
+		} else if !ran {
+			log.Println("Skipping auto-migration (RUN_MIGRATIONS=false)")
+		}
 
-	// Add CORS middleware
-	r.Use(middleware.CORSMiddleware)
+		// Report tickets sharing a QR payload, which would make validation ambiguous
+		if os.Getenv("QR_INTEGRITY_CHECK") != "false" {
+			database.CheckQRCodeIntegrity(db)
+		}
+
+		// Periodically correct drift in the maintained event sold counts
+		if minutes := config.GetInt("SOLD_COUNT_RECONCILE_INTERVAL_MINUTES", 15); minutes > 0 {
+			stopReconciler := jobs.StartSoldCountReconciler(db, time.Duration(minutes)*time.Minute)
+			defer stopReconciler()
+		}
+
+		// Release the tickets held by orders left unpaid past their reservation
+		if minutes := config.GetInt("PENDING_ORDER_SWEEP_INTERVAL_MINUTES", 5); minutes > 0 {
+			ticketHandler := handlers.NewTicketHandler(db, webhook.NewFromEnv(), mailer.NewFromEnv(), payment.NewFromEnv())
+			stopSweeper := jobs.StartPendingOrderSweeper(db, time.Duration(minutes)*time.Minute, ticketHandler.NotifyOrderExpired)
+			defer stopSweeper()
+		}
 
-	// Middleware to inject database into context
-	r.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			ctx := context.WithValue(req.Context(), "db", db)
-			next.ServeHTTP(w, req.WithContext(ctx))
-		})
-	})
+		// Apply the post-event policy to unused tickets once events have ended
+		if minutes := config.GetInt("EVENT_COMPLETION_INTERVAL_MINUTES", 15); minutes > 0 {
+			policy := config.GetEnv("POST_EVENT_TICKET_STATUS", jobs.PostEventKeep)
+			stopCompletion := jobs.StartEventCompletionWorker(db, time.Duration(minutes)*time.Minute, handlers.EventDuration(), policy)
+			defer stopCompletion()
+		}
 
-	// Setup routes
-	setupRoutes(r, db)
+		// Drop blacklist entries of tokens that have expired since logout
+		if minutes := config.GetInt("TOKEN_BLACKLIST_CLEANUP_INTERVAL_MINUTES", 60); minutes > 0 {
+			stopBlacklistCleanup := jobs.StartTokenBlacklistCleanup(db, time.Duration(minutes)*time.Minute)
+			defer stopBlacklistCleanup()
+		}
+
+		// Send the deliveries queued for registered webhooks, retrying failures with backoff
+		if seconds := config.GetInt("WEBHOOK_DELIVERY_INTERVAL_SECONDS", 15); seconds > 0 {
+			stopWebhookDelivery := jobs.StartWebhookDeliveryWorker(db, time.Duration(seconds)*time.Second, config.GetInt("WEBHOOK_MAX_ATTEMPTS", 6))
+			defer stopWebhookDelivery()
+		}
+
+		// Purge the attendance logs, and optionally tickets, of long past events
+		if days := config.GetInt("RETENTION_DAYS", 0); days > 0 {
+			policy := jobs.RetentionPolicy{
+				Window:       time.Duration(days) * 24 * time.Hour,
+				PurgeTickets: config.GetEnv("RETENTION_PURGE_TICKETS", "false") == "true",
+				DryRun:       config.GetEnv("RETENTION_DRY_RUN", "false") == "true",
+			}
+			stopRetention := jobs.StartRetentionWorker(db, time.Duration(config.GetInt("RETENTION_INTERVAL_HOURS", 24))*time.Hour, policy)
+			defer stopRetention()
+		}
+	} else {
+		log.Println("Warning: Database connection is not available. API endpoints requiring database will not work.")
+	}
 
 	// Swagger JSON endpoint - serve dynamically from SWAGGER_URL environment variable
 	swaggerFilePath := getSwaggerFilePath()
 	if swaggerFilePath == "" {
 		log.Fatal("SWAGGER_URL environment variable is required but not set")
 	}
-	r.Path("/docs/swagger.json").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, swaggerFilePath)
-	}))
-
-	// Swagger UI routes
-	r.PathPrefix("/swagger/").Handler(http.StripPrefix("/swagger/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
-			// Serve Swagger UI HTML page
-			w.Header().Set("Content-Type", "text/html")
-			html := `<!DOCTYPE html>
-<html lang="en">
-<head>
-	   <meta charset="UTF-8">
-	   <meta name="viewport" content="width=device-width, initial-scale=1.0">
-	   <title>Event Ticketing System API - Swagger UI</title>
-	   <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui.css" />
-	   <style>
-	       html { box-sizing: border-box; overflow: -moz-scrollbars-vertical; overflow-y: scroll; }
-	       *, *:before, *:after { box-sizing: inherit; }
-	       body { margin:0; background: #fafafa; }
-	   </style>
-</head>
-<body>
-	   <div id="swagger-ui"></div>
-	   <script src="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui-bundle.js"></script>
-	   <script src="https://unpkg.com/swagger-ui-dist@4.15.5/swagger-ui-standalone-preset.js"></script>
-	   <script>
-	       window.onload = function() {
-	           const ui = SwaggerUIBundle({
-	               url: '/docs/swagger.json',
-	               dom_id: '#swagger-ui',
-	               deepLinking: true,
-	               presets: [
-	                   SwaggerUIBundle.presets.apis,
-	                   SwaggerUIStandalonePreset
-	               ],
-	               plugins: [
-	                   SwaggerUIBundle.plugins.DownloadUrl
-	               ],
-	               layout: "StandaloneLayout"
-	           });
-	       };
-	   </script>
-</body>
-</html>`
-			w.Write([]byte(html))
-		} else {
-			// For other assets, redirect to CDN
-			http.Redirect(w, r, "https://unpkg.com/swagger-ui-dist@4.15.5"+r.URL.Path, http.StatusMovedPermanently)
-		}
-	})))
 
-	// Redirect root path to Swagger UI
-	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		http.Redirect(w, r, "/swagger/index.html", http.StatusFound)
-	})
+	// All routes and their middleware are registered by the server package
+	r := server.NewRouter(db, swaggerFilePath)
 
 	// Get port from environment variable or default to 8000
 	port := os.Getenv("PORT")
@@ -148,56 +150,8 @@ func main() {
 
 	log.Printf("Server starting on port %s", port)
 	log.Printf("Swagger JSON available at http://localhost:%s/docs/swagger.json", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
-}
-
-// setupRoutes configures all API routes
-func setupRoutes(r *mux.Router, db *gorm.DB) {
-	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db)
-	eventHandler := handlers.NewEventHandler(db)
-	ticketHandler := handlers.NewTicketHandler(db)
-
-	// Public routes
-	public := r.PathPrefix("/api").Subrouter()
-	{
-		// Authentication routes
-		public.HandleFunc("/register", authHandler.Register).Methods("POST")
-		public.HandleFunc("/login", authHandler.Login).Methods("POST")
-		public.HandleFunc("/logout", authHandler.Logout).Methods("POST")
-	}
-
-	// Protected routes
-	protected := r.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.JWTAuth)
-	{
-		// Event routes (public for browsing, protected for creation)
-		protected.HandleFunc("/events", eventHandler.GetEvents).Methods("GET")
-		protected.HandleFunc("/events/{id}", eventHandler.GetEvent).Methods("GET")
-
-		// Ticket routes
-		protected.HandleFunc("/events/{id}/purchase", ticketHandler.PurchaseTicket).Methods("POST")
-		protected.HandleFunc("/tickets", ticketHandler.GetTickets).Methods("GET")
-		protected.HandleFunc("/tickets/{id}", ticketHandler.GetTicket).Methods("GET")
-	}
-
-	// Admin routes
-	admin := r.PathPrefix("/api").Subrouter()
-	admin.Use(middleware.JWTAuth)
-	admin.Use(middleware.AdminAuth)
-	{
-		// Event management routes
-		admin.HandleFunc("/events", eventHandler.CreateEvent).Methods("POST")
-		admin.HandleFunc("/events/{id}", eventHandler.UpdateEvent).Methods("PUT")
-		admin.HandleFunc("/events/{id}", eventHandler.DeleteEvent).Methods("DELETE")
-
-		// Ticket validation routes
-		admin.HandleFunc("/tickets/{id}/validate", ticketHandler.ValidateTicket).Methods("POST")
-
-		// Attendee management routes
-		admin.HandleFunc("/events/{id}/attendees", ticketHandler.GetEventAttendees).Methods("GET")
-		admin.HandleFunc("/events/{id}/attendees/export", ticketHandler.ExportAttendees).Methods("GET")
-	}
+	// Request logging wraps the router so unmatched routes are logged too
+	log.Fatal(http.ListenAndServe(":"+port, middleware.RequestLogging(r)))
 }
 
 // getSwaggerFilePath returns the full file path for swagger.json based on SWAGGER_URL environment variable
@@ -226,4 +180,4 @@ func getSwaggerFilePath() string {
 	}
 
 	return swaggerURL
-}
\ No newline at end of file
+}
@@ -0,0 +1,102 @@
+package format
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale describes how prices and dates are rendered in generated documents and emails
+type Locale struct {
+	Code              string
+	CurrencySymbol    string
+	SymbolAfter       bool
+	DecimalSeparator  string
+	ThousandSeparator string
+	Decimals          int
+	DateLayout        string
+}
+
+// neutral is the default locale: plain numbers and ISO-like dates
+var neutral = Locale{
+	Code:             "neutral",
+	DecimalSeparator: ".",
+	Decimals:         2,
+	DateLayout:       "2006-01-02 15:04 MST",
+}
+
+var locales = map[string]Locale{
+	"en-US": {Code: "en-US", CurrencySymbol: "$", DecimalSeparator: ".", ThousandSeparator: ",", Decimals: 2, DateLayout: "Jan 2, 2006 3:04 PM MST"},
+	"en-GB": {Code: "en-GB", CurrencySymbol: "£", DecimalSeparator: ".", ThousandSeparator: ",", Decimals: 2, DateLayout: "2 Jan 2006 15:04 MST"},
+	"de-DE": {Code: "de-DE", CurrencySymbol: " €", SymbolAfter: true, DecimalSeparator: ",", ThousandSeparator: ".", Decimals: 2, DateLayout: "02.01.2006 15:04 MST"},
+	"fr-FR": {Code: "fr-FR", CurrencySymbol: " €", SymbolAfter: true, DecimalSeparator: ",", ThousandSeparator: " ", Decimals: 2, DateLayout: "02/01/2006 15:04 MST"},
+	"id-ID": {Code: "id-ID", CurrencySymbol: "Rp", DecimalSeparator: ",", ThousandSeparator: ".", Decimals: 2, DateLayout: "02/01/2006 15:04 MST"},
+	"ja-JP": {Code: "ja-JP", CurrencySymbol: "¥", DecimalSeparator: ".", ThousandSeparator: ",", Decimals: 0, DateLayout: "2006/01/02 15:04 MST"},
+}
+
+// ForLocale returns the locale for a code such as "en-US", falling back to the neutral format
+func ForLocale(code string) Locale {
+	if locale, ok := locales[code]; ok {
+		return locale
+	}
+	return neutral
+}
+
+// Current returns the locale configured through OUTPUT_LOCALE
+func Current() Locale {
+	return ForLocale(os.Getenv("OUTPUT_LOCALE"))
+}
+
+// Price formats a monetary amount in the configured locale
+func Price(amount float64) string {
+	return Current().Price(amount)
+}
+
+// Date formats a time in the configured locale
+func Date(t time.Time) string {
+	return Current().Date(t)
+}
+
+// Price formats a monetary amount, e.g. $1,234.50 for en-US or 1.234,50 € for de-DE
+func (l Locale) Price(amount float64) string {
+	negative := amount < 0
+	scale := math.Pow(10, float64(l.Decimals))
+	rounded := math.Round(math.Abs(amount)*scale) / scale
+
+	number := strconv.FormatFloat(rounded, 'f', l.Decimals, 64)
+	integer, fraction := number, ""
+	if dot := strings.IndexByte(number, '.'); dot >= 0 {
+		integer, fraction = number[:dot], number[dot+1:]
+	}
+
+	// Group the integer part in thousands
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(l.ThousandSeparator)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	result := grouped.String()
+	if fraction != "" {
+		result += l.DecimalSeparator + fraction
+	}
+
+	if l.SymbolAfter {
+		result += l.CurrencySymbol
+	} else {
+		result = l.CurrencySymbol + result
+	}
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
+// Date formats a time using the locale's date layout
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.DateLayout)
+}
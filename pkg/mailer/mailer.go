@@ -0,0 +1,174 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message represents an email to be delivered
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(msg Message) error
+}
+
+// SMTPSender delivers messages through an SMTP server
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers a message through the configured SMTP server
+func (s *SMTPSender) Send(msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	if err := smtp.SendMail(s.Host+":"+s.Port, auth, s.From, []string{msg.To}, buildMessage(s.From, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
+
+// LogSender writes messages to the application log instead of delivering them
+type LogSender struct{}
+
+// Send logs the message
+func (LogSender) Send(msg Message) error {
+	log.Printf("Email to %s: %s (%d attachments)\n%s", msg.To, msg.Subject, len(msg.Attachments), msg.Body)
+	return nil
+}
+
+// NewFromEnv creates the sender selected by MAIL_PROVIDER (smtp, sendgrid, ses or log).
+// When MAIL_PROVIDER is not set, SMTP is used if SMTP_HOST is configured and messages
+// are logged otherwise.
+func NewFromEnv() Sender {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	provider := strings.ToLower(os.Getenv("MAIL_PROVIDER"))
+	if provider == "" {
+		provider = "smtp"
+		if os.Getenv("SMTP_HOST") == "" {
+			provider = "log"
+		}
+	}
+
+	switch provider {
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			log.Println("Warning: SMTP_HOST is not set, emails will be written to the log")
+			return LogSender{}
+		}
+
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+
+		return &SMTPSender{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "sendgrid":
+		apiKey := os.Getenv("SENDGRID_API_KEY")
+		if apiKey == "" {
+			log.Println("Warning: SENDGRID_API_KEY is not set, emails will be written to the log")
+			return LogSender{}
+		}
+		return &SendGridSender{APIKey: apiKey, From: from}
+	case "ses":
+		region := os.Getenv("AWS_REGION")
+		accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID")
+		secretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if region == "" || accessKeyID == "" || secretAccessKey == "" {
+			log.Println("Warning: AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for SES, emails will be written to the log")
+			return LogSender{}
+		}
+		return &SESSender{
+			Region:          region,
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			From:            from,
+		}
+	case "log":
+		log.Println("Warning: no mail provider is configured, emails will be written to the log")
+		return LogSender{}
+	default:
+		log.Printf("Warning: unknown MAIL_PROVIDER %q, emails will be written to the log", provider)
+		return LogSender{}
+	}
+}
+
+// buildMessage renders an RFC 822 message, using multipart/mixed when there are attachments
+func buildMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(msg.Attachments) == 0 {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(msg.Body)
+		return []byte(b.String())
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	b.WriteString("Content-Type: multipart/mixed; boundary=" + writer.Boundary() + "\r\n")
+	b.WriteString("\r\n")
+
+	text, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+	text.Write([]byte(msg.Body))
+
+	for _, attachment := range msg.Attachments {
+		part, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", attachment.Filename)},
+		})
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded))
+	}
+	writer.Close()
+
+	b.Write(body.Bytes())
+	return []byte(b.String())
+}
@@ -0,0 +1,129 @@
+package mailer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewFromEnvSelectsProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "nothing configured", env: map[string]string{}, want: "mailer.LogSender"},
+		{name: "SMTP host without a provider", env: map[string]string{"SMTP_HOST": "smtp.example.com"}, want: "*mailer.SMTPSender"},
+		{name: "smtp", env: map[string]string{"MAIL_PROVIDER": "smtp", "SMTP_HOST": "smtp.example.com"}, want: "*mailer.SMTPSender"},
+		{name: "smtp without a host", env: map[string]string{"MAIL_PROVIDER": "smtp"}, want: "mailer.LogSender"},
+		{name: "sendgrid", env: map[string]string{"MAIL_PROVIDER": "SendGrid", "SENDGRID_API_KEY": "key"}, want: "*mailer.SendGridSender"},
+		{name: "sendgrid without a key", env: map[string]string{"MAIL_PROVIDER": "sendgrid"}, want: "mailer.LogSender"},
+		{name: "ses", env: map[string]string{"MAIL_PROVIDER": "ses", "AWS_REGION": "eu-west-1", "AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "secret"}, want: "*mailer.SESSender"},
+		{name: "ses without credentials", env: map[string]string{"MAIL_PROVIDER": "ses", "AWS_REGION": "eu-west-1"}, want: "mailer.LogSender"},
+		{name: "unknown provider", env: map[string]string{"MAIL_PROVIDER": "pigeon", "SMTP_HOST": "smtp.example.com"}, want: "mailer.LogSender"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"MAIL_PROVIDER", "SMTP_HOST", "SENDGRID_API_KEY", "AWS_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+				t.Setenv(name, tt.env[name])
+			}
+			if got := fmt.Sprintf("%T", NewFromEnv()); got != tt.want {
+				t.Errorf("NewFromEnv() is %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// capturedRequest is the part of a provider API request the tests inspect
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// providerServer starts an API stand-in answering with status and returns the requests it received
+func providerServer(t *testing.T, status int) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+	var requests []capturedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, capturedRequest{header: r.Header.Clone(), body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+var testMessage = Message{
+	To:          "attendee@example.com",
+	Subject:     "Your tickets",
+	Body:        "See attached",
+	Attachments: []Attachment{{Filename: "ticket_1.png", ContentType: "image/png", Data: []byte("png")}},
+}
+
+func TestSendGridSenderCallsAPI(t *testing.T) {
+	server, requests := providerServer(t, http.StatusAccepted)
+	var sender Sender = &SendGridSender{APIKey: "sg-key", From: "events@example.com", Endpoint: server.URL}
+
+	if err := sender.Send(testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("SendGrid received %d requests, want 1", len(*requests))
+	}
+	got := (*requests)[0]
+	if auth := got.header.Get("Authorization"); auth != "Bearer sg-key" {
+		t.Errorf("Authorization is %q", auth)
+	}
+
+	var payload sendGridRequest
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode SendGrid payload: %v", err)
+	}
+	if payload.From.Email != "events@example.com" || payload.Subject != testMessage.Subject ||
+		len(payload.Personalizations) != 1 || payload.Personalizations[0].To[0].Email != testMessage.To {
+		t.Errorf("SendGrid payload is %+v", payload)
+	}
+	if len(payload.Attachments) != 1 || payload.Attachments[0].Filename != "ticket_1.png" {
+		t.Errorf("SendGrid attachments are %+v", payload.Attachments)
+	}
+
+	failing, _ := providerServer(t, http.StatusUnauthorized)
+	sender = &SendGridSender{APIKey: "bad-key", From: "events@example.com", Endpoint: failing.URL}
+	if err := sender.Send(testMessage); err == nil {
+		t.Fatal("Send succeeded against a rejecting API")
+	}
+}
+
+func TestSESSenderCallsAPI(t *testing.T) {
+	server, requests := providerServer(t, http.StatusOK)
+	var sender Sender = &SESSender{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", From: "events@example.com", Endpoint: server.URL}
+
+	if err := sender.Send(testMessage); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("SES received %d requests, want 1", len(*requests))
+	}
+	got := (*requests)[0]
+	if auth := got.header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") {
+		t.Errorf("Authorization is %q", auth)
+	}
+
+	var payload sesRequest
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("decode SES payload: %v", err)
+	}
+	raw := string(payload.Content.Raw.Data)
+	if payload.FromEmailAddress != "events@example.com" || len(payload.Destination.ToAddresses) != 1 ||
+		payload.Destination.ToAddresses[0] != testMessage.To {
+		t.Errorf("SES payload is %+v", payload)
+	}
+	if !strings.Contains(raw, "Subject: "+testMessage.Subject) || !strings.Contains(raw, `filename="ticket_1.png"`) {
+		t.Errorf("SES raw message is missing the subject or attachment:\n%s", raw)
+	}
+}
@@ -0,0 +1,103 @@
+package pdf
+
+import (
+	"bytes"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Document is a single-column A4 document built from headings, text lines and tables
+type Document struct {
+	pdf       *fpdf.Fpdf
+	translate func(string) string
+}
+
+// New creates an empty document with one page
+func New() *Document {
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.SetMargins(20, 20, 20)
+	doc.AddPage()
+	return &Document{
+		pdf: doc,
+		// The core fonts are cp1252 encoded, so UTF-8 text such as currency symbols is translated
+		translate: doc.UnicodeTranslatorFromDescriptor(""),
+	}
+}
+
+// Heading writes a large bold line
+func (d *Document) Heading(text string) {
+	d.pdf.SetFont("Helvetica", "B", 18)
+	d.pdf.CellFormat(0, 10, d.translate(text), "", 1, "L", false, 0, "")
+	d.pdf.Ln(2)
+}
+
+// Text writes a line of regular text
+func (d *Document) Text(text string) {
+	d.pdf.SetFont("Helvetica", "", 11)
+	d.pdf.CellFormat(0, 6, d.translate(text), "", 1, "L", false, 0, "")
+}
+
+// Image places a PNG image of size by size millimetres below the current line, centred on the
+// page, and moves past it. name identifies the image within the document.
+func (d *Document) Image(name string, png []byte, size float64) error {
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	d.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(png))
+	if err := d.pdf.Error(); err != nil {
+		return err
+	}
+
+	pageWidth, _ := d.pdf.GetPageSize()
+	d.pdf.ImageOptions(name, (pageWidth-size)/2, d.pdf.GetY(), size, size, true, options, 0, "")
+	return d.pdf.Error()
+}
+
+// Space adds vertical space in millimetres
+func (d *Document) Space(height float64) {
+	d.pdf.Ln(height)
+}
+
+// Table writes a bordered table. Column widths are in millimetres and columns after the
+// first are right aligned, which suits label and amount columns.
+func (d *Document) Table(header []string, widths []float64, rows [][]string) {
+	d.pdf.SetFont("Helvetica", "B", 10)
+	for i, cell := range header {
+		d.pdf.CellFormat(widths[i], 7, d.translate(cell), "1", 0, alignment(i), false, 0, "")
+	}
+	d.pdf.Ln(-1)
+
+	d.pdf.SetFont("Helvetica", "", 10)
+	for _, row := range rows {
+		for i, cell := range row {
+			d.pdf.CellFormat(widths[i], 7, d.translate(cell), "1", 0, alignment(i), false, 0, "")
+		}
+		d.pdf.Ln(-1)
+	}
+}
+
+// Summary writes right aligned label and value pairs, such as the totals below a table
+func (d *Document) Summary(labelWidth, valueWidth float64, pairs [][2]string) {
+	d.pdf.SetFont("Helvetica", "", 10)
+	for i, pair := range pairs {
+		if i == len(pairs)-1 {
+			d.pdf.SetFont("Helvetica", "B", 10)
+		}
+		d.pdf.CellFormat(labelWidth, 7, d.translate(pair[0]), "", 0, "R", false, 0, "")
+		d.pdf.CellFormat(valueWidth, 7, d.translate(pair[1]), "", 1, "R", false, 0, "")
+	}
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func alignment(column int) string {
+	if column == 0 {
+		return "L"
+	}
+	return "R"
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestGenerateBarcodeValue(t *testing.T) {
+	value, err := GenerateBarcodeValue()
+	if err != nil {
+		t.Fatalf("GenerateBarcodeValue: %v", err)
+	}
+	if len(value) != barcodeDigits {
+		t.Fatalf("barcode %q has %d digits, want %d", value, len(value), barcodeDigits)
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			t.Fatalf("barcode %q is not numeric", value)
+		}
+	}
+}
+
+func TestRenderBarcodePNG(t *testing.T) {
+	data, err := RenderBarcodePNG("1234567890123456", 400, 120)
+	if err != nil {
+		t.Fatalf("RenderBarcodePNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode barcode PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 400 || size.Y != 120 {
+		t.Fatalf("barcode image is %dx%d, want 400x120", size.X, size.Y)
+	}
+}
@@ -1,35 +1,99 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/skip2/go-qrcode"
 )
 
-// GenerateQRCode generates a QR code for a ticket
-func GenerateQRCode(ticketID uint, eventID uint, userID uint) (string, error) {
+// qrPrefix starts every ticket QR payload
+const qrPrefix = "TICKET-"
+
+// Errors returned by ValidateQRCode
+var (
+	ErrInvalidQRCode   = errors.New("invalid QR code format")
+	ErrQRCodeSignature = errors.New("QR code signature does not match")
+)
+
+// QRClaims are the components of a verified QR payload
+type QRClaims struct {
+	TicketID uint
+	EventID  uint
+	UserID   uint
+}
+
+// GenerateQRCode generates the unique text payload encoded in a ticket's QR code. This is what
+// tickets store and scanners read; RenderQRCodePNG draws the image from it on demand. With a
+// secret the payload ends in its HMAC signature, which ValidateQRCode checks.
+func GenerateQRCode(eventID uint, userID uint, ticketID uint, secret []byte) string {
 	// Create unique QR data using UUID and timestamp
-	qrData := fmt.Sprintf("TICKET-%d-%d-%d-%s-%d",
-		ticketID, eventID, userID, uuid.New().String(), time.Now().UnixNano())
+	payload := fmt.Sprintf(qrPrefix+"%d-%d-%d-%s-%d",
+		eventID, userID, ticketID, uuid.New().String(), time.Now().UnixNano())
+	if len(secret) == 0 {
+		return payload
+	}
+	return payload + "-" + SignQRPayload(payload, secret)
+}
+
+// SignQRPayload returns the hex encoded HMAC-SHA256 of a QR payload under secret, letting a
+// holder of the secret check that the payload was issued by the server
+func SignQRPayload(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
 
-	// Generate QR code as bytes
-	qrBytes, err := qrcode.Encode(qrData, qrcode.Medium, 256)
+// RenderQRCodePNG renders a QR payload as a PNG image
+func RenderQRCodePNG(payload string, size int) ([]byte, error) {
+	png, err := qrcode.Encode(payload, qrcode.Medium, size)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate QR code: %v", err)
+		return nil, fmt.Errorf("failed to render QR code: %v", err)
 	}
-
-	return string(qrBytes), nil
+	return png, nil
 }
 
-// ValidateQRCode validates QR code data
-func ValidateQRCode(qrData string) (bool, error) {
-	// Basic validation - check if QR data follows expected format
-	expectedPrefix := "TICKET-"
-	if len(qrData) < len(expectedPrefix) {
-		return false, fmt.Errorf("invalid QR code format")
+// ValidateQRCode checks that a QR payload was signed under secret by GenerateQRCode and returns
+// its components. Truncated or malformed payloads return ErrInvalidQRCode, and forged or
+// tampered ones ErrQRCodeSignature.
+func ValidateQRCode(qrData string, secret []byte) (QRClaims, error) {
+	if len(secret) == 0 {
+		return QRClaims{}, errors.New("QR signing secret is not set")
+	}
+	if !strings.HasPrefix(qrData, qrPrefix) {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+
+	// The signature is the last component; the UUID before it contains dashes of its own
+	cut := strings.LastIndex(qrData, "-")
+	payload, signature := qrData[:cut], qrData[cut+1:]
+	if len(signature) != hex.EncodedLen(sha256.Size) {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignQRPayload(payload, secret))) {
+		return QRClaims{}, ErrQRCodeSignature
 	}
 
-	return true, nil
-}
\ No newline at end of file
+	// event, holder, ticket, then the five UUID groups and the timestamp
+	parts := strings.Split(strings.TrimPrefix(payload, qrPrefix), "-")
+	if len(parts) != 9 {
+		return QRClaims{}, ErrInvalidQRCode
+	}
+	var ids [3]uint
+	for i := range ids {
+		id, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil {
+			return QRClaims{}, ErrInvalidQRCode
+		}
+		ids[i] = uint(id)
+	}
+
+	return QRClaims{EventID: ids[0], UserID: ids[1], TicketID: ids[2]}, nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateQRCode(t *testing.T) {
+	secret := []byte("test-qr-signing-secret-of-32-bytes!")
+	payload := GenerateQRCode(12, 34, 56, secret)
+	cut := strings.LastIndex(payload, "-")
+	unsigned, signature := payload[:cut], payload[cut+1:]
+
+	forged := []byte(signature)
+	if forged[0] == 'a' {
+		forged[0] = 'b'
+	} else {
+		forged[0] = 'a'
+	}
+
+	tests := []struct {
+		name    string
+		qrData  string
+		secret  []byte
+		want    QRClaims
+		wantErr error
+	}{
+		{name: "signed payload", qrData: payload, secret: secret, want: QRClaims{TicketID: 56, EventID: 12, UserID: 34}},
+		{name: "unsigned payload", qrData: GenerateQRCode(12, 34, 56, nil), secret: secret, wantErr: ErrInvalidQRCode},
+		{name: "truncated signature", qrData: payload[:len(payload)-1], secret: secret, wantErr: ErrInvalidQRCode},
+		{name: "truncated payload", qrData: "TICKET-12-34-" + signature, secret: secret, wantErr: ErrQRCodeSignature},
+		{name: "forged signature", qrData: unsigned + "-" + string(forged), secret: secret, wantErr: ErrQRCodeSignature},
+		{name: "tampered ticket", qrData: strings.Replace(unsigned, "-56-", "-57-", 1) + "-" + signature, secret: secret, wantErr: ErrQRCodeSignature},
+		{name: "other secret", qrData: payload, secret: []byte("another-secret-of-at-least-32-bytes"), wantErr: ErrQRCodeSignature},
+		{name: "missing prefix", qrData: strings.TrimPrefix(payload, qrPrefix), secret: secret, wantErr: ErrInvalidQRCode},
+		{name: "empty", qrData: "", secret: secret, wantErr: ErrInvalidQRCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ValidateQRCode(tt.qrData, tt.secret)
+			if err != tt.wantErr {
+				t.Fatalf("ValidateQRCode() error = %v, want %v", err, tt.wantErr)
+			}
+			if claims != tt.want {
+				t.Errorf("ValidateQRCode() claims = %+v, want %+v", claims, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateQRCodeRequiresSecret(t *testing.T) {
+	if _, err := ValidateQRCode(GenerateQRCode(1, 2, 3, nil), nil); err == nil {
+		t.Fatal("ValidateQRCode() accepted a payload without a secret")
+	}
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed with the endpoint's
+// secret, so receivers can verify a delivery came from this server
+const SignatureHeader = "X-Signature"
+
+// Event is a notification delivered to a webhook endpoint
+type Event struct {
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Notifier delivers webhook events
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// HTTPNotifier posts events as JSON to a single endpoint, signing them when Secret is set
+type HTTPNotifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// Notify posts the event to the configured endpoint, treating non-2xx responses as failures
+func (n *HTTPNotifier) Notify(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook: %v", err)
+	}
+	return Deliver(n.Client, n.URL, n.Secret, body)
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver posts an encoded event to url, signing it when secret is set, and treats non-2xx
+// responses as failures
+func Deliver(client *http.Client, url, secret string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to send webhook: endpoint returned %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// LogNotifier writes events to the application log instead of delivering them
+type LogNotifier struct{}
+
+// Notify logs the event
+func (LogNotifier) Notify(event Event) error {
+	log.Printf("Webhook %s: %+v", event.Type, event.Data)
+	return nil
+}
+
+// NewFromEnv creates a notifier posting to WEBHOOK_URL, signed with WEBHOOK_SECRET when set, or
+// one that logs events when WEBHOOK_URL is not set
+func NewFromEnv() Notifier {
+	url := os.Getenv("WEBHOOK_URL")
+	if url == "" {
+		return LogNotifier{}
+	}
+	return &HTTPNotifier{URL: url, Secret: os.Getenv("WEBHOOK_SECRET")}
+}